@@ -0,0 +1,288 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/yunarta/golang-quality-of-life-pack/collections"
+	"github.com/yunarta/terraform-atlassian-api-client/jira"
+	"github.com/yunarta/terraform-atlassian-api-client/util"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
+	"slices"
+)
+
+// ResolvedActor is a user or group of the assignments, found on the site.
+type ResolvedActor struct {
+	// Reference is how the assignments name the actor, by name or by ID
+	Reference string
+	// Id is the account ID of a user or the ID of a group, actors are compared by it
+	Id string
+	// Name is the email or display name of a user, or the name of a group, the diagnostics report the actor with
+	Name        string
+	Permissions []string
+	// Leftover is an actor the assignments no longer name, kept until its permissions are revoked
+	Leftover bool
+}
+
+// UserReferences returns the users of the assignments, named by email or display name, and by account ID.
+func (order AssignmentOrder) UserReferences() []string {
+	return collections.Unique(append(slices.Clone(order.UserNames), order.AccountIds...))
+}
+
+// GroupReferences returns the groups of the assignments, named by name and by group ID.
+func (order AssignmentOrder) GroupReferences() []string {
+	return collections.Unique(append(slices.Clone(order.GroupNames), order.GroupIds...))
+}
+
+// FindUser looks up a user of the assignments. A user named by email that is no longer found, because the email
+// changed, is looked up by the account ID it resolved to on the last apply.
+func (order AssignmentOrder) FindUser(actorLookupService *lookup.ActorLookupService, user string) *jira.User {
+	if slices.Contains(order.AccountIds, user) {
+		return actorLookupService.FindUserById(user)
+	}
+
+	if found := actorLookupService.FindUser(user); found != nil {
+		return found
+	}
+
+	if accountId, ok := order.knownUsers[user]; ok {
+		return actorLookupService.FindUserById(accountId)
+	}
+
+	return nil
+}
+
+// FindGroup looks up a group of the assignments. A renamed group is looked up by the group ID it resolved to
+// on the last apply.
+func (order AssignmentOrder) FindGroup(actorLookupService *lookup.ActorLookupService, group string) *jira.Group {
+	if slices.Contains(order.GroupIds, group) {
+		return actorLookupService.FindGroupById(group)
+	}
+
+	if found := actorLookupService.FindGroup(group); found != nil {
+		return found
+	}
+
+	if groupId, ok := order.knownGroups[group]; ok {
+		return actorLookupService.FindGroupById(groupId)
+	}
+
+	return nil
+}
+
+// ResolveUsers looks up the users of the assignments with their permissions, and the leftover users, users that are
+// not found are left out.
+// A user named twice, by email and by account ID, is an error as the assignments could disagree on its permissions.
+func (order AssignmentOrder) ResolveUsers(actorLookupService *lookup.ActorLookupService) ([]ResolvedActor, diag.Diagnostics) {
+	var resolved []ResolvedActor
+	var references = map[string]string{}
+	for _, user := range order.UserReferences() {
+		found := order.FindUser(actorLookupService, user)
+		if found == nil {
+			continue
+		}
+
+		if other, ok := references[found.AccountID]; ok {
+			return nil, []diag.Diagnostic{diag.NewErrorDiagnostic("User named twice in assignments",
+				fmt.Sprintf("%q and %q are the same user, name it the same way in every assignment", other, user))}
+		}
+		references[found.AccountID] = user
+
+		resolved = append(resolved, ResolvedActor{
+			Reference:   user,
+			Id:          found.AccountID,
+			Name:        util.CoalesceString(found.EmailAddress, found.DisplayName),
+			Permissions: order.Users[user],
+		})
+	}
+
+	for _, leftover := range order.leftoverUsers {
+		found := actorLookupService.FindUserById(leftover.Id.ValueString())
+		if found == nil {
+			continue
+		}
+
+		if _, ok := references[found.AccountID]; ok {
+			continue
+		}
+		references[found.AccountID] = leftover.Name
+
+		resolved = append(resolved, ResolvedActor{
+			Reference:   leftover.Name,
+			Id:          found.AccountID,
+			Name:        util.CoalesceString(found.EmailAddress, found.DisplayName),
+			Permissions: leftover.Permissions,
+			Leftover:    true,
+		})
+	}
+
+	return resolved, nil
+}
+
+// ResolveGroups looks up the groups of the assignments with their permissions, and the leftover groups, groups that
+// are not found are left out.
+// A group named twice, by name and by group ID, is an error as the assignments could disagree on its permissions.
+func (order AssignmentOrder) ResolveGroups(actorLookupService *lookup.ActorLookupService) ([]ResolvedActor, diag.Diagnostics) {
+	var resolved []ResolvedActor
+	var references = map[string]string{}
+	for _, group := range order.GroupReferences() {
+		found := order.FindGroup(actorLookupService, group)
+		if found == nil {
+			continue
+		}
+
+		if other, ok := references[found.GroupId]; ok {
+			return nil, []diag.Diagnostic{diag.NewErrorDiagnostic("Group named twice in assignments",
+				fmt.Sprintf("%q and %q are the same group, name it the same way in every assignment", other, group))}
+		}
+		references[found.GroupId] = group
+
+		resolved = append(resolved, ResolvedActor{
+			Reference:   group,
+			Id:          found.GroupId,
+			Name:        found.Name,
+			Permissions: order.Groups[group],
+		})
+	}
+
+	for _, leftover := range order.leftoverGroups {
+		found := actorLookupService.FindGroupById(leftover.Id.ValueString())
+		if found == nil {
+			continue
+		}
+
+		if _, ok := references[found.GroupId]; ok {
+			continue
+		}
+		references[found.GroupId] = leftover.Name
+
+		resolved = append(resolved, ResolvedActor{
+			Reference:   leftover.Name,
+			Id:          found.GroupId,
+			Name:        found.Name,
+			Permissions: leftover.Permissions,
+			Leftover:    true,
+		})
+	}
+
+	return resolved, nil
+}
+
+// PermissionsById returns the permissions of the actors by their ID.
+func PermissionsById(actors []ResolvedActor) map[string][]string {
+	var permissions = map[string][]string{}
+	for _, actor := range actors {
+		permissions[actor.Id] = actor.Permissions
+	}
+
+	return permissions
+}
+
+// RegisterActors prepares the lookup service with the users and groups of the orders.
+func RegisterActors(actorLookupService *lookup.ActorLookupService, orders ...AssignmentOrder) {
+	var userNames, accountIds, groupNames, groupIds []string
+	for _, order := range orders {
+		userNames = append(userNames, order.UserNames...)
+		accountIds = append(accountIds, order.AccountIds...)
+		groupNames = append(groupNames, order.GroupNames...)
+		groupIds = append(groupIds, order.GroupIds...)
+		for _, leftover := range order.leftoverUsers {
+			accountIds = append(accountIds, leftover.Id.ValueString())
+		}
+		for _, leftover := range order.leftoverGroups {
+			groupIds = append(groupIds, leftover.Id.ValueString())
+		}
+	}
+
+	actorLookupService.RegisterUsernames(collections.Unique(userNames)...)
+	actorLookupService.RegisterGroupNames(collections.Unique(groupNames)...)
+	if len(accountIds) > 0 {
+		actorLookupService.RegisterAccountIds(collections.Unique(accountIds)...)
+	}
+	actorLookupService.RegisterGroupIds(collections.Unique(groupIds)...)
+}
+
+// knownActor is an element of the computed users or groups, the ID is null in a state written before it was kept
+type knownActor struct {
+	Name        string       `tfsdk:"name"`
+	Id          types.String `tfsdk:"id"`
+	Permissions []string     `tfsdk:"permissions"`
+}
+
+// UseKnownIds takes the IDs the actors resolved to on the last apply from the computed users and groups,
+// so that an actor renamed since is still found by FindUser and FindGroup.
+func (order *AssignmentOrder) UseKnownIds(ctx context.Context, result AssignmentResult) diag.Diagnostics {
+	knownUsers, diags := readKnownIds(ctx, result.ComputedUsers)
+	if diags != nil {
+		return diags
+	}
+
+	knownGroups, diags := readKnownIds(ctx, result.ComputedGroups)
+	if diags != nil {
+		return diags
+	}
+
+	order.knownUsers = knownUsers
+	order.knownGroups = knownGroups
+	return nil
+}
+
+// KeepLeftovers adds the actors of the computed users and groups that the assignments no longer name. They are only
+// there when revoking their permissions failed, and are revoked on the next apply.
+func (order *AssignmentOrder) KeepLeftovers(ctx context.Context, result AssignmentResult) diag.Diagnostics {
+	users, diags := readKnownActors(ctx, result.ComputedUsers)
+	if diags != nil {
+		return diags
+	}
+
+	groups, diags := readKnownActors(ctx, result.ComputedGroups)
+	if diags != nil {
+		return diags
+	}
+
+	order.leftoverUsers = leftovers(users, order.UserReferences())
+	order.leftoverGroups = leftovers(groups, order.GroupReferences())
+	for _, leftover := range append(slices.Clone(order.leftoverUsers), order.leftoverGroups...) {
+		order.Permissions = collections.Unique(append(order.Permissions, leftover.Permissions...))
+	}
+
+	return nil
+}
+
+// leftovers returns the actors with an ID that are not named by references.
+func leftovers(actors []knownActor, references []string) []knownActor {
+	return slices.DeleteFunc(actors, func(actor knownActor) bool {
+		return actor.Id.IsNull() || actor.Id.IsUnknown() || slices.Contains(references, actor.Name)
+	})
+}
+
+func readKnownActors(ctx context.Context, computed types.List) ([]knownActor, diag.Diagnostics) {
+	if computed.IsNull() || computed.IsUnknown() {
+		return nil, nil
+	}
+
+	var actors []knownActor
+	diags := computed.ElementsAs(ctx, &actors, true)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	return actors, nil
+}
+
+func readKnownIds(ctx context.Context, computed types.List) (map[string]string, diag.Diagnostics) {
+	var ids = map[string]string{}
+	actors, diags := readKnownActors(ctx, computed)
+	if diags != nil {
+		return nil, diags
+	}
+
+	for _, actor := range actors {
+		if !actor.Id.IsNull() && !actor.Id.IsUnknown() {
+			ids[actor.Name] = actor.Id.ValueString()
+		}
+	}
+
+	return ids, nil
+}
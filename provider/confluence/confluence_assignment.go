@@ -4,7 +4,6 @@ import (
 	"context"
 	"github.com/emirpasic/gods/utils"
 	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
-	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -13,103 +12,152 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/yunarta/golang-quality-of-life-pack/collections"
 	"github.com/yunarta/terraform-atlassian-api-client/confluence"
-	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/parallel"
 	"slices"
 	"strings"
 )
 
+const (
+	// MergeOverride gives an actor named in several assignments the permissions of the one with the highest priority
+	MergeOverride = "override"
+	// MergeUnion gives an actor named in several assignments the permissions of all of them
+	MergeUnion = "union"
+)
+
 type Assignment struct {
 	Users       []string `tfsdk:"users"`
+	AccountIds  []string `tfsdk:"account_ids"`
 	Groups      []string `tfsdk:"groups"`
+	GroupIds    []string `tfsdk:"group_ids"`
 	Permissions []string `tfsdk:"permissions"`
 	Priority    int64    `tfsdk:"priority"`
 }
 
+// AssignmentOrder holds the permissions of the actors of the assignments, keyed by how the assignments name them.
 type AssignmentOrder struct {
 	Permissions []string
 	Users       map[string][]string
 	UserNames   []string
+	AccountIds  []string
 	Groups      map[string][]string
 	GroupNames  []string
+	GroupIds    []string
+	// UserPriorities and GroupPriorities are the priorities of the assignments naming each actor
+	UserPriorities  map[string][]int64
+	GroupPriorities map[string][]int64
+
+	// knownUsers and knownGroups are the IDs the actors resolved to on the last apply
+	knownUsers  map[string]string
+	knownGroups map[string]string
+	// leftoverUsers and leftoverGroups are the actors the assignments no longer name, whose permissions failed to be revoked
+	leftoverUsers  []knownActor
+	leftoverGroups []knownActor
 }
 
 type Assignments []Assignment
 
-type UpdateUserPermissionsFunc func(user string, requestedPermissions []string) error
-type UpdateGroupPermissionsFunc func(group string, requestedPermissions []string) error
+// UpdateUserPermissionsFunc gives the user with the account ID exactly the permissions requested.
+type UpdateUserPermissionsFunc func(accountId string, requestedPermissions []string) error
 
-func (assignments Assignments) CreateAssignmentOrder(ctx context.Context) (*AssignmentOrder, diag.Diagnostics) {
-	var priorities []int64
-	var makeAssignments = map[int64]Assignment{}
-	for _, assignment := range assignments {
-		priorities = append(priorities, assignment.Priority)
-		makeAssignments[assignment.Priority] = assignment
-	}
-	slices.SortFunc(priorities, func(a, b int64) int {
-		return utils.Int64Comparator(a, b)
+// UpdateGroupPermissionsFunc gives the group with the group ID exactly the permissions requested.
+type UpdateGroupPermissionsFunc func(groupId string, requestedPermissions []string) error
+
+// CreateAssignmentOrder resolves the permissions of every actor, going through the assignments by priority.
+// With MergeOverride an actor gets the permissions of the last assignment naming it, with MergeUnion the permissions
+// of all of them.
+func (assignments Assignments) CreateAssignmentOrder(ctx context.Context, mergeStrategy string) (*AssignmentOrder, diag.Diagnostics) {
+	sorted := slices.Clone(assignments)
+	slices.SortStableFunc(sorted, func(a, b Assignment) int {
+		return utils.Int64Comparator(a.Priority, b.Priority)
 	})
 
 	var usersAssignments = map[string][]string{}
 	var groupsAssignments = map[string][]string{}
 	var userNames = make([]string, 0)
+	var accountIds = make([]string, 0)
 	var groupNames = make([]string, 0)
+	var groupIds = make([]string, 0)
+	var userPriorities = map[string][]int64{}
+	var groupPriorities = map[string][]int64{}
 	var permissions = make([]string, 0)
-	for _, priority := range priorities {
-		assignment := makeAssignments[priority]
-		for _, user := range assignment.Users {
-			usersAssignments[user] = assignment.Permissions
-			userNames = append(userNames, user)
+	for _, assignment := range sorted {
+		priority := assignment.Priority
+		for _, user := range append(slices.Clone(assignment.Users), assignment.AccountIds...) {
+			usersAssignments[user] = mergeAssignment(mergeStrategy, usersAssignments[user], assignment.Permissions)
+			userPriorities[user] = appendPriority(userPriorities[user], priority)
 			permissions = append(permissions, assignment.Permissions...)
 		}
+		userNames = append(userNames, assignment.Users...)
+		accountIds = append(accountIds, assignment.AccountIds...)
 
-		for _, group := range assignment.Groups {
-			groupsAssignments[group] = assignment.Permissions
-			groupNames = append(groupNames, group)
+		for _, group := range append(slices.Clone(assignment.Groups), assignment.GroupIds...) {
+			groupsAssignments[group] = mergeAssignment(mergeStrategy, groupsAssignments[group], assignment.Permissions)
+			groupPriorities[group] = appendPriority(groupPriorities[group], priority)
 			permissions = append(permissions, assignment.Permissions...)
 		}
+		groupNames = append(groupNames, assignment.Groups...)
+		groupIds = append(groupIds, assignment.GroupIds...)
 	}
 
 	return &AssignmentOrder{
-		Permissions: collections.Unique(permissions),
-		Users:       usersAssignments,
-		UserNames:   userNames,
-		Groups:      groupsAssignments,
-		GroupNames:  groupNames,
+		Permissions:     collections.Unique(permissions),
+		Users:           usersAssignments,
+		UserNames:       userNames,
+		AccountIds:      accountIds,
+		Groups:          groupsAssignments,
+		GroupNames:      groupNames,
+		GroupIds:        groupIds,
+		UserPriorities:  userPriorities,
+		GroupPriorities: groupPriorities,
 	}, nil
 }
+
+// mergeAssignment combines the permissions an actor already has with the permissions of the next assignment naming it
+func mergeAssignment(mergeStrategy string, current []string, next []string) []string {
+	if mergeStrategy != MergeUnion || current == nil {
+		return next
+	}
+
+	return collections.Unique(append(slices.Clone(current), next...))
+}
+
+// appendPriority adds the priority once, an actor can be listed twice in the same assignment
+func appendPriority(priorities []int64, priority int64) []int64 {
+	if slices.Contains(priorities, priority) {
+		return priorities
+	}
+
+	return append(priorities, priority)
+}
 func AssignmentSchema() schema.ListNestedBlock {
 	return schema.ListNestedBlock{
+		Validators: []validator.List{
+			assignmentsValidator{},
+		},
 		NestedObject: schema.NestedBlockObject{
 			Attributes: map[string]schema.Attribute{
 				"users": schema.ListAttribute{
 					Optional:    true,
 					ElementType: types.StringType,
 				},
+				"account_ids": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+				},
 				"groups": schema.ListAttribute{
 					Optional:    true,
 					ElementType: types.StringType,
 				},
+				"group_ids": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+				},
 				"permissions": schema.ListAttribute{
 					Required:    true,
 					ElementType: types.StringType,
 					Validators: []validator.List{
-						listvalidator.ValueStringsAre(stringvalidator.OneOf(
-							"read_space",
-							"delete_space",
-							"create_page",
-							"delete_page",
-							"archive_page",
-							"create_blogpost",
-							"delete_blogpost",
-							"delete_comment",
-							"create_comment",
-							"create_attachment",
-							"delete_attachment",
-							"administer_space",
-							"restrict_content_space",
-							"export_space",
-							"administer_space",
-						)),
+						listvalidator.ValueStringsAre(SpacePermission()),
 					},
 				},
 				"priority": schema.Int64Attribute{
@@ -120,6 +168,8 @@ func AssignmentSchema() schema.ListNestedBlock {
 	}
 }
 
+// ComputedAssignmentSchema holds the permissions read from the site, the plan previews the permissions the assignments grant
+// so that a change made outside Terraform shows up as a difference.
 var ComputedAssignmentSchema = schema.ListNestedAttribute{
 	Computed: true,
 	NestedObject: schema.NestedAttributeObject{
@@ -127,6 +177,9 @@ var ComputedAssignmentSchema = schema.ListNestedAttribute{
 			"name": schema.StringAttribute{
 				Computed: true,
 			},
+			"id": schema.StringAttribute{
+				Computed: true,
+			},
 			"permissions": schema.ListAttribute{
 				Computed:    true,
 				ElementType: types.StringType,
@@ -135,8 +188,11 @@ var ComputedAssignmentSchema = schema.ListNestedAttribute{
 	},
 }
 
+// ComputedAssignment is an actor of the assignments with the permissions it holds, Name is how the assignments
+// name the actor and Id is its account ID or group ID.
 type ComputedAssignment struct {
 	Name        string   `tfsdk:"name"`
+	Id          string   `tfsdk:"id"`
 	Permissions []string `tfsdk:"permissions"`
 }
 
@@ -149,9 +205,15 @@ var assignmentType = types.ObjectType{
 		"users": types.ListType{
 			ElemType: types.StringType,
 		},
+		"account_ids": types.ListType{
+			ElemType: types.StringType,
+		},
 		"groups": types.ListType{
 			ElemType: types.StringType,
 		},
+		"group_ids": types.ListType{
+			ElemType: types.StringType,
+		},
 	},
 }
 
@@ -159,6 +221,7 @@ var computedAssignmentType = types.ObjectType{
 	AttrTypes: map[string]attr.Type{
 		"permissions": types.ListType{ElemType: types.StringType},
 		"name":        types.StringType,
+		"id":          types.StringType,
 	},
 }
 
@@ -167,66 +230,88 @@ type AssignmentResult struct {
 	ComputedGroups types.List
 }
 
-func ApplyNewAssignmentSet(ctx context.Context, actorLookupService *cloud.ActorLookupService,
+// ApplyNewAssignmentSet grants the actors of the assignments their permissions, updating at most parallelism actors at once.
+func ApplyNewAssignmentSet(ctx context.Context, actorLookupService *lookup.ActorLookupService,
 	assignmentOrder AssignmentOrder,
+	parallelism int,
 	updateUserPermissions UpdateUserPermissionsFunc,
 	updateGroupPermissions UpdateGroupPermissionsFunc) (*AssignmentResult, diag.Diagnostics) {
 
-	var err error
+	users, diags := assignmentOrder.ResolveUsers(actorLookupService)
+	if diags != nil {
+		return nil, diags
+	}
+
+	groups, diags := assignmentOrder.ResolveGroups(actorLookupService)
+	if diags != nil {
+		return nil, diags
+	}
 
 	computedUsers := make([]ComputedAssignment, 0)
 	computedGroups := make([]ComputedAssignment, 0)
+	var updates []parallel.Update
 
-	for user, requestedPermissions := range assignmentOrder.Users {
-		found := actorLookupService.FindUser(user)
-		if found == nil {
-			continue
-		}
-
+	for _, user := range users {
 		computedUsers = append(computedUsers, ComputedAssignment{
-			Name:        user,
-			Permissions: requestedPermissions,
+			Name:        user.Reference,
+			Id:          user.Id,
+			Permissions: user.Permissions,
 		})
-
-		err = updateUserPermissions(user, requestedPermissions)
-		if err != nil {
-			return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToUpdateUserPermissions, err.Error())}
-		}
+		updates = append(updates, parallel.Update{Name: user.Name, Id: user.Id, Grants: user.Permissions, Apply: updateUserPermissions, Failure: failedToUpdateUserPermissions})
 	}
 
-	for group, requestedPermissions := range assignmentOrder.Groups {
-		found := actorLookupService.FindGroup(group)
-		if found == nil {
-			continue
-		}
-
+	for _, group := range groups {
 		computedGroups = append(computedGroups, ComputedAssignment{
-			Name:        group,
-			Permissions: requestedPermissions,
+			Name:        group.Reference,
+			Id:          group.Id,
+			Permissions: group.Permissions,
 		})
+		updates = append(updates, parallel.Update{Name: group.Name, Id: group.Id, Grants: group.Permissions, Apply: updateGroupPermissions, Failure: failedToUpdateGroupPermissions})
+	}
 
-		err = updateGroupPermissions(group, requestedPermissions)
-		if err != nil {
-			return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToUpdateGroupPermissions, err.Error())}
-		}
+	diags = parallel.Diagnostics(updates, parallel.Run(parallelism, updates))
+	if diags != nil {
+		return nil, diags
 	}
 
 	return createAssignmentResult(ctx, computedUsers, computedGroups)
 }
 
-func UpdateAssignment(ctx context.Context, actorLookupService *cloud.ActorLookupService,
+// PreviewAssignment computes the assignments an apply of assignmentOrder results in, without changing any permission.
+func PreviewAssignment(ctx context.Context, actorLookupService *lookup.ActorLookupService,
+	assignmentOrder AssignmentOrder) (*AssignmentResult, diag.Diagnostics) {
+	return ApplyNewAssignmentSet(ctx, actorLookupService, assignmentOrder, 1,
+		func(accountId string, requestedPermissions []string) error {
+			return nil
+		},
+		func(groupId string, requestedPermissions []string) error {
+			return nil
+		},
+	)
+}
+
+// UpdateAssignment changes the permissions of the actors from the in state assignments to the planned ones, updating at
+// most parallelism actors at once.
+func UpdateAssignment(ctx context.Context, actorLookupService *lookup.ActorLookupService,
 	inStateAssignmentOrder AssignmentOrder,
 	plannedAssignmentOrder AssignmentOrder,
 	forceUpdate bool,
+	parallelism int,
 	updateUserPermission UpdateUserPermissionsFunc,
 	updateGroupPermission UpdateGroupPermissionsFunc) (*AssignmentResult, diag.Diagnostics) {
 
-	computedUsers, diags := updateUsers(inStateAssignmentOrder, plannedAssignmentOrder, actorLookupService, forceUpdate, updateUserPermission)
+	computedUsers, userUpdates, diags := updateUsers(inStateAssignmentOrder, plannedAssignmentOrder, actorLookupService, forceUpdate, updateUserPermission)
 	if diags != nil {
 		return nil, diags
 	}
 
-	computedGroups, diags := updateGroups(inStateAssignmentOrder, plannedAssignmentOrder, actorLookupService, forceUpdate, updateGroupPermission)
+	computedGroups, groupUpdates, diags := updateGroups(inStateAssignmentOrder, plannedAssignmentOrder, actorLookupService, forceUpdate, updateGroupPermission)
+	if diags != nil {
+		return nil, diags
+	}
+
+	updates := append(userUpdates, groupUpdates...)
+	diags = parallel.Diagnostics(updates, parallel.Run(parallelism, updates))
 	if diags != nil {
 		return nil, diags
 	}
@@ -234,138 +319,236 @@ func UpdateAssignment(ctx context.Context, actorLookupService *cloud.ActorLookup
 	return createAssignmentResult(ctx, computedUsers, computedGroups)
 }
 
+// updateUsers compares the users by account ID, so that a user whose email changed keeps its permissions
+// instead of being removed and added again.
 func updateUsers(inStateAssignmentOrder AssignmentOrder, plannedAssignmentOrder AssignmentOrder,
-	actorLookupService *cloud.ActorLookupService, forceUpdate bool, updateUserPermissions UpdateUserPermissionsFunc) ([]ComputedAssignment, diag.Diagnostics) {
-	var err error
+	actorLookupService *lookup.ActorLookupService, forceUpdate bool, updateUserPermissions UpdateUserPermissionsFunc) ([]ComputedAssignment, []parallel.Update, diag.Diagnostics) {
+	inStateUsers, diags := inStateAssignmentOrder.ResolveUsers(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
 
-	var computedUsers = make([]ComputedAssignment, 0)
-	_, removing := collections.Delta(inStateAssignmentOrder.UserNames, plannedAssignmentOrder.UserNames)
-	for _, user := range plannedAssignmentOrder.UserNames {
-		if collections.Contains(removing, user) {
-			continue
-		}
+	plannedUsers, diags := plannedAssignmentOrder.ResolveUsers(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
 
-		found := actorLookupService.FindUser(user)
-		if found == nil {
-			continue
-		}
+	computed, updates := updateActors(inStateUsers, plannedUsers, forceUpdate, updateUserPermissions, failedToUpdateUserPermissions, failedToRemoveUserPermissions)
+	return computed, updates, nil
+}
 
-		requestedPermissions := plannedAssignmentOrder.Users[user]
-		inStatePermissions := inStateAssignmentOrder.Users[user]
-		computedUsers = append(computedUsers, ComputedAssignment{
-			Name:        user,
-			Permissions: requestedPermissions,
+// updateGroups compares the groups by group ID, so that a renamed group keeps its permissions
+// instead of being removed and added again.
+func updateGroups(inStateAssignmentOrder AssignmentOrder, plannedAssignmentOrder AssignmentOrder,
+	actorLookupService *lookup.ActorLookupService, forceUpdate bool, updateGroupPermissions UpdateGroupPermissionsFunc) ([]ComputedAssignment, []parallel.Update, diag.Diagnostics) {
+	inStateGroups, diags := inStateAssignmentOrder.ResolveGroups(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
+
+	plannedGroups, diags := plannedAssignmentOrder.ResolveGroups(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
+
+	computed, updates := updateActors(inStateGroups, plannedGroups, forceUpdate, updateGroupPermissions, failedToUpdateGroupPermissions, failedToRemoveGroupPermissions)
+	return computed, updates, nil
+}
+
+// updateActors returns the computed assignments of the planned actors, and the updates of the actors whose permissions
+// change, the actors only in state lose their permissions.
+func updateActors(inStateActors []ResolvedActor, plannedActors []ResolvedActor, forceUpdate bool,
+	updatePermissions func(id string, requestedPermissions []string) error,
+	failedToUpdate string, failedToRemove string) ([]ComputedAssignment, []parallel.Update) {
+	var computed = make([]ComputedAssignment, 0)
+	var updates []parallel.Update
+
+	inStatePermissions := PermissionsById(inStateActors)
+	plannedPermissions := PermissionsById(plannedActors)
+	for _, actor := range plannedActors {
+		computed = append(computed, ComputedAssignment{
+			Name:        actor.Reference,
+			Id:          actor.Id,
+			Permissions: actor.Permissions,
 		})
 
-		if !collections.EqualsIgnoreOrder(inStatePermissions, requestedPermissions) || forceUpdate {
-			err = updateUserPermissions(user, requestedPermissions)
-			if err != nil {
-				return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToUpdateUserPermissions, err.Error())}
-			}
+		if !collections.EqualsIgnoreOrder(inStatePermissions[actor.Id], actor.Permissions) || forceUpdate {
+			updates = append(updates, parallel.Update{Name: actor.Name, Id: actor.Id, Grants: actor.Permissions, Apply: updatePermissions, Failure: failedToUpdate})
 		}
 	}
 
-	for _, user := range removing {
-		err := updateUserPermissions(user, make([]string, 0))
-		if err != nil {
-			return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveUserPermissions, err.Error())}
+	for _, actor := range inStateActors {
+		if _, ok := plannedPermissions[actor.Id]; ok {
+			continue
 		}
+
+		updates = append(updates, parallel.Update{Name: actor.Name, Id: actor.Id, Grants: make([]string, 0), Apply: updatePermissions, Failure: failedToRemove})
 	}
-	return computedUsers, nil
+
+	return computed, updates
 }
 
-func updateGroups(inStateAssignmentOrder AssignmentOrder, plannedAssignmentOrder AssignmentOrder,
-	actorLookupService *cloud.ActorLookupService, forceUpdate bool, updateGroupPermissions UpdateGroupPermissionsFunc) ([]ComputedAssignment, diag.Diagnostics) {
-	var err error
-	var computedGroups = make([]ComputedAssignment, 0)
+// RemoveAssignment revokes every permission of the actors of the assignments, updating at most parallelism actors
+// at once.
+func RemoveAssignment(ctx context.Context, actorLookupService *lookup.ActorLookupService,
+	assignedPermissions *confluence.ObjectPermissions, assignmentOrder *AssignmentOrder,
+	parallelism int,
+	updateUserPermissions UpdateUserPermissionsFunc,
+	updateGroupPermissions UpdateGroupPermissionsFunc) diag.Diagnostics {
 
-	_, removing := collections.Delta(inStateAssignmentOrder.GroupNames, plannedAssignmentOrder.GroupNames)
-	for _, group := range plannedAssignmentOrder.GroupNames {
-		if collections.Contains(removing, group) {
-			continue
+	users, diags := assignmentOrder.ResolveUsers(actorLookupService)
+	if diags != nil {
+		return diags
+	}
+
+	groups, diags := assignmentOrder.ResolveGroups(actorLookupService)
+	if diags != nil {
+		return diags
+	}
+
+	var updates []parallel.Update
+	for _, user := range users {
+		if assignedPermissions.FindUser(user.Id) != nil {
+			updates = append(updates, parallel.Update{Name: user.Name, Id: user.Id, Grants: make([]string, 0), Apply: updateUserPermissions, Failure: failedToRemoveUserPermissions})
 		}
+	}
 
-		found := actorLookupService.FindGroup(group)
-		if found == nil {
-			continue
+	for _, group := range groups {
+		if assignedPermissions.FindGroup(group.Id) != nil {
+			updates = append(updates, parallel.Update{Name: group.Name, Id: group.Id, Grants: make([]string, 0), Apply: updateGroupPermissions, Failure: failedToRemoveGroupPermissions})
 		}
+	}
 
-		requestedPermissions := plannedAssignmentOrder.Groups[group]
-		inStatePermissions := inStateAssignmentOrder.Groups[group]
-		computedGroups = append(computedGroups, ComputedAssignment{
-			Name:        group,
-			Permissions: requestedPermissions,
-		})
+	return parallel.Diagnostics(updates, parallel.Run(parallelism, updates))
+}
 
-		if !collections.EqualsIgnoreOrder(inStatePermissions, requestedPermissions) || forceUpdate {
-			err = updateGroupPermissions(group, requestedPermissions)
-			if err != nil {
-				return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToUpdateGroupPermissions, err.Error())}
-			}
+// ComputePermissionAssignments computes the permissions of the actors in the assignments. Actors that exist but hold
+// no permission are included without permissions, so that a removal made outside Terraform shows up as drift.
+func ComputePermissionAssignments(ctx context.Context, actorLookupService *lookup.ActorLookupService,
+	assignedPermissions *confluence.ObjectPermissions, assignmentOrder AssignmentOrder) (*AssignmentResult, diag.Diagnostics) {
+	computedUsers, computedGroups, diags := computeAssignments(actorLookupService, assignedPermissions, assignmentOrder)
+	if diags != nil {
+		return nil, diags
+	}
+
+	return createAssignmentResult(ctx, computedUsers, computedGroups)
+}
+
+// ComputeAppliedAssignment computes the assignments an apply that failed part way left, from the permissions the
+// actors hold now. The actors of the in state assignments that are not planned are kept while they hold a permission,
+// as leftovers the next apply revokes.
+func ComputeAppliedAssignment(ctx context.Context, actorLookupService *lookup.ActorLookupService,
+	assignedPermissions *confluence.ObjectPermissions, plannedAssignmentOrder AssignmentOrder, inStateAssignmentOrder *AssignmentOrder) (*AssignmentResult, diag.Diagnostics) {
+	computedUsers, computedGroups, diags := computeAssignments(actorLookupService, assignedPermissions, plannedAssignmentOrder)
+	if diags != nil {
+		return nil, diags
+	}
+
+	if inStateAssignmentOrder != nil {
+		inStateUsers, inStateGroups, diags := computeAssignments(actorLookupService, assignedPermissions, *inStateAssignmentOrder)
+		if diags != nil {
+			return nil, diags
 		}
+
+		computedUsers = appendLeftovers(computedUsers, inStateUsers)
+		computedGroups = appendLeftovers(computedGroups, inStateGroups)
 	}
 
-	for _, group := range removing {
-		err := updateGroupPermissions(group, make([]string, 0))
-		if err != nil {
-			return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveGroupPermissions, err.Error())}
+	return createAssignmentResult(ctx, computedUsers, computedGroups)
+}
+
+// appendLeftovers appends the in state actors that are not planned and still hold a permission.
+func appendLeftovers(computed []ComputedAssignment, inState []ComputedAssignment) []ComputedAssignment {
+	for _, actor := range inState {
+		planned := slices.ContainsFunc(computed, func(assignment ComputedAssignment) bool {
+			return assignment.Id == actor.Id
+		})
+		if !planned && len(actor.Permissions) > 0 {
+			computed = append(computed, actor)
 		}
 	}
 
-	return computedGroups, nil
+	return computed
 }
 
-func RemoveAssignment(ctx context.Context,
-	assignedPermissions *confluence.ObjectPermissions, assignmentOrder *AssignmentOrder,
-	updateUserPermissions UpdateUserPermissionsFunc,
-	updateGroupPermissions UpdateGroupPermissionsFunc) diag.Diagnostics {
+// ComputeAuthoritativeAssignment computes the assignments like ComputePermissionAssignments, and adds the unmanaged actors
+// so that they show up as drift.
+func ComputeAuthoritativeAssignment(ctx context.Context, actorLookupService *lookup.ActorLookupService,
+	assignedPermissions *confluence.ObjectPermissions, assignmentOrder AssignmentOrder, unmanaged UnmanagedActors) (*AssignmentResult, diag.Diagnostics) {
+	computedUsers, computedGroups, diags := computeAssignments(actorLookupService, assignedPermissions, assignmentOrder)
+	if diags != nil {
+		return nil, diags
+	}
 
-	for _, user := range assignedPermissions.Users {
-		if _, ok := assignmentOrder.Users[user.Name]; ok {
-			err := updateUserPermissions(user.Name, make([]string, 0))
-			if err != nil {
-				return []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveUserPermissions, err.Error())}
-			}
-		}
+	for _, user := range unmanaged.Users {
+		computedUsers = append(computedUsers, ComputedAssignment{
+			Name:        user.Name,
+			Id:          user.AccountId,
+			Permissions: user.Permissions,
+		})
 	}
 
-	for _, group := range assignedPermissions.Groups {
-		if _, ok := assignmentOrder.Groups[group.Name]; ok {
-			err := updateGroupPermissions(group.Name, make([]string, 0))
-			if err != nil {
-				return []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveGroupPermissions, err.Error())}
-			}
-		}
+	for _, group := range unmanaged.Groups {
+		computedGroups = append(computedGroups, ComputedAssignment{
+			Name:        group.Name,
+			Id:          group.AccountId,
+			Permissions: group.Permissions,
+		})
 	}
 
-	return nil
+	return createAssignmentResult(ctx, computedUsers, computedGroups)
 }
 
-func ComputePermissionAssignments(ctx context.Context,
-	assignedPermissions *confluence.ObjectPermissions, assignmentOrder AssignmentOrder) (*AssignmentResult, diag.Diagnostics) {
+func computeAssignments(actorLookupService *lookup.ActorLookupService,
+	assignedPermissions *confluence.ObjectPermissions, assignmentOrder AssignmentOrder) ([]ComputedAssignment, []ComputedAssignment, diag.Diagnostics) {
+	users, diags := assignmentOrder.ResolveUsers(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
+
+	groups, diags := assignmentOrder.ResolveGroups(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
 
 	computedUsers := make([]ComputedAssignment, 0)
 	computedGroups := make([]ComputedAssignment, 0)
 
-	for _, user := range assignedPermissions.Users {
-		if _, ok := assignmentOrder.Users[user.Name]; ok {
-			computedUsers = append(computedUsers, ComputedAssignment{
-				Name:        user.Name,
-				Permissions: user.Permissions,
-			})
+	for _, user := range users {
+		permissions := make([]string, 0)
+		if assigned := assignedPermissions.FindUser(user.Id); assigned != nil {
+			permissions = assigned.Permissions
 		}
+
+		if user.Leftover && len(permissions) == 0 {
+			continue
+		}
+
+		computedUsers = append(computedUsers, ComputedAssignment{
+			Name:        user.Reference,
+			Id:          user.Id,
+			Permissions: permissions,
+		})
 	}
 
-	for _, group := range assignedPermissions.Groups {
-		if _, ok := assignmentOrder.Groups[group.Name]; ok {
-			computedGroups = append(computedGroups, ComputedAssignment{
-				Name:        group.Name,
-				Permissions: group.Permissions,
-			})
+	for _, group := range groups {
+		permissions := make([]string, 0)
+		if assigned := assignedPermissions.FindGroup(group.Id); assigned != nil {
+			permissions = assigned.Permissions
+		}
+
+		if group.Leftover && len(permissions) == 0 {
+			continue
 		}
+
+		computedGroups = append(computedGroups, ComputedAssignment{
+			Name:        group.Reference,
+			Id:          group.Id,
+			Permissions: permissions,
+		})
 	}
 
-	return createAssignmentResult(ctx, computedUsers, computedGroups)
+	return computedUsers, computedGroups, nil
 }
 
 func createAssignmentResult(ctx context.Context, computedUsers []ComputedAssignment, computedGroups []ComputedAssignment) (*AssignmentResult, diag.Diagnostics) {
@@ -0,0 +1,63 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// assignmentsValidator rejects assignments sharing a priority, which would leave the order between them undefined,
+// and assignments without users or groups, named or referenced by ID.
+type assignmentsValidator struct{}
+
+var _ validator.List = assignmentsValidator{}
+
+func (v assignmentsValidator) Description(ctx context.Context) string {
+	return "assignments must have distinct priorities, and name at least one user or group"
+}
+
+func (v assignmentsValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v assignmentsValidator) ValidateList(ctx context.Context, request validator.ListRequest, response *validator.ListResponse) {
+	if request.ConfigValue.IsNull() || request.ConfigValue.IsUnknown() {
+		return
+	}
+
+	priorities := map[int64]int{}
+	for i, element := range request.ConfigValue.Elements() {
+		assignment, ok := element.(types.Object)
+		if !ok || assignment.IsUnknown() {
+			continue
+		}
+
+		attributes := assignment.Attributes()
+		priority, _ := attributes["priority"].(types.Int64)
+		if !priority.IsNull() && !priority.IsUnknown() {
+			if first, found := priorities[priority.ValueInt64()]; found {
+				response.Diagnostics.AddAttributeError(request.Path.AtListIndex(i).AtName("priority"), "Duplicate assignment priority",
+					fmt.Sprintf("Priority %d is already used by assignment %d, every assignment needs its own priority", priority.ValueInt64(), first+1),
+				)
+			} else {
+				priorities[priority.ValueInt64()] = i
+			}
+		}
+
+		users, _ := attributes["users"].(types.List)
+		groups, _ := attributes["groups"].(types.List)
+		accountIds, _ := attributes["account_ids"].(types.List)
+		groupIds, _ := attributes["group_ids"].(types.List)
+		if isEmpty(users) && isEmpty(groups) && isEmpty(accountIds) && isEmpty(groupIds) {
+			response.Diagnostics.AddAttributeError(request.Path.AtListIndex(i), "Empty assignment",
+				fmt.Sprintf("Assignment %d has no users or groups", i+1),
+			)
+		}
+	}
+}
+
+// isEmpty returns true for a list that is known to have no elements
+func isEmpty(list types.List) bool {
+	return !list.IsUnknown() && len(list.Elements()) == 0
+}
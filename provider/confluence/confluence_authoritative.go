@@ -0,0 +1,131 @@
+package confluence
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/yunarta/golang-quality-of-life-pack/collections"
+	"github.com/yunarta/terraform-atlassian-api-client/confluence"
+	"github.com/yunarta/terraform-atlassian-api-client/util"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// appAccountType is the account type of Connect and Forge apps, which hold space permissions on their own
+const appAccountType = "app"
+
+// UnmanagedActors are the users and groups holding space permissions without being in the assignments.
+type UnmanagedActors struct {
+	Users  []confluence.UserPermissions
+	Groups []confluence.GroupPermissions
+}
+
+// FindUnmanagedActors looks up the actors an authoritative space has to revoke. App users are always left alone,
+// and an entry of ignoreList matches a user email or account ID, or a group name or group ID.
+func FindUnmanagedActors(actorLookupService *lookup.ActorLookupService, assignedPermissions *confluence.ObjectPermissions,
+	assignmentOrder AssignmentOrder, ignoreList []string) UnmanagedActors {
+	var unmanaged UnmanagedActors
+	if assignedPermissions == nil {
+		return unmanaged
+	}
+
+	configuredUsers, configuredGroups := resolvedIds(actorLookupService, assignmentOrder)
+
+	for _, user := range assignedPermissions.Users {
+		name := user.Name
+		if found := actorLookupService.FindUserById(user.AccountId); found != nil {
+			if found.AccountType == appAccountType {
+				continue
+			}
+			name = util.CoalesceString(found.EmailAddress, found.DisplayName)
+		}
+
+		if collections.Contains(configuredUsers, user.AccountId) ||
+			collections.Contains(ignoreList, name) || collections.Contains(ignoreList, user.AccountId) {
+			continue
+		}
+
+		unmanaged.Users = append(unmanaged.Users, confluence.UserPermissions{
+			Name:        name,
+			AccountId:   user.AccountId,
+			Permissions: user.Permissions,
+		})
+	}
+
+	for _, group := range assignedPermissions.Groups {
+		if collections.Contains(configuredGroups, group.AccountId) ||
+			collections.Contains(ignoreList, group.Name) || collections.Contains(ignoreList, group.AccountId) {
+			continue
+		}
+
+		unmanaged.Groups = append(unmanaged.Groups, group)
+	}
+
+	return unmanaged
+}
+
+// Without leaves out the actors of assignmentOrder.
+func (unmanaged UnmanagedActors) Without(actorLookupService *lookup.ActorLookupService, assignmentOrder AssignmentOrder) UnmanagedActors {
+	userIds, groupIds := resolvedIds(actorLookupService, assignmentOrder)
+	return UnmanagedActors{
+		Users: slices.DeleteFunc(slices.Clone(unmanaged.Users), func(user confluence.UserPermissions) bool {
+			return collections.Contains(userIds, user.AccountId)
+		}),
+		Groups: slices.DeleteFunc(slices.Clone(unmanaged.Groups), func(group confluence.GroupPermissions) bool {
+			return collections.Contains(groupIds, group.AccountId)
+		}),
+	}
+}
+
+// resolvedIds returns the account IDs and group IDs of the actors of assignmentOrder, an actor named twice is
+// reported when the assignments are applied.
+func resolvedIds(actorLookupService *lookup.ActorLookupService, assignmentOrder AssignmentOrder) ([]string, []string) {
+	users, _ := assignmentOrder.ResolveUsers(actorLookupService)
+	groups, _ := assignmentOrder.ResolveGroups(actorLookupService)
+	return collections.GetKeysOfMap(PermissionsById(users)), collections.GetKeysOfMap(PermissionsById(groups))
+}
+
+// Remove revokes every permission of the unmanaged actors.
+func (unmanaged UnmanagedActors) Remove(
+	updateUserPermissions UpdateUserPermissionsFunc,
+	updateGroupPermissions UpdateGroupPermissionsFunc) diag.Diagnostics {
+
+	for _, user := range unmanaged.Users {
+		err := updateUserPermissions(user.AccountId, make([]string, 0))
+		if err != nil {
+			return []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveUserPermissions, err.Error())}
+		}
+	}
+
+	for _, group := range unmanaged.Groups {
+		err := updateGroupPermissions(group.AccountId, make([]string, 0))
+		if err != nil {
+			return []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveGroupPermissions, err.Error())}
+		}
+	}
+
+	return nil
+}
+
+// Drift describes the unmanaged actors as a warning, nil when there are none.
+func (unmanaged UnmanagedActors) Drift(spaceKey string) diag.Diagnostics {
+	var lines []string
+	for _, user := range unmanaged.Users {
+		lines = append(lines, fmt.Sprintf("user %s: %s", user.Name, strings.Join(collections.SortStrings(user.Permissions), ", ")))
+	}
+	for _, group := range unmanaged.Groups {
+		lines = append(lines, fmt.Sprintf("group %s: %s", group.Name, strings.Join(collections.SortStrings(group.Permissions), ", ")))
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	sort.Strings(lines)
+	return diag.Diagnostics{diag.NewWarningDiagnostic("Unmanaged space permissions",
+		fmt.Sprintf("Space %s has permissions of actors that are not in the assignments. The plan shows them leaving "+
+			"computed_users and computed_groups, and the apply revokes their permissions:\n  %s",
+			spaceKey, strings.Join(lines, "\n  ")),
+	)}
+}
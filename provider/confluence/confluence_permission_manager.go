@@ -0,0 +1,289 @@
+package confluence
+
+import (
+	"errors"
+	"fmt"
+	"github.com/yunarta/golang-quality-of-life-pack/collections"
+	"github.com/yunarta/terraform-atlassian-api-client/confluence"
+	confluenceCloud "github.com/yunarta/terraform-atlassian-api-client/confluence/cloud"
+	"github.com/yunarta/terraform-atlassian-api-client/util"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SpacePermissionManager reads the permissions of a space and changes them, one request per permission granted
+// or revoked. Unlike the manager of the client library it reports the requests that fail and keeps track of the
+// changes made, and the permissions of different actors may be changed at once.
+type SpacePermissionManager struct {
+	client             *confluenceCloud.ConfluenceClient
+	actorLookupService *lookup.ActorLookupService
+	spaceKey           string
+
+	// mutex guards assignedPermissions and permissionIds while the actors are updated in parallel
+	mutex               sync.Mutex
+	assignedPermissions *confluence.ObjectPermissions
+	// permissionIds are the IDs of the permissions held, by actor ID and permission
+	permissionIds map[string]string
+}
+
+func NewSpacePermissionManager(client *confluenceCloud.ConfluenceClient, actorLookupService *lookup.ActorLookupService, spaceKey string) *SpacePermissionManager {
+	return &SpacePermissionManager{
+		client:              client,
+		actorLookupService:  actorLookupService,
+		spaceKey:            spaceKey,
+		assignedPermissions: &confluence.ObjectPermissions{},
+		permissionIds:       map[string]string{},
+	}
+}
+
+// ReadPermissions reads the permissions of the users and groups of the space.
+func (manager *SpacePermissionManager) ReadPermissions() (*confluence.ObjectPermissions, error) {
+	space, err := manager.client.SpaceService().Read(manager.spaceKey)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := manager.client.SpacePermissionsService().Read(space.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var userPermissions, groupPermissions = map[string][]string{}, map[string][]string{}
+	var permissionIds = map[string]string{}
+	for _, permission := range *permissions {
+		slug := permission.Operation.GetSlug()
+		permissionIds[permissionKey(permission.Principal.Id, slug)] = permission.Id
+
+		switch permission.Principal.Type {
+		case confluence.PrincipalUser:
+			userPermissions[permission.Principal.Id] = append(userPermissions[permission.Principal.Id], slug)
+		case confluence.PrincipalGroup:
+			groupPermissions[permission.Principal.Id] = append(groupPermissions[permission.Principal.Id], slug)
+		}
+	}
+
+	lookupService := manager.actorLookupService
+	accountIds := collections.SortStrings(collections.GetKeysOfMap(userPermissions))
+	groupIds := collections.SortStrings(collections.GetKeysOfMap(groupPermissions))
+	if len(accountIds) > 0 {
+		lookupService.RegisterAccountIds(accountIds...)
+	}
+	lookupService.RegisterGroupIds(groupIds...)
+
+	var assignedPermissions = &confluence.ObjectPermissions{Users: []confluence.UserPermissions{}, Groups: []confluence.GroupPermissions{}}
+	for _, accountId := range accountIds {
+		var name string
+		if found := lookupService.FindUserById(accountId); found != nil {
+			name = util.CoalesceString(found.EmailAddress, found.DisplayName)
+		}
+
+		assignedPermissions.Users = append(assignedPermissions.Users, confluence.UserPermissions{
+			Name:        name,
+			AccountId:   accountId,
+			Permissions: userPermissions[accountId],
+		})
+	}
+
+	for _, groupId := range groupIds {
+		var name string
+		if found := lookupService.FindGroupById(groupId); found != nil {
+			name = found.Name
+		}
+
+		assignedPermissions.Groups = append(assignedPermissions.Groups, confluence.GroupPermissions{
+			Name:        name,
+			AccountId:   groupId,
+			Permissions: groupPermissions[groupId],
+		})
+	}
+
+	manager.assignedPermissions = assignedPermissions
+	manager.permissionIds = permissionIds
+	return assignedPermissions, nil
+}
+
+// UpdateUserPermissions grants and revokes permissions so that the user with the account ID holds exactly the
+// permissions given.
+func (manager *SpacePermissionManager) UpdateUserPermissions(accountId string, permissions []string) error {
+	found := manager.actorLookupService.FindUserById(accountId)
+	if found == nil {
+		return fmt.Errorf("unable to find user %s", accountId)
+	}
+
+	var assigned []string
+	manager.mutex.Lock()
+	if userPermissions := manager.assignedPermissions.FindUser(found.AccountID); userPermissions != nil {
+		assigned = userPermissions.Permissions
+	}
+	manager.mutex.Unlock()
+
+	return manager.update(confluence.PrincipalUser, found.AccountID, assigned, permissions)
+}
+
+// UpdateGroupPermissions grants and revokes permissions so that the group with the group ID holds exactly the
+// permissions given.
+func (manager *SpacePermissionManager) UpdateGroupPermissions(groupId string, permissions []string) error {
+	found := manager.actorLookupService.FindGroupById(groupId)
+	if found == nil {
+		return fmt.Errorf("unable to find group %s", groupId)
+	}
+
+	var assigned []string
+	manager.mutex.Lock()
+	if groupPermissions := manager.assignedPermissions.FindGroup(found.GroupId); groupPermissions != nil {
+		assigned = groupPermissions.Permissions
+	}
+	manager.mutex.Unlock()
+
+	return manager.update(confluence.PrincipalGroup, found.GroupId, assigned, permissions)
+}
+
+// update grants the permissions missing before revoking the others, read_space first as the other permissions
+// require it. It stops at the first request that fails.
+func (manager *SpacePermissionManager) update(principalType string, actorId string, assigned []string, permissions []string) error {
+	adding, removing := collections.Delta(assigned, permissions)
+	slices.SortFunc(adding, confluence.SortOperation)
+	slices.SortFunc(removing, confluence.SortOperation)
+
+	for _, permission := range adding {
+		parts := strings.Split(permission, "_")
+		created, err := manager.client.SpacePermissionsService().Create(manager.spaceKey, confluence.AddPermission{
+			Subject: confluence.Subject{Type: principalType, Id: actorId},
+			Operation: confluence.AddOperation{
+				Key:    strings.Join(parts[:len(parts)-1], "_"),
+				Target: parts[len(parts)-1],
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("granting %s: %s", permission, err.Error())
+		}
+		manager.applied(principalType, actorId, permission, strconv.FormatInt(created.Id, 10))
+	}
+
+	// read_space is revoked last, as the other permissions require it
+	slices.Reverse(removing)
+	for _, permission := range removing {
+		manager.mutex.Lock()
+		permissionId, ok := manager.permissionIds[permissionKey(actorId, permission)]
+		manager.mutex.Unlock()
+		if !ok {
+			continue
+		}
+
+		err := manager.client.SpacePermissionsService().Delete(manager.spaceKey, permissionId)
+		if err != nil {
+			return fmt.Errorf("revoking %s: %s", permission, err.Error())
+		}
+		manager.applied(principalType, actorId, permission, "")
+	}
+
+	return nil
+}
+
+// AssignedPermissions returns the permissions the actors hold, the permissions read with the changes made since.
+func (manager *SpacePermissionManager) AssignedPermissions() *confluence.ObjectPermissions {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	return manager.assignedPermissions
+}
+
+// Snapshot returns a copy of AssignedPermissions, which the changes made later leave as it is.
+func (manager *SpacePermissionManager) Snapshot() *confluence.ObjectPermissions {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	return &confluence.ObjectPermissions{
+		Users:  slices.Clone(manager.assignedPermissions.Users),
+		Groups: slices.Clone(manager.assignedPermissions.Groups),
+	}
+}
+
+// Restore grants and revokes permissions so that the actors hold the permissions of snapshot again. Every actor is
+// restored even when others fail, the errors are joined.
+func (manager *SpacePermissionManager) Restore(snapshot *confluence.ObjectPermissions) error {
+	current := manager.Snapshot()
+
+	var errs []error
+	for _, user := range current.Users {
+		var permissions []string
+		if previous := snapshot.FindUser(user.AccountId); previous != nil {
+			permissions = previous.Permissions
+		}
+
+		err := manager.update(confluence.PrincipalUser, user.AccountId, user.Permissions, permissions)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", util.CoalesceString(user.Name, user.AccountId), err.Error()))
+		}
+	}
+
+	for _, group := range current.Groups {
+		var permissions []string
+		if previous := snapshot.FindGroup(group.AccountId); previous != nil {
+			permissions = previous.Permissions
+		}
+
+		err := manager.update(confluence.PrincipalGroup, group.AccountId, group.Permissions, permissions)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", util.CoalesceString(group.Name, group.AccountId), err.Error()))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// applied records that the actor was granted the permission with the given ID, or that it was revoked when the ID
+// is empty.
+func (manager *SpacePermissionManager) applied(principalType string, actorId string, permission string, permissionId string) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if permissionId == "" {
+		delete(manager.permissionIds, permissionKey(actorId, permission))
+	} else {
+		manager.permissionIds[permissionKey(actorId, permission)] = permissionId
+	}
+
+	switch principalType {
+	case confluence.PrincipalUser:
+		index := slices.IndexFunc(manager.assignedPermissions.Users, func(user confluence.UserPermissions) bool {
+			return user.AccountId == actorId
+		})
+		if index < 0 {
+			manager.assignedPermissions.Users = append(manager.assignedPermissions.Users, confluence.UserPermissions{AccountId: actorId})
+			index = len(manager.assignedPermissions.Users) - 1
+		}
+
+		user := &manager.assignedPermissions.Users[index]
+		user.Permissions = withPermission(user.Permissions, permission, permissionId != "")
+	case confluence.PrincipalGroup:
+		index := slices.IndexFunc(manager.assignedPermissions.Groups, func(group confluence.GroupPermissions) bool {
+			return group.AccountId == actorId
+		})
+		if index < 0 {
+			manager.assignedPermissions.Groups = append(manager.assignedPermissions.Groups, confluence.GroupPermissions{AccountId: actorId})
+			index = len(manager.assignedPermissions.Groups) - 1
+		}
+
+		group := &manager.assignedPermissions.Groups[index]
+		group.Permissions = withPermission(group.Permissions, permission, permissionId != "")
+	}
+}
+
+func withPermission(permissions []string, permission string, granted bool) []string {
+	permissions = slices.DeleteFunc(slices.Clone(permissions), func(held string) bool {
+		return held == permission
+	})
+	if granted {
+		permissions = append(permissions, permission)
+	}
+
+	return permissions
+}
+
+func permissionKey(actorId string, permission string) string {
+	return fmt.Sprintf("%s:%s", actorId, permission)
+}
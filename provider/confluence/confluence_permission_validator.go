@@ -0,0 +1,79 @@
+package confluence
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/yunarta/golang-quality-of-life-pack/collections"
+	"github.com/yunarta/terraform-atlassian-api-client/confluence"
+	"slices"
+	"strings"
+)
+
+// spacePermissionTargets are the targets of each operation that the space permission API can grant,
+// a permission is written as operation_target, as in create_page.
+var spacePermissionTargets = map[string][]string{
+	confluence.OpRead:            {confluence.TargetSpace},
+	confluence.OpCreate:          {confluence.TargetPage, confluence.TargetBlogpost, confluence.TargetComment, confluence.TargetAttachment},
+	confluence.OpDelete:          {confluence.TargetSpace, confluence.TargetPage, confluence.TargetBlogpost, confluence.TargetComment, confluence.TargetAttachment},
+	confluence.OpArchive:         {confluence.TargetPage},
+	confluence.OpRestrictContent: {confluence.TargetSpace},
+	confluence.OpExport:          {confluence.TargetSpace},
+	confluence.OpAdminister:      {confluence.TargetSpace},
+}
+
+// SpacePermissions lists every space permission, sorted.
+func SpacePermissions() []string {
+	var permissions []string
+	for operation, targets := range spacePermissionTargets {
+		for _, target := range targets {
+			permissions = append(permissions, operation+"_"+target)
+		}
+	}
+
+	return collections.SortStrings(permissions)
+}
+
+// spacePermission validates a permission as an operation and target pair of the space permission API.
+type spacePermission struct{}
+
+var _ validator.String = spacePermission{}
+
+// SpacePermission returns the validator of the permissions in the assignments.
+func SpacePermission() validator.String {
+	return spacePermission{}
+}
+
+func (v spacePermission) Description(ctx context.Context) string {
+	return fmt.Sprintf("value must be one of: %s", strings.Join(SpacePermissions(), ", "))
+}
+
+func (v spacePermission) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v spacePermission) ValidateString(ctx context.Context, request validator.StringRequest, response *validator.StringResponse) {
+	if request.ConfigValue.IsNull() || request.ConfigValue.IsUnknown() {
+		return
+	}
+
+	permission := request.ConfigValue.ValueString()
+
+	var operation, target string
+	if i := strings.LastIndex(permission, "_"); i > 0 {
+		operation, target = permission[:i], permission[i+1:]
+	}
+
+	targets, ok := spacePermissionTargets[operation]
+	if ok && slices.Contains(targets, target) {
+		return
+	}
+
+	detail := fmt.Sprintf("%q is not a space permission, %s", permission, v.Description(ctx))
+	if ok {
+		detail = fmt.Sprintf("%q cannot be granted on %q, the targets of %q are: %s",
+			operation, target, operation, strings.Join(targets, ", "))
+	}
+
+	response.Diagnostics.AddAttributeError(request.Path, "Invalid space permission", detail)
+}
@@ -16,10 +16,15 @@ type SpaceModel struct {
 	Name           types.String `tfsdk:"name"`
 	Description    types.String `tfsdk:"description"`
 
-	AssignmentVersion types.String `tfsdk:"assignment_version"`
-	Assignments       types.List   `tfsdk:"assignments"`
-	ComputedUsers     types.List   `tfsdk:"computed_users"`
-	ComputedGroups    types.List   `tfsdk:"computed_groups"`
+	AssignmentVersion      types.String `tfsdk:"assignment_version"`
+	Authoritative          types.Bool   `tfsdk:"authoritative"`
+	AuthoritativeAllowList types.List   `tfsdk:"authoritative_allow_list"`
+	OnUnknownPrincipal     types.String `tfsdk:"on_unknown_principal"`
+	MergeStrategy          types.String `tfsdk:"merge_strategy"`
+	RollbackOnFailure      types.Bool   `tfsdk:"rollback_on_failure"`
+	Assignments            types.List   `tfsdk:"assignments"`
+	ComputedUsers          types.List   `tfsdk:"computed_users"`
+	ComputedGroups         types.List   `tfsdk:"computed_groups"`
 }
 
 var _ SpaceRoleInterface = &SpaceModel{}
@@ -35,16 +40,47 @@ func (s SpaceModel) getSpaceIdOrKey(ctx context.Context) string {
 	return s.Key.ValueString()
 }
 
+func (s SpaceModel) getAuthoritative(ctx context.Context) (bool, []string, diag.Diagnostics) {
+	var allowList = make([]string, 0)
+	if !s.Authoritative.ValueBool() {
+		return false, allowList, nil
+	}
+
+	diags := s.AuthoritativeAllowList.ElementsAs(ctx, &allowList, true)
+	return true, allowList, diags
+}
+
+func (s SpaceModel) getOnUnknownPrincipal() types.String {
+	return s.OnUnknownPrincipal
+}
+
+func (s SpaceModel) getMergeStrategy() string {
+	return s.MergeStrategy.ValueString()
+}
+
+func (s SpaceModel) getRollbackOnFailure() bool {
+	return s.RollbackOnFailure.ValueBool()
+}
+
+func (s SpaceModel) getAssignmentResult() confluence.AssignmentResult {
+	return confluence.AssignmentResult{ComputedUsers: s.ComputedUsers, ComputedGroups: s.ComputedGroups}
+}
+
 func NewSpaceModel(plan SpaceModel, project *clientApi.Space, assignmentResult *confluence.AssignmentResult) *SpaceModel {
 	return &SpaceModel{
-		RetainOnDelete:    plan.RetainOnDelete,
-		AccountId:         types.Int64Value(project.Id),
-		Key:               types.StringValue(project.Key),
-		Name:              types.StringValue(project.Name),
-		Description:       util.NullString(project.Description.Plain.Value),
-		AssignmentVersion: plan.AssignmentVersion,
-		Assignments:       plan.Assignments,
-		ComputedUsers:     assignmentResult.ComputedUsers,
-		ComputedGroups:    assignmentResult.ComputedGroups,
+		RetainOnDelete:         plan.RetainOnDelete,
+		AccountId:              types.Int64Value(project.Id),
+		Key:                    types.StringValue(project.Key),
+		Name:                   types.StringValue(project.Name),
+		Description:            util.NullString(project.Description.Plain.Value),
+		AssignmentVersion:      plan.AssignmentVersion,
+		Authoritative:          plan.Authoritative,
+		AuthoritativeAllowList: plan.AuthoritativeAllowList,
+		OnUnknownPrincipal:     plan.OnUnknownPrincipal,
+		MergeStrategy:          plan.MergeStrategy,
+		RollbackOnFailure:      plan.RollbackOnFailure,
+		Assignments:            plan.Assignments,
+		ComputedUsers:          assignmentResult.ComputedUsers,
+		ComputedGroups:         assignmentResult.ComputedGroups,
 	}
 }
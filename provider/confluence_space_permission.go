@@ -2,19 +2,41 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
-	"github.com/yunarta/golang-quality-of-life-pack/collections"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	confluenceApi "github.com/yunarta/terraform-atlassian-api-client/confluence"
 	"github.com/yunarta/terraform-atlassian-api-client/confluence/cloud"
 	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/confluence"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
 )
 
 type SpaceRoleResource interface {
+	// getProviderConfig returns the configuration of the provider, nil until the resource is configured
+	getProviderConfig() *AtlassianCloudProviderConfig
 	getClient() *cloud.ConfluenceClient
+	// getActorLookup returns the actor lookup cache of the client, shared by the resources of the provider
+	getActorLookup() *lookup.ActorLookupService
+	// getProviderAccountId returns the account ID of the provider, empty unless the credentials were validated
+	getProviderAccountId() string
+	// getSpaceCreator returns the account ID or username of the creator of the space, which an authoritative space keeps
+	getSpaceCreator() string
 }
 
 type SpaceRoleInterface interface {
 	getAssignment(ctx context.Context) (confluence.Assignments, diag.Diagnostics)
 	getSpaceIdOrKey(ctx context.Context) string
+	// getAuthoritative returns whether permissions of actors outside the assignments are revoked,
+	// and the actors that are left alone
+	getAuthoritative(ctx context.Context) (bool, []string, diag.Diagnostics)
+	// getOnUnknownPrincipal returns the resource setting for users and groups that do not exist, null for the provider default
+	getOnUnknownPrincipal() types.String
+	// getMergeStrategy returns how the assignments naming the same actor are combined
+	getMergeStrategy() string
+	// getRollbackOnFailure returns whether the permission changes of an apply that fails are undone
+	getRollbackOnFailure() bool
+	// getAssignmentResult returns the computed users and groups, with the IDs they resolved to
+	getAssignmentResult() confluence.AssignmentResult
 }
 
 func CreateSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource, plan SpaceRoleInterface) (*confluence.AssignmentResult, diag.Diagnostics) {
@@ -23,37 +45,191 @@ func CreateSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource,
 		return nil, diags
 	}
 
-	plannedAssignmentOrder, diags := plannedAssignment.CreateAssignmentOrder(ctx)
+	plannedAssignmentOrder, diags := plannedAssignment.CreateAssignmentOrder(ctx, plan.getMergeStrategy())
+	if diags != nil {
+		return nil, diags
+	}
+
+	authoritative, ignoreList, diags := plan.getAuthoritative(ctx)
 	if diags != nil {
 		return nil, diags
 	}
 
 	SpaceIdOrKey := plan.getSpaceIdOrKey(ctx)
 
-	updateService := cloud.NewSpaceRoleManager(
+	updateService := confluence.NewSpacePermissionManager(
 		receiver.getClient(),
+		receiver.getActorLookup(),
 		SpaceIdOrKey,
 	)
 
 	// Read both in state and planned roles to fill in the update service with prepared data
-	_, _ = updateService.ReadPermissions()
-	// Register all usernames and groupNames in play to prepare the data
-	receiver.getClient().ActorLookupService().RegisterUsernames(
-		plannedAssignmentOrder.UserNames...,
-	)
-	receiver.getClient().ActorLookupService().RegisterGroupNames(
-		plannedAssignmentOrder.GroupNames...,
-	)
+	assignedPermissions, err := updateService.ReadPermissions()
+	if err != nil {
+		return nil, []diag.Diagnostic{diag.NewErrorDiagnostic("Failed to read space permissions", err.Error())}
+	}
+	// Register all users and groups in play to prepare the data
+	confluence.RegisterActors(receiver.getActorLookup(), *plannedAssignmentOrder)
+	// the permissions read are the ones a failed apply is rolled back to
+	snapshot := updateService.Snapshot()
 
-	return confluence.ApplyNewAssignmentSet(ctx, receiver.getClient().ActorLookupService(),
+	principals := resolveSpacePrincipals(ctx, receiver, plan, *plannedAssignmentOrder)
+	if principals.HasError() {
+		return nil, principals
+	}
+
+	computation, diags := confluence.ApplyNewAssignmentSet(ctx, receiver.getActorLookup(),
 		*plannedAssignmentOrder,
-		func(user string, requestedRoles []string) error {
-			return updateService.UpdateUserPermissions(user, requestedRoles)
-		},
-		func(group string, requestedRoles []string) error {
-			return updateService.UpdateUserPermissions(group, requestedRoles)
-		},
+		assignmentParallelism(receiver.getProviderConfig()),
+		updateService.UpdateUserPermissions,
+		updateService.UpdateGroupPermissions,
+	)
+	if diags == nil && authoritative {
+		diags = revokeUnmanagedPermissions(receiver, updateService, assignedPermissions, nil, *plannedAssignmentOrder, ignoreList)
+	}
+
+	if diags.HasError() {
+		return failedSpaceRoleAssignments(ctx, receiver, plan, updateService, snapshot, *plannedAssignmentOrder, nil, append(principals, diags...))
+	}
+
+	return computation, append(principals, diags...)
+}
+
+// failedSpaceRoleAssignments rolls the permissions back to snapshot when the resource asks for it, and computes the
+// assignments the failed apply left.
+func failedSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource, plan SpaceRoleInterface,
+	updateService *confluence.SpacePermissionManager, snapshot *confluenceApi.ObjectPermissions,
+	plannedAssignmentOrder confluence.AssignmentOrder, inStateAssignmentOrder *confluence.AssignmentOrder,
+	diags diag.Diagnostics) (*confluence.AssignmentResult, diag.Diagnostics) {
+	if diags.HasError() && plan.getRollbackOnFailure() {
+		diags = append(diags, rollbackSpacePermissions(plan.getSpaceIdOrKey(ctx), updateService, snapshot)...)
+	}
+
+	return appliedSpaceRoleAssignments(ctx, receiver, updateService, plannedAssignmentOrder, inStateAssignmentOrder), diags
+}
+
+// rollbackSpacePermissions undoes the permission changes made before the failure, reporting whether the permissions
+// are back to the ones read.
+func rollbackSpacePermissions(spaceKey string, updateService *confluence.SpacePermissionManager, snapshot *confluenceApi.ObjectPermissions) diag.Diagnostics {
+	err := updateService.Restore(snapshot)
+	if err != nil {
+		return diag.Diagnostics{diag.NewErrorDiagnostic("Failed to roll back space permissions",
+			fmt.Sprintf("Space %s keeps some of the changes made before the failure, the computed users and groups show the permissions held. %s",
+				spaceKey, err.Error()))}
+	}
+
+	return diag.Diagnostics{diag.NewWarningDiagnostic("Rolled back space permissions",
+		fmt.Sprintf("The permissions of space %s are back to the ones held before the apply.", spaceKey))}
+}
+
+// appliedSpaceRoleAssignments computes the assignments an apply that failed part way left, for the state to record
+// the permissions changed before the failure and the next plan to only show the remaining changes.
+func appliedSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource, updateService *confluence.SpacePermissionManager,
+	plannedAssignmentOrder confluence.AssignmentOrder, inStateAssignmentOrder *confluence.AssignmentOrder) *confluence.AssignmentResult {
+	computation, diags := confluence.ComputeAppliedAssignment(ctx, receiver.getActorLookup(),
+		updateService.AssignedPermissions(), plannedAssignmentOrder, inStateAssignmentOrder)
+	if diags != nil {
+		return nil
+	}
+
+	return computation
+}
+
+// findUnmanagedPermissions looks up the actors outside the assignments, keeping the space creator.
+func findUnmanagedPermissions(receiver SpaceRoleResource, assignedPermissions *confluenceApi.ObjectPermissions,
+	assignmentOrder confluence.AssignmentOrder, ignoreList []string) confluence.UnmanagedActors {
+	if creator := receiver.getSpaceCreator(); creator != "" {
+		ignoreList = append(ignoreList, creator)
+	}
+
+	return confluence.FindUnmanagedActors(receiver.getActorLookup(), assignedPermissions, assignmentOrder, ignoreList)
+}
+
+// revokeUnmanagedPermissions revokes the permissions of the actors that are not in the assignments. Actors of the
+// in state assignments are skipped, as their permissions have been revoked already.
+func revokeUnmanagedPermissions(receiver SpaceRoleResource, updateService *confluence.SpacePermissionManager,
+	assignedPermissions *confluenceApi.ObjectPermissions, inStateAssignmentOrder *confluence.AssignmentOrder,
+	assignmentOrder confluence.AssignmentOrder, ignoreList []string) diag.Diagnostics {
+	unmanaged := findUnmanagedPermissions(receiver, assignedPermissions, assignmentOrder, ignoreList)
+	if inStateAssignmentOrder != nil {
+		unmanaged = unmanaged.Without(receiver.getActorLookup(), *inStateAssignmentOrder)
+	}
+
+	var inState = map[string][]string{}
+	for _, user := range unmanaged.Users {
+		inState[user.AccountId] = user.Permissions
+	}
+	warnings := selfAdminRemoval(receiver.getProviderAccountId(), "administer_space", inState, nil)
+
+	diags := unmanaged.Remove(
+		updateService.UpdateUserPermissions,
+		updateService.UpdateGroupPermissions,
 	)
+
+	return append(warnings, diags...)
+}
+
+// PreviewSpaceRoleAssignments computes the permissions the planned assignments grant, for the plan to show them.
+// The state is nil when the space is to be created.
+func PreviewSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource, plan SpaceRoleInterface, state SpaceRoleInterface) (*confluence.AssignmentResult, diag.Diagnostics) {
+	// assignments with unknown values cannot be previewed, they are resolved on apply
+	plannedAssignment, diags := plan.getAssignment(ctx)
+	if diags.HasError() {
+		return nil, nil
+	}
+
+	plannedAssignmentOrder, diags := plannedAssignment.CreateAssignmentOrder(ctx, plan.getMergeStrategy())
+	if diags != nil {
+		return nil, diags
+	}
+
+	var inStateAssignmentOrder *confluence.AssignmentOrder
+	if state != nil {
+		inStateAssignments, diags := state.getAssignment(ctx)
+		if diags != nil {
+			return nil, diags
+		}
+
+		inStateAssignmentOrder, diags = inStateAssignments.CreateAssignmentOrder(ctx, state.getMergeStrategy())
+		if diags != nil {
+			return nil, diags
+		}
+
+		for _, assignmentOrder := range []*confluence.AssignmentOrder{inStateAssignmentOrder, plannedAssignmentOrder} {
+			diags = assignmentOrder.UseKnownIds(ctx, state.getAssignmentResult())
+			if diags != nil {
+				return nil, diags
+			}
+		}
+	}
+
+	confluence.RegisterActors(receiver.getActorLookup(), *plannedAssignmentOrder)
+
+	principals := resolveSpacePrincipals(ctx, receiver, plan, *plannedAssignmentOrder)
+	if principals.HasError() {
+		return nil, principals
+	}
+
+	if inStateAssignmentOrder != nil {
+		principals = append(principals, spaceAdminRemoval(receiver, inStateAssignmentOrder, plannedAssignmentOrder)...)
+	}
+
+	preview, diags := confluence.PreviewAssignment(ctx, receiver.getActorLookup(), *plannedAssignmentOrder)
+	return preview, append(principals, diags...)
+}
+
+// spaceAdminRemoval warns when the planned assignments take administer_space away from the provider account. Every
+// permission of the in state assignments is revoked when there are no planned assignments.
+func spaceAdminRemoval(receiver SpaceRoleResource, inStateAssignmentOrder *confluence.AssignmentOrder, plannedAssignmentOrder *confluence.AssignmentOrder) diag.Diagnostics {
+	inStateUsers, _ := inStateAssignmentOrder.ResolveUsers(receiver.getActorLookup())
+
+	var plannedPermissions map[string][]string
+	if plannedAssignmentOrder != nil {
+		plannedUsers, _ := plannedAssignmentOrder.ResolveUsers(receiver.getActorLookup())
+		plannedPermissions = confluence.PermissionsById(plannedUsers)
+	}
+
+	return selfAdminRemoval(receiver.getProviderAccountId(), "administer_space", confluence.PermissionsById(inStateUsers), plannedPermissions)
 }
 
 func ComputeSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource, state SpaceRoleInterface) (*confluence.AssignmentResult, diag.Diagnostics) {
@@ -62,31 +238,68 @@ func ComputeSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource
 		return nil, diags
 	}
 
-	assignmentOrder, diags := assignments.CreateAssignmentOrder(ctx)
+	assignmentOrder, diags := assignments.CreateAssignmentOrder(ctx, state.getMergeStrategy())
+	if diags != nil {
+		return nil, diags
+	}
+
+	diags = assignmentOrder.UseKnownIds(ctx, state.getAssignmentResult())
+	if diags != nil {
+		return nil, diags
+	}
+
+	diags = keepSpaceLeftovers(ctx, state, assignmentOrder)
+	if diags != nil {
+		return nil, diags
+	}
+
+	authoritative, ignoreList, diags := state.getAuthoritative(ctx)
 	if diags != nil {
 		return nil, diags
 	}
 
 	SpaceIdOrKey := state.getSpaceIdOrKey(ctx)
 
-	updateService := cloud.NewSpaceRoleManager(
+	updateService := confluence.NewSpacePermissionManager(
 		receiver.getClient(),
+		receiver.getActorLookup(),
 		SpaceIdOrKey,
 	)
 
 	assignedRoles, err := updateService.ReadPermissions()
 	if err != nil {
-		return nil, []diag.Diagnostic{diag.NewErrorDiagnostic("Failed to read Space roles", err.Error())}
+		return nil, []diag.Diagnostic{diag.NewErrorDiagnostic("Failed to read space permissions", err.Error())}
+	}
+
+	if !authoritative {
+		return confluence.ComputePermissionAssignments(ctx, receiver.getActorLookup(), assignedRoles, *assignmentOrder)
 	}
 
-	return confluence.ComputePermissionAssignments(ctx, assignedRoles, *assignmentOrder)
+	// report the permissions an apply would revoke as drift
+	unmanaged := findUnmanagedPermissions(receiver, assignedRoles, *assignmentOrder, ignoreList)
+	computation, diags := confluence.ComputeAuthoritativeAssignment(ctx, receiver.getActorLookup(), assignedRoles, *assignmentOrder, unmanaged)
+	if diags != nil {
+		return nil, diags
+	}
+
+	return computation, unmanaged.Drift(SpaceIdOrKey)
+}
+
+// keepSpaceLeftovers adds the actors whose permissions failed to be revoked on the last apply to the in state
+// assignments, for them to be revoked again. An authoritative state has them among the unmanaged actors instead.
+func keepSpaceLeftovers(ctx context.Context, state SpaceRoleInterface, assignmentOrder *confluence.AssignmentOrder) diag.Diagnostics {
+	authoritative, _, diags := state.getAuthoritative(ctx)
+	if diags != nil || authoritative {
+		return diags
+	}
+
+	return assignmentOrder.KeepLeftovers(ctx, state.getAssignmentResult())
 }
 
 func UpdateSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource,
 	plan SpaceRoleInterface,
 	state SpaceRoleInterface,
 	forceUpdate bool) (*confluence.AssignmentResult, diag.Diagnostics) {
-
 	plannedAssignments, diags := plan.getAssignment(ctx)
 	if diags != nil {
 		return nil, diags
@@ -97,12 +310,30 @@ func UpdateSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource,
 		return nil, diags
 	}
 
-	plannedAssignmentOrder, diags := plannedAssignments.CreateAssignmentOrder(ctx)
+	plannedAssignmentOrder, diags := plannedAssignments.CreateAssignmentOrder(ctx, plan.getMergeStrategy())
+	if diags != nil {
+		return nil, diags
+	}
+
+	inStateAssignmentOrder, diags := inStateAssignments.CreateAssignmentOrder(ctx, state.getMergeStrategy())
+	if diags != nil {
+		return nil, diags
+	}
+
+	// the actors of the last apply are looked up by the IDs they resolved to, when they were renamed since
+	for _, assignmentOrder := range []*confluence.AssignmentOrder{inStateAssignmentOrder, plannedAssignmentOrder} {
+		diags = assignmentOrder.UseKnownIds(ctx, state.getAssignmentResult())
+		if diags != nil {
+			return nil, diags
+		}
+	}
+
+	diags = keepSpaceLeftovers(ctx, state, inStateAssignmentOrder)
 	if diags != nil {
 		return nil, diags
 	}
 
-	inStateAssignmentOrder, diags := inStateAssignments.CreateAssignmentOrder(ctx)
+	authoritative, ignoreList, diags := plan.getAuthoritative(ctx)
 	if diags != nil {
 		return nil, diags
 	}
@@ -110,33 +341,47 @@ func UpdateSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource,
 	// the plan does not have computed value deployment ID
 	SpaceIdOrKey := state.getSpaceIdOrKey(ctx)
 
-	updateService := cloud.NewSpaceRoleManager(
+	updateService := confluence.NewSpacePermissionManager(
 		receiver.getClient(),
+		receiver.getActorLookup(),
 		SpaceIdOrKey,
 	)
 
 	// Read both in state and planned roles to fill in the update service with prepared data
-	_, _ = updateService.ReadPermissions()
-	// Register all usernames and groupNames in play to prepare the data
-	receiver.getClient().ActorLookupService().RegisterUsernames(
-		collections.Unique(append(inStateAssignmentOrder.UserNames, plannedAssignmentOrder.UserNames...))...,
-	)
-	receiver.getClient().ActorLookupService().RegisterGroupNames(
-		collections.Unique(append(inStateAssignmentOrder.GroupNames, plannedAssignmentOrder.GroupNames...))...,
-	)
-	//defer updateService.Finalized()
+	assignedPermissions, err := updateService.ReadPermissions()
+	if err != nil {
+		return nil, []diag.Diagnostic{diag.NewErrorDiagnostic("Failed to read space permissions", err.Error())}
+	}
+	// Register all users and groups in play to prepare the data
+	confluence.RegisterActors(receiver.getActorLookup(), *inStateAssignmentOrder, *plannedAssignmentOrder)
+	// the permissions read are the ones a failed apply is rolled back to
+	snapshot := updateService.Snapshot()
 
-	return confluence.UpdateAssignment(ctx, receiver.getClient().ActorLookupService(),
+	principals := resolveSpacePrincipals(ctx, receiver, plan, *plannedAssignmentOrder)
+	if principals.HasError() {
+		return nil, principals
+	}
+
+	warnings := append(principals, spaceAdminRemoval(receiver, inStateAssignmentOrder, plannedAssignmentOrder)...)
+
+	// an authoritative space also reverts the permissions of configured actors that were changed outside Terraform
+	computation, diags := confluence.UpdateAssignment(ctx, receiver.getActorLookup(),
 		*inStateAssignmentOrder,
 		*plannedAssignmentOrder,
-		forceUpdate,
-		func(user string, requestedRoles []string) error {
-			return updateService.UpdateUserPermissions(user, requestedRoles)
-		},
-		func(group string, requestedRoles []string) error {
-			return updateService.UpdateGroupPermissions(group, requestedRoles)
-		},
+		forceUpdate || authoritative,
+		assignmentParallelism(receiver.getProviderConfig()),
+		updateService.UpdateUserPermissions,
+		updateService.UpdateGroupPermissions,
 	)
+	if diags == nil && authoritative {
+		diags = revokeUnmanagedPermissions(receiver, updateService, assignedPermissions, inStateAssignmentOrder, *plannedAssignmentOrder, ignoreList)
+	}
+
+	if diags.HasError() {
+		return failedSpaceRoleAssignments(ctx, receiver, plan, updateService, snapshot, *plannedAssignmentOrder, inStateAssignmentOrder, append(warnings, diags...))
+	}
+
+	return computation, append(warnings, diags...)
 }
 
 func DeleteSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource, state SpaceRoleInterface) diag.Diagnostics {
@@ -145,34 +390,57 @@ func DeleteSpaceRoleAssignments(ctx context.Context, receiver SpaceRoleResource,
 		return diags
 	}
 
-	inStateAssignmentOrder, diags := assignments.CreateAssignmentOrder(ctx)
+	inStateAssignmentOrder, diags := assignments.CreateAssignmentOrder(ctx, state.getMergeStrategy())
+	if diags != nil {
+		return diags
+	}
+
+	diags = inStateAssignmentOrder.UseKnownIds(ctx, state.getAssignmentResult())
+	if diags != nil {
+		return diags
+	}
+
+	diags = keepSpaceLeftovers(ctx, state, inStateAssignmentOrder)
 	if diags != nil {
 		return diags
 	}
 
 	SpaceIdOrKey := state.getSpaceIdOrKey(ctx)
 
-	updateService := cloud.NewSpaceRoleManager(
+	updateService := confluence.NewSpacePermissionManager(
 		receiver.getClient(),
+		receiver.getActorLookup(),
 		SpaceIdOrKey,
 	)
 
 	// Read both in state and planned roles to fill in the update service with prepared data
-	assignedRoles, _ := updateService.ReadPermissions()
-	// Register all usernames and groupNames in play to prepare the data
-	receiver.getClient().ActorLookupService().RegisterUsernames(
-		inStateAssignmentOrder.UserNames...,
-	)
-	receiver.getClient().ActorLookupService().RegisterGroupNames(
-		inStateAssignmentOrder.GroupNames...,
+	assignedRoles, err := updateService.ReadPermissions()
+	if err != nil {
+		return []diag.Diagnostic{diag.NewErrorDiagnostic("Failed to read space permissions", err.Error())}
+	}
+	// Register all users and groups in play to prepare the data
+	confluence.RegisterActors(receiver.getActorLookup(), *inStateAssignmentOrder)
+	warnings := spaceAdminRemoval(receiver, inStateAssignmentOrder, nil)
+
+	return append(warnings, confluence.RemoveAssignment(ctx, receiver.getActorLookup(), assignedRoles, inStateAssignmentOrder,
+		assignmentParallelism(receiver.getProviderConfig()),
+		updateService.UpdateUserPermissions,
+		updateService.UpdateGroupPermissions,
+	)...)
+}
+
+// resolveSpacePrincipals reports the users and groups of the assignments that do not exist, as configured by on_unknown_principal.
+func resolveSpacePrincipals(ctx context.Context, receiver SpaceRoleResource, plan SpaceRoleInterface, assignmentOrder confluence.AssignmentOrder) diag.Diagnostics {
+	lookupService := receiver.getActorLookup()
+	unresolved := append(
+		unresolvedPrincipals("user", assignmentOrder.UserReferences(), assignmentOrder.UserPriorities, func(name string) bool {
+			return assignmentOrder.FindUser(lookupService, name) != nil
+		}),
+		unresolvedPrincipals("group", assignmentOrder.GroupReferences(), assignmentOrder.GroupPriorities, func(name string) bool {
+			return assignmentOrder.FindGroup(lookupService, name) != nil
+		})...,
 	)
-	//defer updateService.Finalized()
-
-	return confluence.RemoveAssignment(ctx, assignedRoles, inStateAssignmentOrder,
-		func(user string, requestedRoles []string) error {
-			return updateService.UpdateUserPermissions(user, requestedRoles)
-		},
-		func(group string, requestedRoles []string) error {
-			return updateService.UpdateGroupPermissions(group, requestedRoles)
-		})
+
+	return unknownPrincipals(unknownPrincipalMode(receiver.getProviderConfig(), plan.getOnUnknownPrincipal()),
+		"atlassian_confluence_space "+plan.getSpaceIdOrKey(ctx), unresolved)
 }
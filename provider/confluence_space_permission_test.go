@@ -0,0 +1,577 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-api-transport/transport"
+	confluenceApi "github.com/yunarta/terraform-atlassian-api-client/confluence"
+	"github.com/yunarta/terraform-atlassian-api-client/confluence/cloud"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/confluence"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/test"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func spaceModel(t *testing.T, key string, assignments ...confluence.Assignment) SpaceModel {
+	list, diags := types.ListValueFrom(context.Background(), confluence.AssignmentSchema().NestedObject.Type(), assignments)
+	assert.False(t, diags.HasError())
+
+	return SpaceModel{Key: types.StringValue(key), Assignments: list}
+}
+
+// spaceResource returns a resource configured like the provider does, with an actor lookup cache of its own
+func spaceResource(fake transport.PayloadTransport, config *AtlassianCloudProviderConfig) *ConfluenceSpaceResource {
+	client := cloud.NewConfluenceClient(fake)
+
+	receiver := &ConfluenceSpaceResource{}
+	receiver.SetConfig(config, client, lookup.NewActorLookupService(client.ActorService()))
+	return receiver
+}
+
+func TestSpaceRoleAssignments(t *testing.T) {
+	ctx := context.Background()
+
+	jira := test.NewJiraTransport()
+	writer := jira.AddUser("writer@example.com", "Writer")
+	readers := jira.AddGroup("confluence-readers")
+	fake := test.NewConfluenceTransport(jira)
+
+	receiver := spaceResource(fake, nil)
+	_, err := receiver.client.SpaceService().Create(confluenceApi.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	created := spaceModel(t, "TEST",
+		confluence.Assignment{Users: []string{"writer@example.com"}, Permissions: []string{"create_page", "read_space"}, Priority: 1},
+		confluence.Assignment{Groups: []string{"confluence-readers"}, Permissions: []string{"read_space"}, Priority: 2},
+	)
+	result, diags := CreateSpaceRoleAssignments(ctx, receiver, created)
+	assert.False(t, diags.HasError())
+	assert.Len(t, result.ComputedGroups.Elements(), 1)
+
+	users, groups := fake.Permissions("TEST")
+	assert.Equal(t, []string{"create_page", "read_space"}, users[writer.AccountID])
+	assert.Equal(t, []string{"read_space"}, groups[readers.GroupId])
+
+	computed, diags := ComputeSpaceRoleAssignments(ctx, receiver, created)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, result.ComputedUsers, computed.ComputedUsers)
+	assert.Equal(t, result.ComputedGroups, computed.ComputedGroups)
+
+	updated := spaceModel(t, "TEST",
+		confluence.Assignment{Users: []string{"writer@example.com"}, Permissions: []string{"read_space"}, Priority: 1},
+	)
+	_, diags = UpdateSpaceRoleAssignments(ctx, receiver, updated, created, false)
+	assert.False(t, diags.HasError())
+
+	users, groups = fake.Permissions("TEST")
+	assert.Equal(t, []string{"read_space"}, users[writer.AccountID])
+	assert.NotContains(t, groups, readers.GroupId)
+}
+
+func TestCreateSpaceRoleAssignments_Groups(t *testing.T) {
+	ctx := context.Background()
+
+	jira := test.NewJiraTransport()
+	administrators := jira.AddGroup("confluence-administrators")
+	readers := jira.AddGroup("confluence-readers")
+	fake := test.NewConfluenceTransport(jira)
+
+	receiver := spaceResource(fake, nil)
+	_, err := receiver.client.SpaceService().Create(confluenceApi.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	// groups are granted their permissions as groups, not looked up as users
+	model := spaceModel(t, "TEST",
+		confluence.Assignment{Groups: []string{"confluence-administrators"}, Permissions: []string{"administer_space", "read_space"}, Priority: 1},
+		confluence.Assignment{Groups: []string{"confluence-readers"}, Permissions: []string{"read_space"}, Priority: 2},
+	)
+	result, diags := CreateSpaceRoleAssignments(ctx, receiver, model)
+	assert.Empty(t, diags)
+	assert.Len(t, result.ComputedUsers.Elements(), 0)
+
+	var groups []confluence.ComputedAssignment
+	assert.False(t, result.ComputedGroups.ElementsAs(ctx, &groups, false).HasError())
+	assert.Equal(t, []confluence.ComputedAssignment{
+		{Name: "confluence-administrators", Id: administrators.GroupId, Permissions: []string{"administer_space", "read_space"}},
+		{Name: "confluence-readers", Id: readers.GroupId, Permissions: []string{"read_space"}},
+	}, groups)
+
+	users, granted := fake.Permissions("TEST")
+	assert.Empty(t, users)
+	assert.Equal(t, []string{"administer_space", "read_space"}, granted[administrators.GroupId])
+	assert.Equal(t, []string{"read_space"}, granted[readers.GroupId])
+}
+
+func TestSpaceRoleAssignments_Authoritative(t *testing.T) {
+	ctx := context.Background()
+
+	jira := test.NewJiraTransport()
+	creator := jira.AddUser("creator@example.com", "Creator")
+	writer := jira.AddUser("writer@example.com", "Writer")
+	stranger := jira.AddUser("stranger@example.com", "Stranger")
+	app := jira.AddAppUser("Automation")
+	readers := jira.AddGroup("confluence-readers")
+	guests := jira.AddGroup("confluence-guests")
+	jira.SetCurrentUser(creator.AccountID)
+	fake := test.NewConfluenceTransport(jira)
+
+	receiver := spaceResource(fake, &AtlassianCloudProviderConfig{confluenceAccountId: creator.AccountID})
+	_, err := receiver.client.SpaceService().Create(confluenceApi.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	model := spaceModel(t, "TEST",
+		confluence.Assignment{Users: []string{"writer@example.com"}, Permissions: []string{"create_page", "read_space"}, Priority: 1},
+	)
+	model.Authoritative = types.BoolValue(true)
+	model.AuthoritativeAllowList, _ = types.ListValueFrom(ctx, types.StringType, []string{"confluence-readers"})
+
+	_, diags := CreateSpaceRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+
+	// changes made outside Terraform
+	assert.Nil(t, fake.AddPermission("TEST", "user", stranger.AccountID, "read_space"))
+	assert.Nil(t, fake.AddPermission("TEST", "user", app.AccountID, "read_space"))
+	assert.Nil(t, fake.AddPermission("TEST", "group", readers.GroupId, "read_space"))
+	assert.Nil(t, fake.AddPermission("TEST", "group", guests.GroupId, "read_space"))
+
+	computed, diags := ComputeSpaceRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, 1, diags.WarningsCount())
+	assert.Len(t, computed.ComputedUsers.Elements(), 2)
+	assert.Len(t, computed.ComputedGroups.Elements(), 1)
+
+	// the plan previews the assignments alone, so it shows the unmanaged permissions revoked
+	refreshed := model
+	refreshed.ComputedUsers, refreshed.ComputedGroups = computed.ComputedUsers, computed.ComputedGroups
+	preview, diags := PreviewSpaceRoleAssignments(ctx, receiver, model, refreshed)
+	assert.False(t, diags.HasError())
+	assert.Len(t, preview.ComputedUsers.Elements(), 1)
+	assert.Len(t, preview.ComputedGroups.Elements(), 0)
+
+	_, diags = UpdateSpaceRoleAssignments(ctx, receiver, model, model, false)
+	assert.False(t, diags.HasError())
+
+	users, groups := fake.Permissions("TEST")
+	assert.Equal(t, []string{"create_page", "read_space"}, users[writer.AccountID])
+	assert.NotContains(t, users, stranger.AccountID)
+	assert.NotContains(t, groups, guests.GroupId)
+	assert.Contains(t, users, creator.AccountID)
+	assert.Contains(t, users, app.AccountID)
+	assert.Contains(t, groups, readers.GroupId)
+
+	_, diags = ComputeSpaceRoleAssignments(ctx, receiver, model)
+	assert.Empty(t, diags)
+}
+
+func TestSpaceRoleAssignments_ReadFailure(t *testing.T) {
+	ctx := context.Background()
+
+	jira := test.NewJiraTransport()
+	jira.AddUser("writer@example.com", "Writer")
+	fake := test.NewConfluenceTransport(jira)
+
+	receiver := spaceResource(fake, nil)
+	_, err := receiver.client.SpaceService().Create(confluenceApi.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	model := spaceModel(t, "TEST",
+		confluence.Assignment{Users: []string{"writer@example.com"}, Permissions: []string{"read_space"}, Priority: 1},
+	)
+	model.Authoritative = types.BoolValue(true)
+	model.AuthoritativeAllowList, _ = types.ListValueFrom(ctx, types.StringType, []string{})
+	result, diags := CreateSpaceRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+	model.ComputedUsers, model.ComputedGroups = result.ComputedUsers, result.ComputedGroups
+
+	// without the permissions held, nothing is known to revoke or to roll back to
+	fake.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.Method == http.MethodGet && strings.HasSuffix(request.URL.Path, "/permissions") {
+				writer.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	})
+
+	_, diags = CreateSpaceRoleAssignments(ctx, receiver, model)
+	assert.Equal(t, "Failed to read space permissions", diags.Errors()[0].Summary())
+
+	_, diags = UpdateSpaceRoleAssignments(ctx, receiver, model, model, false)
+	assert.Equal(t, "Failed to read space permissions", diags.Errors()[0].Summary())
+
+	diags = DeleteSpaceRoleAssignments(ctx, receiver, model)
+	assert.Equal(t, "Failed to read space permissions", diags.Errors()[0].Summary())
+
+	granted, _ := fake.Permissions("TEST")
+	assert.NotEmpty(t, granted)
+}
+
+func TestSpaceRoleAssignments_Drift(t *testing.T) {
+	ctx := context.Background()
+
+	jira := test.NewJiraTransport()
+	writer := jira.AddUser("writer@example.com", "Writer")
+	fake := test.NewConfluenceTransport(jira)
+
+	receiver := spaceResource(fake, nil)
+	_, err := receiver.client.SpaceService().Create(confluenceApi.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	model := spaceModel(t, "TEST",
+		confluence.Assignment{Users: []string{"writer@example.com"}, Permissions: []string{"create_page", "read_space"}, Priority: 1},
+	)
+	created, diags := CreateSpaceRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+
+	// revoked outside Terraform
+	space := fake.Space("TEST")
+	permissions, err := receiver.client.SpacePermissionsService().Read(space.Id)
+	assert.Nil(t, err)
+	for _, permission := range *permissions {
+		assert.Nil(t, receiver.client.SpacePermissionsService().Delete("TEST", permission.Id))
+	}
+
+	computed, diags := ComputeSpaceRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+	assert.False(t, computed.ComputedUsers.Equal(created.ComputedUsers))
+
+	var users []confluence.ComputedAssignment
+	assert.False(t, computed.ComputedUsers.ElementsAs(ctx, &users, false).HasError())
+	assert.Equal(t, []confluence.ComputedAssignment{{Name: "writer@example.com", Id: writer.AccountID, Permissions: []string{}}}, users)
+
+	_, diags = UpdateSpaceRoleAssignments(ctx, receiver, model, model, true)
+	assert.False(t, diags.HasError())
+
+	granted, _ := fake.Permissions("TEST")
+	assert.Equal(t, []string{"create_page", "read_space"}, granted[writer.AccountID])
+}
+
+func TestPreviewSpaceRoleAssignments(t *testing.T) {
+	ctx := context.Background()
+
+	jira := test.NewJiraTransport()
+	writer := jira.AddUser("writer@example.com", "Writer")
+	jira.AddGroup("confluence-readers")
+	fake := test.NewConfluenceTransport(jira)
+
+	receiver := spaceResource(fake, nil)
+	_, err := receiver.client.SpaceService().Create(confluenceApi.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	model := spaceModel(t, "TEST",
+		confluence.Assignment{Users: []string{"writer@example.com", "unknown@example.com"}, Permissions: []string{"read_space", "create_page"}, Priority: 1},
+		confluence.Assignment{Groups: []string{"confluence-readers"}, Permissions: []string{"read_space"}, Priority: 2},
+	)
+	preview, diags := PreviewSpaceRoleAssignments(ctx, receiver, model, nil)
+	assert.False(t, diags.HasError())
+
+	var users []confluence.ComputedAssignment
+	assert.False(t, preview.ComputedUsers.ElementsAs(ctx, &users, false).HasError())
+	assert.Equal(t, []confluence.ComputedAssignment{{Name: "writer@example.com", Id: writer.AccountID, Permissions: []string{"create_page", "read_space"}}}, users)
+
+	granted, _ := fake.Permissions("TEST")
+	assert.NotContains(t, granted, writer.AccountID)
+
+	result, diags := CreateSpaceRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, preview, result)
+}
+
+func TestSpaceRoleAssignments_SelfAdminRemoval(t *testing.T) {
+	ctx := context.Background()
+
+	jira := test.NewJiraTransport()
+	provider := jira.AddUser("provider@example.com", "Provider")
+	fake := test.NewConfluenceTransport(jira)
+
+	receiver := spaceResource(fake, &AtlassianCloudProviderConfig{confluenceAccountId: provider.AccountID})
+	_, err := receiver.client.SpaceService().Create(confluenceApi.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	created := spaceModel(t, "TEST",
+		confluence.Assignment{Users: []string{"provider@example.com"}, Permissions: []string{"administer_space", "read_space"}, Priority: 1},
+	)
+	result, diags := CreateSpaceRoleAssignments(ctx, receiver, created)
+	assert.False(t, diags.HasError())
+	created.ComputedUsers, created.ComputedGroups = result.ComputedUsers, result.ComputedGroups
+
+	_, diags = PreviewSpaceRoleAssignments(ctx, receiver, created, created)
+	assert.Empty(t, diags)
+
+	// the plan warns before the permission is revoked
+	updated := spaceModel(t, "TEST",
+		confluence.Assignment{Users: []string{"provider@example.com"}, Permissions: []string{"read_space"}, Priority: 1},
+	)
+	_, diags = PreviewSpaceRoleAssignments(ctx, receiver, updated, created)
+	assert.False(t, diags.HasError())
+	if assert.Equal(t, 1, diags.WarningsCount()) {
+		assert.Equal(t, "Provider account removes its own admin access", diags[0].Summary())
+	}
+
+	diags = DeleteSpaceRoleAssignments(ctx, receiver, created)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, 1, diags.WarningsCount())
+
+	granted, _ := fake.Permissions("TEST")
+	assert.NotContains(t, granted, provider.AccountID)
+}
+
+func TestSpaceRoleAssignments_ActorIds(t *testing.T) {
+	ctx := context.Background()
+
+	jira := test.NewJiraTransport()
+	writer := jira.AddUser("writer@example.com", "Writer")
+	reader := jira.AddUser("reader@example.com", "Reader")
+	writers := jira.AddGroup("confluence-writers")
+	readers := jira.AddGroup("confluence-readers")
+	fake := test.NewConfluenceTransport(jira)
+
+	receiver := spaceResource(fake, nil)
+	_, err := receiver.client.SpaceService().Create(confluenceApi.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	model := spaceModel(t, "TEST",
+		confluence.Assignment{AccountIds: []string{writer.AccountID}, GroupIds: []string{writers.GroupId}, Permissions: []string{"create_page", "read_space"}, Priority: 1},
+		confluence.Assignment{Users: []string{"reader@example.com"}, Groups: []string{"confluence-readers"}, Permissions: []string{"read_space"}, Priority: 2},
+	)
+	result, diags := CreateSpaceRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+
+	users, groups := fake.Permissions("TEST")
+	assert.Equal(t, []string{"create_page", "read_space"}, users[writer.AccountID])
+	assert.Equal(t, []string{"create_page", "read_space"}, groups[writers.GroupId])
+
+	var computedUsers []confluence.ComputedAssignment
+	assert.False(t, result.ComputedUsers.ElementsAs(ctx, &computedUsers, false).HasError())
+	assert.ElementsMatch(t, []confluence.ComputedAssignment{
+		{Name: writer.AccountID, Id: writer.AccountID, Permissions: []string{"create_page", "read_space"}},
+		{Name: "reader@example.com", Id: reader.AccountID, Permissions: []string{"read_space"}},
+	}, computedUsers)
+
+	// renamed outside Terraform, the next run finds them by the IDs kept in the state
+	assert.Nil(t, jira.ChangeEmail(reader.AccountID, "viewer@example.com"))
+	assert.Nil(t, jira.RenameGroup(readers.GroupId, "confluence-viewers"))
+	receiver = spaceResource(fake, nil)
+	model.ComputedUsers, model.ComputedGroups = result.ComputedUsers, result.ComputedGroups
+
+	computed, diags := ComputeSpaceRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, result.ComputedUsers, computed.ComputedUsers)
+	assert.Equal(t, result.ComputedGroups, computed.ComputedGroups)
+
+	requests := len(fake.Requests())
+	_, diags = UpdateSpaceRoleAssignments(ctx, receiver, model, model, false)
+	assert.False(t, diags.HasError())
+	for _, request := range fake.Requests()[requests:] {
+		assert.NotContains(t, request, "DELETE")
+	}
+
+	users, groups = fake.Permissions("TEST")
+	assert.Equal(t, []string{"read_space"}, users[reader.AccountID])
+	assert.Equal(t, []string{"read_space"}, groups[readers.GroupId])
+
+	// the same group by name and by group ID
+	named := spaceModel(t, "TEST",
+		confluence.Assignment{Groups: []string{"confluence-writers"}, Permissions: []string{"read_space"}, Priority: 1},
+		confluence.Assignment{GroupIds: []string{writers.GroupId}, Permissions: []string{"create_page"}, Priority: 2},
+	)
+	_, diags = CreateSpaceRoleAssignments(ctx, receiver, named)
+	assert.True(t, diags.HasError())
+	assert.Equal(t, "Group named twice in assignments", diags.Errors()[0].Summary())
+}
+
+func TestSpaceRoleAssignments_Parallel(t *testing.T) {
+	ctx := context.Background()
+
+	jira := test.NewJiraTransport()
+	var groups []string
+	var groupIds []string
+	for i := 0; i < 10; i++ {
+		group := jira.AddGroup(fmt.Sprintf("confluence-team-%d", i))
+		groups = append(groups, group.Name)
+		groupIds = append(groupIds, group.GroupId)
+	}
+	fake := test.NewConfluenceTransport(jira)
+
+	receiver := spaceResource(fake, &AtlassianCloudProviderConfig{AssignmentParallelism: types.Int64Value(4)})
+	_, err := receiver.client.SpaceService().Create(confluenceApi.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	// the permissions of two groups fail, the other groups are still updated
+	failing := []string{groupIds[3], groupIds[7]}
+	fake.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.Method == http.MethodPost {
+				body, _ := io.ReadAll(request.Body)
+				for _, groupId := range failing {
+					if strings.Contains(string(body), groupId) {
+						writer.WriteHeader(http.StatusInternalServerError)
+						return
+					}
+				}
+				request.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	})
+
+	model := spaceModel(t, "TEST",
+		confluence.Assignment{Groups: groups, Permissions: []string{"create_page", "read_space"}, Priority: 1},
+	)
+	result, diags := CreateSpaceRoleAssignments(ctx, receiver, model)
+	assert.Len(t, diags.Errors(), 2)
+	for i, diagnostic := range diags.Errors() {
+		assert.Equal(t, "Failed to update group roles", diagnostic.Summary())
+		assert.Contains(t, diagnostic.Detail(), []string{"confluence-team-3", "confluence-team-7"}[i])
+	}
+
+	_, granted := fake.Permissions("TEST")
+	for i, groupId := range groupIds {
+		if i == 3 || i == 7 {
+			assert.NotContains(t, granted, groupId)
+		} else {
+			assert.Equal(t, []string{"create_page", "read_space"}, granted[groupId])
+		}
+	}
+
+	// the permissions granted before the failures are recorded
+	var computedGroups []confluence.ComputedAssignment
+	assert.False(t, result.ComputedGroups.ElementsAs(ctx, &computedGroups, false).HasError())
+	assert.Len(t, computedGroups, 10)
+	for _, group := range computedGroups {
+		if group.Id == groupIds[3] || group.Id == groupIds[7] {
+			assert.Empty(t, group.Permissions)
+		} else {
+			assert.Equal(t, []string{"create_page", "read_space"}, group.Permissions)
+		}
+	}
+
+	// the same updates give the same diagnostics, in the order of the assignments
+	failing = []string{groupIds[7], groupIds[3]}
+	_, again := CreateSpaceRoleAssignments(ctx, receiver, model)
+	assert.Equal(t, diags, again)
+}
+
+func TestSpaceRoleAssignments_Rollback(t *testing.T) {
+	ctx := context.Background()
+
+	jira := test.NewJiraTransport()
+	writers := jira.AddGroup("confluence-writers")
+	readers := jira.AddGroup("confluence-readers")
+	fake := test.NewConfluenceTransport(jira)
+
+	receiver := spaceResource(fake, nil)
+	_, err := receiver.client.SpaceService().Create(confluenceApi.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	state := spaceModel(t, "TEST",
+		confluence.Assignment{Groups: []string{"confluence-writers"}, Permissions: []string{"read_space"}, Priority: 1},
+	)
+	result, diags := CreateSpaceRoleAssignments(ctx, receiver, state)
+	assert.False(t, diags.HasError())
+	state.ComputedUsers, state.ComputedGroups = result.ComputedUsers, result.ComputedGroups
+
+	// the permissions of the readers fail, and so do the revocations once failRevoke is set
+	failRevoke := false
+	fake.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.Method == http.MethodPost {
+				body, _ := io.ReadAll(request.Body)
+				if strings.Contains(string(body), readers.GroupId) {
+					writer.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				request.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			if failRevoke && request.Method == http.MethodDelete {
+				writer.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	})
+
+	// the permission granted to the writers before the failure is revoked again
+	plan := spaceModel(t, "TEST",
+		confluence.Assignment{Groups: []string{"confluence-writers"}, Permissions: []string{"create_page", "read_space"}, Priority: 1},
+		confluence.Assignment{Groups: []string{"confluence-readers"}, Permissions: []string{"read_space"}, Priority: 2},
+	)
+	plan.RollbackOnFailure = types.BoolValue(true)
+	result, diags = UpdateSpaceRoleAssignments(ctx, receiver, plan, state, false)
+	assert.Len(t, diags.Errors(), 1)
+	assert.Equal(t, "Failed to update group roles", diags.Errors()[0].Summary())
+	assert.Len(t, diags.Warnings(), 1)
+	assert.Equal(t, "Rolled back space permissions", diags.Warnings()[0].Summary())
+
+	_, granted := fake.Permissions("TEST")
+	assert.Equal(t, []string{"read_space"}, granted[writers.GroupId])
+	assert.NotContains(t, granted, readers.GroupId)
+
+	// the state records the permissions held again, for the next plan to show every change
+	var computedGroups []confluence.ComputedAssignment
+	assert.False(t, result.ComputedGroups.ElementsAs(ctx, &computedGroups, false).HasError())
+	for _, group := range computedGroups {
+		if group.Id == writers.GroupId {
+			assert.Equal(t, []string{"read_space"}, group.Permissions)
+		} else {
+			assert.Empty(t, group.Permissions)
+		}
+	}
+
+	// a rollback that fails is reported next to the original failure
+	failRevoke = true
+	receiver = spaceResource(fake, nil)
+	_, diags = UpdateSpaceRoleAssignments(ctx, receiver, plan, state, false)
+	assert.Len(t, diags.Errors(), 2)
+	assert.Equal(t, "Failed to update group roles", diags.Errors()[0].Summary())
+	assert.Equal(t, "Failed to roll back space permissions", diags.Errors()[1].Summary())
+	assert.Contains(t, diags.Errors()[1].Detail(), "confluence-writers: revoking create_page")
+	assert.Empty(t, diags.Warnings())
+
+	_, granted = fake.Permissions("TEST")
+	assert.ElementsMatch(t, []string{"create_page", "read_space"}, granted[writers.GroupId])
+}
+
+func TestSpacePermissionValidator(t *testing.T) {
+	ctx := context.Background()
+
+	validate := func(permission string) diag.Diagnostics {
+		var response validator.StringResponse
+		confluence.SpacePermission().ValidateString(ctx, validator.StringRequest{
+			Path:        path.Root("permissions"),
+			ConfigValue: types.StringValue(permission),
+		}, &response)
+		return response.Diagnostics
+	}
+
+	permissions := confluence.SpacePermissions()
+	assert.Len(t, permissions, 14)
+	for _, permission := range permissions {
+		assert.False(t, validate(permission).HasError(), permission)
+	}
+
+	diags := validate("archive_blogpost")
+	assert.True(t, diags.HasError())
+	assert.Contains(t, diags.Errors()[0].Detail(), `"archive" cannot be granted on "blogpost"`)
+
+	diags = validate("administer")
+	assert.True(t, diags.HasError())
+	assert.Contains(t, diags.Errors()[0].Detail(), `"administer" is not a space permission`)
+
+	assert.True(t, validate("restrict_space").HasError())
+	assert.False(t, validate("restrict_content_space").HasError())
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/jira"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
 	"github.com/yunarta/terraform-provider-commons/util"
 )
 
@@ -17,8 +19,9 @@ type JiraProjectRolesData struct {
 }
 
 type JiraProjectRolesDataSource struct {
-	client *cloud.JiraClient
-	model  *AtlassianCloudProviderConfig
+	client      *cloud.JiraClient
+	actorLookup *lookup.ActorLookupService
+	model       *AtlassianCloudProviderConfig
 }
 
 var (
@@ -35,9 +38,10 @@ func (receiver *JiraProjectRolesDataSource) Metadata(ctx context.Context, reques
 	response.TypeName = request.ProviderTypeName + "_jira_project_roles"
 }
 
-func (receiver *JiraProjectRolesDataSource) SetConfig(config *AtlassianCloudProviderConfig, client *cloud.JiraClient) {
+func (receiver *JiraProjectRolesDataSource) SetConfig(config *AtlassianCloudProviderConfig, client *cloud.JiraClient, actorLookup *lookup.ActorLookupService) {
 	receiver.model = config
 	receiver.client = client
+	receiver.actorLookup = actorLookup
 }
 
 func (receiver *JiraProjectRolesDataSource) Schema(ctx context.Context, request datasource.SchemaRequest, response *datasource.SchemaResponse) {
@@ -91,7 +95,7 @@ func (receiver *JiraProjectRolesDataSource) Read(ctx context.Context, request da
 		return
 	}
 
-	manager := cloud.NewProjectRoleManager(receiver.client, state.Key)
+	manager := jira.NewProjectRoleManager(receiver.client, receiver.actorLookup, state.Key)
 	objectRoles, err := manager.ReadAllRoles()
 
 	users, groups := CreateAttestation(objectRoles)
@@ -7,6 +7,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
 	"github.com/yunarta/terraform-provider-commons/util"
 )
 
@@ -35,7 +36,7 @@ func (receiver *UserDataSource) Metadata(ctx context.Context, request datasource
 	response.TypeName = request.ProviderTypeName + "_user"
 }
 
-func (receiver *UserDataSource) SetConfig(config *AtlassianCloudProviderConfig, client *cloud.JiraClient) {
+func (receiver *UserDataSource) SetConfig(config *AtlassianCloudProviderConfig, client *cloud.JiraClient, actorLookup *lookup.ActorLookupService) {
 	receiver.model = config
 	receiver.client = client
 }
@@ -4,3 +4,4 @@ const failedToUpdateUserRoles = "Failed to update user roles"
 const failedToRemoveUserRoles = "Failed to remove user roles"
 const failedToUpdateGroupRoles = "Failed to update group roles"
 const failedToRemoveGroupRoles = "Failed to remove group roles"
+const failedToUpdateProjectRole = "Failed to update project role"
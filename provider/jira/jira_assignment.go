@@ -6,87 +6,153 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/yunarta/golang-quality-of-life-pack/collections"
 	"github.com/yunarta/terraform-atlassian-api-client/jira"
-	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/parallel"
 	"slices"
-	"sort"
 	"strings"
 )
 
+const (
+	// MergeOverride gives an actor named in several assignments the roles of the one with the highest priority
+	MergeOverride = "override"
+	// MergeUnion gives an actor named in several assignments the roles of all of them
+	MergeUnion = "union"
+)
+
 type Assignment struct {
-	Users    []string `tfsdk:"users"`
-	Groups   []string `tfsdk:"groups"`
-	Roles    []string `tfsdk:"roles"`
-	Priority int64    `tfsdk:"priority"`
+	Users      []string `tfsdk:"users"`
+	AccountIds []string `tfsdk:"account_ids"`
+	Groups     []string `tfsdk:"groups"`
+	GroupIds   []string `tfsdk:"group_ids"`
+	Roles      []string `tfsdk:"roles"`
+	Priority   int64    `tfsdk:"priority"`
 }
 
+// AssignmentOrder holds the roles of the actors of the assignments, keyed by how the assignments name them.
 type AssignmentOrder struct {
 	Roles      []string
 	Users      map[string][]string
 	UserNames  []string
+	AccountIds []string
 	Groups     map[string][]string
 	GroupNames []string
+	GroupIds   []string
+	// UserPriorities and GroupPriorities are the priorities of the assignments naming each actor
+	UserPriorities  map[string][]int64
+	GroupPriorities map[string][]int64
+
+	// knownUsers and knownGroups are the IDs the actors resolved to on the last apply
+	knownUsers  map[string]string
+	knownGroups map[string]string
+	// leftoverUsers and leftoverGroups are the actors the assignments no longer name, whose removal failed
+	leftoverUsers  []knownActor
+	leftoverGroups []knownActor
 }
 
 type Assignments []Assignment
 
-type UpdateUserRolesFunc func(user string, requestedRoles []string) error
-type UpdateGroupRolesFunc func(group string, requestedRoles []string) error
+// UpdateUserRolesFunc gives the user with the account ID exactly the roles requested.
+type UpdateUserRolesFunc func(accountId string, requestedRoles []string) error
 
-func (assignments Assignments) CreateAssignmentOrder(ctx context.Context) (*AssignmentOrder, diag.Diagnostics) {
-	var priorities []int64
-	var makeAssignments = map[int64]Assignment{}
-	for _, assignment := range assignments {
-		priorities = append(priorities, assignment.Priority)
-		makeAssignments[assignment.Priority] = assignment
-	}
-	slices.SortFunc(priorities, func(a, b int64) int {
-		return utils.Int64Comparator(a, b)
+// UpdateGroupRolesFunc gives the group with the group ID exactly the roles requested.
+type UpdateGroupRolesFunc func(groupId string, requestedRoles []string) error
+
+// CreateAssignmentOrder resolves the roles of every actor, going through the assignments by priority.
+// With MergeOverride an actor gets the roles of the last assignment naming it, with MergeUnion the roles
+// of all of them.
+func (assignments Assignments) CreateAssignmentOrder(ctx context.Context, mergeStrategy string) (*AssignmentOrder, diag.Diagnostics) {
+	sorted := slices.Clone(assignments)
+	slices.SortStableFunc(sorted, func(a, b Assignment) int {
+		return utils.Int64Comparator(a.Priority, b.Priority)
 	})
+
 	var usersAssignments = map[string][]string{}
 	var groupsAssignments = map[string][]string{}
 	var userNames = make([]string, 0)
+	var accountIds = make([]string, 0)
 	var groupNames = make([]string, 0)
+	var groupIds = make([]string, 0)
+	var userPriorities = map[string][]int64{}
+	var groupPriorities = map[string][]int64{}
 	var roles = make([]string, 0)
-	for _, priority := range priorities {
-		assignment := makeAssignments[priority]
-		for _, user := range assignment.Users {
-			usersAssignments[user] = assignment.Roles
-			userNames = append(userNames, user)
+	for _, assignment := range sorted {
+		priority := assignment.Priority
+		for _, user := range append(slices.Clone(assignment.Users), assignment.AccountIds...) {
+			usersAssignments[user] = mergeAssignment(mergeStrategy, usersAssignments[user], assignment.Roles)
+			userPriorities[user] = appendPriority(userPriorities[user], priority)
 			roles = append(roles, assignment.Roles...)
 		}
+		userNames = append(userNames, assignment.Users...)
+		accountIds = append(accountIds, assignment.AccountIds...)
 
-		for _, group := range assignment.Groups {
-			groupsAssignments[group] = assignment.Roles
-			groupNames = append(groupNames, group)
+		for _, group := range append(slices.Clone(assignment.Groups), assignment.GroupIds...) {
+			groupsAssignments[group] = mergeAssignment(mergeStrategy, groupsAssignments[group], assignment.Roles)
+			groupPriorities[group] = appendPriority(groupPriorities[group], priority)
 			roles = append(roles, assignment.Roles...)
 		}
+		groupNames = append(groupNames, assignment.Groups...)
+		groupIds = append(groupIds, assignment.GroupIds...)
 	}
 
 	return &AssignmentOrder{
-		Roles:      collections.Unique(roles),
-		Users:      usersAssignments,
-		UserNames:  userNames,
-		Groups:     groupsAssignments,
-		GroupNames: groupNames,
+		Roles:           collections.Unique(roles),
+		Users:           usersAssignments,
+		UserNames:       userNames,
+		AccountIds:      accountIds,
+		Groups:          groupsAssignments,
+		GroupNames:      groupNames,
+		GroupIds:        groupIds,
+		UserPriorities:  userPriorities,
+		GroupPriorities: groupPriorities,
 	}, nil
 }
 
+// mergeAssignment combines the roles an actor already has with the roles of the next assignment naming it
+func mergeAssignment(mergeStrategy string, current []string, next []string) []string {
+	if mergeStrategy != MergeUnion || current == nil {
+		return next
+	}
+
+	return collections.Unique(append(slices.Clone(current), next...))
+}
+
+// appendPriority adds the priority once, an actor can be listed twice in the same assignment
+func appendPriority(priorities []int64, priority int64) []int64 {
+	if slices.Contains(priorities, priority) {
+		return priorities
+	}
+
+	return append(priorities, priority)
+}
+
 func AssignmentSchema() schema.ListNestedBlock {
 	return schema.ListNestedBlock{
+		Validators: []validator.List{
+			assignmentsValidator{},
+		},
 		NestedObject: schema.NestedBlockObject{
 			Attributes: map[string]schema.Attribute{
 				"users": schema.ListAttribute{
 					Optional:    true,
 					ElementType: types.StringType,
 				},
+				"account_ids": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+				},
 				"groups": schema.ListAttribute{
 					Optional:    true,
 					ElementType: types.StringType,
 				},
+				"group_ids": schema.ListAttribute{
+					Optional:    true,
+					ElementType: types.StringType,
+				},
 				"roles": schema.ListAttribute{
 					Required:    true,
 					ElementType: types.StringType,
@@ -99,6 +165,8 @@ func AssignmentSchema() schema.ListNestedBlock {
 	}
 }
 
+// ComputedAssignmentSchema holds the roles read from the site, the plan previews the roles the assignments grant
+// so that a change made outside Terraform shows up as a difference.
 var ComputedAssignmentSchema = schema.ListNestedAttribute{
 	Computed: true,
 	NestedObject: schema.NestedAttributeObject{
@@ -106,6 +174,9 @@ var ComputedAssignmentSchema = schema.ListNestedAttribute{
 			"name": schema.StringAttribute{
 				Computed: true,
 			},
+			"id": schema.StringAttribute{
+				Computed: true,
+			},
 			"roles": schema.ListAttribute{
 				Computed:    true,
 				ElementType: types.StringType,
@@ -114,8 +185,11 @@ var ComputedAssignmentSchema = schema.ListNestedAttribute{
 	},
 }
 
+// ComputedAssignment is an actor of the assignments with the roles it holds, Name is how the assignments name
+// the actor and Id is its account ID or group ID.
 type ComputedAssignment struct {
 	Name  string   `tfsdk:"name"`
+	Id    string   `tfsdk:"id"`
 	Roles []string `tfsdk:"roles"`
 }
 
@@ -128,9 +202,15 @@ var assignmentType = types.ObjectType{
 		"users": types.ListType{
 			ElemType: types.StringType,
 		},
+		"account_ids": types.ListType{
+			ElemType: types.StringType,
+		},
 		"groups": types.ListType{
 			ElemType: types.StringType,
 		},
+		"group_ids": types.ListType{
+			ElemType: types.StringType,
+		},
 	},
 }
 
@@ -138,6 +218,7 @@ var computedAssignmentType = types.ObjectType{
 	AttrTypes: map[string]attr.Type{
 		"roles": types.ListType{ElemType: types.StringType},
 		"name":  types.StringType,
+		"id":    types.StringType,
 	},
 }
 
@@ -146,73 +227,88 @@ type AssignmentResult struct {
 	ComputedGroups types.List
 }
 
-func ApplyNewAssignmentSet(ctx context.Context, actorLookupService *cloud.ActorLookupService,
+// ApplyNewAssignmentSet gives the actors of the assignments their roles, updating at most parallelism actors at once.
+func ApplyNewAssignmentSet(ctx context.Context, actorLookupService *lookup.ActorLookupService,
 	assignmentOrder AssignmentOrder,
+	parallelism int,
 	updateUserRoles UpdateUserRolesFunc,
 	updateGroupRoles UpdateGroupRolesFunc) (*AssignmentResult, diag.Diagnostics) {
 
-	var err error
+	users, diags := assignmentOrder.ResolveUsers(actorLookupService)
+	if diags != nil {
+		return nil, diags
+	}
+
+	groups, diags := assignmentOrder.ResolveGroups(actorLookupService)
+	if diags != nil {
+		return nil, diags
+	}
 
 	computedUsers := make([]ComputedAssignment, 0)
 	computedGroups := make([]ComputedAssignment, 0)
+	var updates []parallel.Update
 
-	for user, requestedRoles := range assignmentOrder.Users {
-		found := actorLookupService.FindUser(user)
-		if found == nil {
-			continue
-		}
-
+	for _, user := range users {
 		computedUsers = append(computedUsers, ComputedAssignment{
-			Name:  user,
-			Roles: requestedRoles,
+			Name:  user.Reference,
+			Id:    user.Id,
+			Roles: user.Roles,
 		})
-
-		err = updateUserRoles(user, requestedRoles)
-		if err != nil {
-			return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToUpdateUserRoles, err.Error())}
-		}
+		updates = append(updates, parallel.Update{Name: user.Name, Id: user.Id, Grants: user.Roles, Apply: updateUserRoles, Failure: failedToUpdateUserRoles})
 	}
 
-	for group, requestedRoles := range assignmentOrder.Groups {
-		found := actorLookupService.FindGroup(group)
-		if found == nil {
-			continue
-		}
-
+	for _, group := range groups {
 		computedGroups = append(computedGroups, ComputedAssignment{
-			Name:  group,
-			Roles: requestedRoles,
+			Name:  group.Reference,
+			Id:    group.Id,
+			Roles: group.Roles,
 		})
-
-		err = updateGroupRoles(group, requestedRoles)
-		if err != nil {
-			return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToUpdateGroupRoles, err.Error())}
-		}
+		updates = append(updates, parallel.Update{Name: group.Name, Id: group.Id, Grants: group.Roles, Apply: updateGroupRoles, Failure: failedToUpdateGroupRoles})
 	}
 
-	sort.Slice(computedUsers, func(a, b int) bool {
-		return computedUsers[a].Name > computedUsers[b].Name
-	})
-	sort.Slice(computedGroups, func(a, b int) bool {
-		return computedGroups[a].Name > computedGroups[b].Name
-	})
+	diags = parallel.Diagnostics(updates, parallel.Run(parallelism, updates))
+	if diags != nil {
+		return nil, diags
+	}
 
 	return createAssignmentResult(ctx, computedUsers, computedGroups)
 }
 
-func UpdateAssignment(ctx context.Context, actorLookupService *cloud.ActorLookupService,
+// PreviewAssignment computes the assignments an apply of assignmentOrder results in, without changing any role.
+func PreviewAssignment(ctx context.Context, actorLookupService *lookup.ActorLookupService,
+	assignmentOrder AssignmentOrder) (*AssignmentResult, diag.Diagnostics) {
+	return ApplyNewAssignmentSet(ctx, actorLookupService, assignmentOrder, 1,
+		func(accountId string, requestedRoles []string) error {
+			return nil
+		},
+		func(groupId string, requestedRoles []string) error {
+			return nil
+		},
+	)
+}
+
+// UpdateAssignment changes the roles of the actors from the in state assignments to the planned ones, updating at
+// most parallelism actors at once.
+func UpdateAssignment(ctx context.Context, actorLookupService *lookup.ActorLookupService,
 	inStateAssignmentOrder AssignmentOrder,
 	plannedAssignmentOrder AssignmentOrder,
 	forceUpdate bool,
+	parallelism int,
 	updateUserRole UpdateUserRolesFunc,
 	updateGroupRole UpdateGroupRolesFunc) (*AssignmentResult, diag.Diagnostics) {
 
-	computedUsers, diags := updateUsers(inStateAssignmentOrder, plannedAssignmentOrder, actorLookupService, forceUpdate, updateUserRole)
+	computedUsers, userUpdates, diags := updateUsers(inStateAssignmentOrder, plannedAssignmentOrder, actorLookupService, forceUpdate, updateUserRole)
+	if diags != nil {
+		return nil, diags
+	}
+
+	computedGroups, groupUpdates, diags := updateGroups(inStateAssignmentOrder, plannedAssignmentOrder, actorLookupService, forceUpdate, updateGroupRole)
 	if diags != nil {
 		return nil, diags
 	}
 
-	computedGroups, diags := updateGroups(inStateAssignmentOrder, plannedAssignmentOrder, actorLookupService, forceUpdate, updateGroupRole)
+	updates := append(userUpdates, groupUpdates...)
+	diags = parallel.Diagnostics(updates, parallel.Run(parallelism, updates))
 	if diags != nil {
 		return nil, diags
 	}
@@ -220,138 +316,236 @@ func UpdateAssignment(ctx context.Context, actorLookupService *cloud.ActorLookup
 	return createAssignmentResult(ctx, computedUsers, computedGroups)
 }
 
+// updateUsers compares the users by account ID, so that a user whose email changed keeps its roles
+// instead of being removed and added again.
 func updateUsers(inStateAssignmentOrder AssignmentOrder, plannedAssignmentOrder AssignmentOrder,
-	actorLookupService *cloud.ActorLookupService, forceUpdate bool, updateUserRoles UpdateUserRolesFunc) ([]ComputedAssignment, diag.Diagnostics) {
-	var err error
+	actorLookupService *lookup.ActorLookupService, forceUpdate bool, updateUserRoles UpdateUserRolesFunc) ([]ComputedAssignment, []parallel.Update, diag.Diagnostics) {
+	inStateUsers, diags := inStateAssignmentOrder.ResolveUsers(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
 
-	var computedUsers = make([]ComputedAssignment, 0)
-	_, removing := collections.Delta(inStateAssignmentOrder.UserNames, plannedAssignmentOrder.UserNames)
-	for _, user := range plannedAssignmentOrder.UserNames {
-		if collections.Contains(removing, user) {
-			continue
-		}
+	plannedUsers, diags := plannedAssignmentOrder.ResolveUsers(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
 
-		found := actorLookupService.FindUser(user)
-		if found == nil {
-			continue
-		}
+	computed, updates := updateActors(inStateUsers, plannedUsers, forceUpdate, updateUserRoles, failedToUpdateUserRoles, failedToRemoveUserRoles)
+	return computed, updates, nil
+}
 
-		requestedRoles := plannedAssignmentOrder.Users[user]
-		inStateRoles := inStateAssignmentOrder.Users[user]
-		computedUsers = append(computedUsers, ComputedAssignment{
-			Name:  user,
-			Roles: requestedRoles,
+// updateGroups compares the groups by group ID, so that a renamed group keeps its roles
+// instead of being removed and added again.
+func updateGroups(inStateAssignmentOrder AssignmentOrder, plannedAssignmentOrder AssignmentOrder,
+	actorLookupService *lookup.ActorLookupService, forceUpdate bool, updateGroupRoles UpdateGroupRolesFunc) ([]ComputedAssignment, []parallel.Update, diag.Diagnostics) {
+	inStateGroups, diags := inStateAssignmentOrder.ResolveGroups(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
+
+	plannedGroups, diags := plannedAssignmentOrder.ResolveGroups(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
+
+	computed, updates := updateActors(inStateGroups, plannedGroups, forceUpdate, updateGroupRoles, failedToUpdateGroupRoles, failedToRemoveGroupRoles)
+	return computed, updates, nil
+}
+
+// updateActors returns the computed assignments of the planned actors, and the updates of the actors whose roles
+// change, the actors only in state lose their roles.
+func updateActors(inStateActors []ResolvedActor, plannedActors []ResolvedActor, forceUpdate bool,
+	updateRoles func(id string, requestedRoles []string) error,
+	failedToUpdate string, failedToRemove string) ([]ComputedAssignment, []parallel.Update) {
+	var computed = make([]ComputedAssignment, 0)
+	var updates []parallel.Update
+
+	inStateRoles := RolesById(inStateActors)
+	plannedRoles := RolesById(plannedActors)
+	for _, actor := range plannedActors {
+		computed = append(computed, ComputedAssignment{
+			Name:  actor.Reference,
+			Id:    actor.Id,
+			Roles: actor.Roles,
 		})
 
-		if !collections.EqualsIgnoreOrder(inStateRoles, requestedRoles) || forceUpdate {
-			err = updateUserRoles(user, requestedRoles)
-			if err != nil {
-				return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToUpdateUserRoles, err.Error())}
-			}
+		if !collections.EqualsIgnoreOrder(inStateRoles[actor.Id], actor.Roles) || forceUpdate {
+			updates = append(updates, parallel.Update{Name: actor.Name, Id: actor.Id, Grants: actor.Roles, Apply: updateRoles, Failure: failedToUpdate})
 		}
 	}
 
-	for _, user := range removing {
-		err := updateUserRoles(user, make([]string, 0))
-		if err != nil {
-			return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveUserRoles, err.Error())}
+	for _, actor := range inStateActors {
+		if _, ok := plannedRoles[actor.Id]; ok {
+			continue
 		}
+
+		updates = append(updates, parallel.Update{Name: actor.Name, Id: actor.Id, Grants: make([]string, 0), Apply: updateRoles, Failure: failedToRemove})
 	}
-	return computedUsers, nil
+
+	return computed, updates
 }
 
-func updateGroups(inStateAssignmentOrder AssignmentOrder, plannedAssignmentOrder AssignmentOrder,
-	actorLookupService *cloud.ActorLookupService, forceUpdate bool, updateGroupRoles UpdateGroupRolesFunc) ([]ComputedAssignment, diag.Diagnostics) {
-	var err error
-	var computedGroups = make([]ComputedAssignment, 0)
+// RemoveAssignment takes every role away from the actors of the assignments, updating at most parallelism actors
+// at once.
+func RemoveAssignment(ctx context.Context, actorLookupService *lookup.ActorLookupService,
+	assignedRoles *jira.ObjectRoles, assignmentOrder *AssignmentOrder,
+	parallelism int,
+	updateUserRoles UpdateUserRolesFunc,
+	updateGroupRoles UpdateGroupRolesFunc) diag.Diagnostics {
 
-	_, removing := collections.Delta(inStateAssignmentOrder.GroupNames, plannedAssignmentOrder.GroupNames)
-	for _, group := range plannedAssignmentOrder.GroupNames {
-		if collections.Contains(removing, group) {
-			continue
+	users, diags := assignmentOrder.ResolveUsers(actorLookupService)
+	if diags != nil {
+		return diags
+	}
+
+	groups, diags := assignmentOrder.ResolveGroups(actorLookupService)
+	if diags != nil {
+		return diags
+	}
+
+	var updates []parallel.Update
+	for _, user := range users {
+		if assignedRoles.FindUser(user.Id) != nil {
+			updates = append(updates, parallel.Update{Name: user.Name, Id: user.Id, Grants: make([]string, 0), Apply: updateUserRoles, Failure: failedToRemoveUserRoles})
 		}
+	}
 
-		found := actorLookupService.FindGroup(group)
-		if found == nil {
-			continue
+	for _, group := range groups {
+		if assignedRoles.FindGroup(group.Id) != nil {
+			updates = append(updates, parallel.Update{Name: group.Name, Id: group.Id, Grants: make([]string, 0), Apply: updateGroupRoles, Failure: failedToRemoveGroupRoles})
 		}
+	}
 
-		requestedRoles := plannedAssignmentOrder.Groups[group]
-		inStateRoles := inStateAssignmentOrder.Groups[group]
-		computedGroups = append(computedGroups, ComputedAssignment{
-			Name:  group,
-			Roles: requestedRoles,
-		})
+	return parallel.Diagnostics(updates, parallel.Run(parallelism, updates))
+}
+
+// ComputeJiraAssignment computes the roles of the actors in the assignments. Actors that exist but hold none of the
+// roles are included without roles, so that a removal made outside Terraform shows up as drift.
+func ComputeJiraAssignment(ctx context.Context, actorLookupService *lookup.ActorLookupService,
+	assignedRoles *jira.ObjectRoles, assignmentOrder AssignmentOrder) (*AssignmentResult, diag.Diagnostics) {
+	computedUsers, computedGroups, diags := computeAssignments(actorLookupService, assignedRoles, assignmentOrder)
+	if diags != nil {
+		return nil, diags
+	}
 
-		if !collections.EqualsIgnoreOrder(inStateRoles, requestedRoles) || forceUpdate {
-			err = updateGroupRoles(group, requestedRoles)
-			if err != nil {
-				return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToUpdateGroupRoles, err.Error())}
-			}
+	return createAssignmentResult(ctx, computedUsers, computedGroups)
+}
+
+// ComputeAppliedAssignment computes the assignments an apply that failed part way left, from the roles the actors hold
+// now. The actors of the in state assignments that are not planned are kept while they hold a role, as leftovers the
+// next apply removes.
+func ComputeAppliedAssignment(ctx context.Context, actorLookupService *lookup.ActorLookupService,
+	assignedRoles *jira.ObjectRoles, plannedAssignmentOrder AssignmentOrder, inStateAssignmentOrder *AssignmentOrder) (*AssignmentResult, diag.Diagnostics) {
+	computedUsers, computedGroups, diags := computeAssignments(actorLookupService, assignedRoles, plannedAssignmentOrder)
+	if diags != nil {
+		return nil, diags
+	}
+
+	if inStateAssignmentOrder != nil {
+		inStateUsers, inStateGroups, diags := computeAssignments(actorLookupService, assignedRoles, *inStateAssignmentOrder)
+		if diags != nil {
+			return nil, diags
 		}
+
+		computedUsers = appendLeftovers(computedUsers, inStateUsers)
+		computedGroups = appendLeftovers(computedGroups, inStateGroups)
 	}
 
-	for _, group := range removing {
-		err := updateGroupRoles(group, make([]string, 0))
-		if err != nil {
-			return nil, []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveGroupRoles, err.Error())}
+	return createAssignmentResult(ctx, computedUsers, computedGroups)
+}
+
+// appendLeftovers appends the in state actors that are not planned and still hold a role.
+func appendLeftovers(computed []ComputedAssignment, inState []ComputedAssignment) []ComputedAssignment {
+	for _, actor := range inState {
+		planned := slices.ContainsFunc(computed, func(assignment ComputedAssignment) bool {
+			return assignment.Id == actor.Id
+		})
+		if !planned && len(actor.Roles) > 0 {
+			computed = append(computed, actor)
 		}
 	}
 
-	return computedGroups, nil
+	return computed
 }
 
-func RemoveAssignment(ctx context.Context,
-	assignedRoles *jira.ObjectRoles, assignmentOrder *AssignmentOrder,
-	updateUserRoles UpdateUserRolesFunc,
-	updateGroupRoles UpdateGroupRolesFunc) diag.Diagnostics {
+// ComputeAuthoritativeAssignment computes the assignments like ComputeJiraAssignment, and adds the unmanaged actors
+// so that they show up as drift.
+func ComputeAuthoritativeAssignment(ctx context.Context, actorLookupService *lookup.ActorLookupService,
+	assignedRoles *jira.ObjectRoles, assignmentOrder AssignmentOrder, unmanaged UnmanagedActors) (*AssignmentResult, diag.Diagnostics) {
+	computedUsers, computedGroups, diags := computeAssignments(actorLookupService, assignedRoles, assignmentOrder)
+	if diags != nil {
+		return nil, diags
+	}
 
-	for _, user := range assignedRoles.Users {
-		if _, ok := assignmentOrder.Users[user.Name]; ok {
-			err := updateUserRoles(user.Name, make([]string, 0))
-			if err != nil {
-				return []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveUserRoles, err.Error())}
-			}
-		}
+	for _, user := range unmanaged.Users {
+		computedUsers = append(computedUsers, ComputedAssignment{
+			Name:  user.Name,
+			Id:    user.AccountId,
+			Roles: user.Roles,
+		})
 	}
 
-	for _, group := range assignedRoles.Groups {
-		if _, ok := assignmentOrder.Groups[group.Name]; ok {
-			err := updateGroupRoles(group.Name, make([]string, 0))
-			if err != nil {
-				return []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveGroupRoles, err.Error())}
-			}
-		}
+	for _, group := range unmanaged.Groups {
+		computedGroups = append(computedGroups, ComputedAssignment{
+			Name:  group.Name,
+			Id:    group.AccountId,
+			Roles: group.Roles,
+		})
 	}
 
-	return nil
+	return createAssignmentResult(ctx, computedUsers, computedGroups)
 }
 
-func ComputeJiraAssignment(ctx context.Context,
-	assignedRoles *jira.ObjectRoles, assignmentOrder AssignmentOrder) (*AssignmentResult, diag.Diagnostics) {
+func computeAssignments(actorLookupService *lookup.ActorLookupService,
+	assignedRoles *jira.ObjectRoles, assignmentOrder AssignmentOrder) ([]ComputedAssignment, []ComputedAssignment, diag.Diagnostics) {
+	users, diags := assignmentOrder.ResolveUsers(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
+
+	groups, diags := assignmentOrder.ResolveGroups(actorLookupService)
+	if diags != nil {
+		return nil, nil, diags
+	}
 
 	computedUsers := make([]ComputedAssignment, 0)
 	computedGroups := make([]ComputedAssignment, 0)
 
-	for _, user := range assignedRoles.Users {
-		if _, ok := assignmentOrder.Users[user.Name]; ok {
-			computedUsers = append(computedUsers, ComputedAssignment{
-				Name:  user.Name,
-				Roles: user.Roles,
-			})
+	for _, user := range users {
+		roles := make([]string, 0)
+		if assigned := assignedRoles.FindUser(user.Id); assigned != nil {
+			roles = assigned.Roles
 		}
+
+		if user.Leftover && len(roles) == 0 {
+			continue
+		}
+
+		computedUsers = append(computedUsers, ComputedAssignment{
+			Name:  user.Reference,
+			Id:    user.Id,
+			Roles: roles,
+		})
 	}
 
-	for _, group := range assignedRoles.Groups {
-		if _, ok := assignmentOrder.Groups[group.Name]; ok {
-			computedGroups = append(computedGroups, ComputedAssignment{
-				Name:  group.Name,
-				Roles: group.Roles,
-			})
+	for _, group := range groups {
+		roles := make([]string, 0)
+		if assigned := assignedRoles.FindGroup(group.Id); assigned != nil {
+			roles = assigned.Roles
+		}
+
+		if group.Leftover && len(roles) == 0 {
+			continue
 		}
+
+		computedGroups = append(computedGroups, ComputedAssignment{
+			Name:  group.Reference,
+			Id:    group.Id,
+			Roles: roles,
+		})
 	}
 
-	return createAssignmentResult(ctx, computedUsers, computedGroups)
+	return computedUsers, computedGroups, nil
 }
 
 func createAssignmentResult(ctx context.Context, computedUsers []ComputedAssignment, computedGroups []ComputedAssignment) (*AssignmentResult, diag.Diagnostics) {
@@ -0,0 +1,134 @@
+package jira
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/yunarta/golang-quality-of-life-pack/collections"
+	"github.com/yunarta/terraform-atlassian-api-client/jira"
+	"github.com/yunarta/terraform-atlassian-api-client/util"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultAuthoritativeAllowList is kept by authoritative projects unless configured otherwise,
+// Connect apps are granted project access through this role.
+var DefaultAuthoritativeAllowList = []string{"atlassian-addons-project-access"}
+
+// UnmanagedActors are the users and groups holding a managed role without being in the assignments,
+// with the managed roles they hold.
+type UnmanagedActors struct {
+	Users  []jira.UserRoles
+	Groups []jira.GroupRoles
+}
+
+// FindUnmanagedActors looks up the actors an authoritative resource has to remove from the managed roles.
+// An entry of allowList matches a role name, a user email or account ID, or a group name or group ID.
+func FindUnmanagedActors(actorLookupService *lookup.ActorLookupService, assignedRoles *jira.ObjectRoles,
+	assignmentOrder AssignmentOrder, allowList []string) UnmanagedActors {
+	var unmanaged UnmanagedActors
+	if assignedRoles == nil {
+		return unmanaged
+	}
+
+	_, managedRoles := collections.Delta(assignmentOrder.Roles, allowList)
+	configuredUsers, configuredGroups := resolvedIds(actorLookupService, assignmentOrder)
+
+	for _, user := range assignedRoles.Users {
+		name := user.Name
+		if found := actorLookupService.FindUserById(user.AccountId); found != nil {
+			name = util.CoalesceString(found.EmailAddress, found.DisplayName)
+		}
+
+		if collections.Contains(configuredUsers, user.AccountId) ||
+			collections.Contains(allowList, name) || collections.Contains(allowList, user.AccountId) {
+			continue
+		}
+
+		roles := collections.Intersect(user.Roles, managedRoles)
+		if len(roles) > 0 {
+			unmanaged.Users = append(unmanaged.Users, jira.UserRoles{Name: name, AccountId: user.AccountId, Roles: roles})
+		}
+	}
+
+	for _, group := range assignedRoles.Groups {
+		if collections.Contains(configuredGroups, group.AccountId) ||
+			collections.Contains(allowList, group.Name) || collections.Contains(allowList, group.AccountId) {
+			continue
+		}
+
+		roles := collections.Intersect(group.Roles, managedRoles)
+		if len(roles) > 0 {
+			unmanaged.Groups = append(unmanaged.Groups, jira.GroupRoles{Name: group.Name, AccountId: group.AccountId, Roles: roles})
+		}
+	}
+
+	return unmanaged
+}
+
+// Without leaves out the actors of assignmentOrder.
+func (unmanaged UnmanagedActors) Without(actorLookupService *lookup.ActorLookupService, assignmentOrder AssignmentOrder) UnmanagedActors {
+	userIds, groupIds := resolvedIds(actorLookupService, assignmentOrder)
+	return UnmanagedActors{
+		Users: slices.DeleteFunc(slices.Clone(unmanaged.Users), func(user jira.UserRoles) bool {
+			return collections.Contains(userIds, user.AccountId)
+		}),
+		Groups: slices.DeleteFunc(slices.Clone(unmanaged.Groups), func(group jira.GroupRoles) bool {
+			return collections.Contains(groupIds, group.AccountId)
+		}),
+	}
+}
+
+// resolvedIds returns the account IDs and group IDs of the actors of assignmentOrder, an actor named twice is
+// reported when the assignments are applied.
+func resolvedIds(actorLookupService *lookup.ActorLookupService, assignmentOrder AssignmentOrder) ([]string, []string) {
+	users, _ := assignmentOrder.ResolveUsers(actorLookupService)
+	groups, _ := assignmentOrder.ResolveGroups(actorLookupService)
+	return collections.GetKeysOfMap(RolesById(users)), collections.GetKeysOfMap(RolesById(groups))
+}
+
+// Remove takes the managed roles away from every unmanaged actor, the roles they hold outside of them are kept.
+func (unmanaged UnmanagedActors) Remove(assignedRoles *jira.ObjectRoles,
+	updateUserRoles UpdateUserRolesFunc,
+	updateGroupRoles UpdateGroupRolesFunc) diag.Diagnostics {
+
+	for _, user := range unmanaged.Users {
+		_, keep := collections.Delta(assignedRoles.FindUser(user.AccountId).Roles, user.Roles)
+		err := updateUserRoles(user.AccountId, keep)
+		if err != nil {
+			return []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveUserRoles, err.Error())}
+		}
+	}
+
+	for _, group := range unmanaged.Groups {
+		_, keep := collections.Delta(assignedRoles.FindGroup(group.AccountId).Roles, group.Roles)
+		err := updateGroupRoles(group.AccountId, keep)
+		if err != nil {
+			return []diag.Diagnostic{diag.NewErrorDiagnostic(failedToRemoveGroupRoles, err.Error())}
+		}
+	}
+
+	return nil
+}
+
+// Drift describes the unmanaged actors as a warning, nil when there are none.
+func (unmanaged UnmanagedActors) Drift(projectKey string) diag.Diagnostics {
+	var lines []string
+	for _, user := range unmanaged.Users {
+		lines = append(lines, fmt.Sprintf("user %s: %s", user.Name, strings.Join(user.Roles, ", ")))
+	}
+	for _, group := range unmanaged.Groups {
+		lines = append(lines, fmt.Sprintf("group %s: %s", group.Name, strings.Join(group.Roles, ", ")))
+	}
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	sort.Strings(lines)
+	return diag.Diagnostics{diag.NewWarningDiagnostic("Unmanaged actors in authoritative roles",
+		fmt.Sprintf("Project %s has actors that are not in the assignments, they will be removed on the next apply:\n  %s",
+			projectKey, strings.Join(lines, "\n  ")),
+	)}
+}
@@ -0,0 +1,394 @@
+package jira
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/yunarta/golang-quality-of-life-pack/collections"
+	"github.com/yunarta/terraform-atlassian-api-client/jira"
+	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	"github.com/yunarta/terraform-atlassian-api-client/util"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/parallel"
+	"slices"
+	"sync"
+)
+
+const (
+	userRoleActor  = "atlassian-user-role-actor"
+	groupRoleActor = "atlassian-group-role-actor"
+)
+
+// ProjectRoleManager reads the actors of the project roles and changes them in batches. UpdateUserRoles and
+// UpdateGroupRoles only record the change, Apply then sends one request adding actors for each role, however many
+// actors the assignments add. Jira removes a single actor per request, so removals are sent one actor at a time.
+// The updates of different actors may be recorded at once, and Apply changes up to Parallelism roles at once.
+type ProjectRoleManager struct {
+	client             *cloud.JiraClient
+	actorLookupService *lookup.ActorLookupService
+	projectIdOrKey     string
+
+	Parallelism int
+
+	// mutex guards changes and assignedRoles while the actors and roles are updated in parallel
+	mutex sync.Mutex
+
+	assignedRoles *jira.ObjectRoles
+	// roleIds are the IDs of the roles read, by name
+	roleIds map[string]string
+	// changes are the actors to add to and remove from each role, by role name
+	changes map[string]*roleChange
+}
+
+type roleChange struct {
+	addingUsers    []string
+	removingUsers  []string
+	addingGroups   []string
+	removingGroups []string
+}
+
+func NewProjectRoleManager(client *cloud.JiraClient, actorLookupService *lookup.ActorLookupService, projectIdOrKey string) *ProjectRoleManager {
+	return &ProjectRoleManager{
+		client:             client,
+		actorLookupService: actorLookupService,
+		projectIdOrKey:     projectIdOrKey,
+		assignedRoles:      &jira.ObjectRoles{},
+		roleIds:            map[string]string{},
+		changes:            map[string]*roleChange{},
+	}
+}
+
+// ReadRoles reads the actors of the named roles. The users holding them are looked up with one request,
+// rather than one request per user.
+func (manager *ProjectRoleManager) ReadRoles(roles []string) (*jira.ObjectRoles, error) {
+	return manager.readRoles(func(role string) bool {
+		return collections.Contains(roles, role)
+	})
+}
+
+// ReadAllRoles reads the actors of every role of the project.
+func (manager *ProjectRoleManager) ReadAllRoles() (*jira.ObjectRoles, error) {
+	return manager.readRoles(func(role string) bool {
+		return true
+	})
+}
+
+func (manager *ProjectRoleManager) readRoles(include func(role string) bool) (*jira.ObjectRoles, error) {
+	projectRoles, err := manager.client.ProjectRoleService().ReadProjectRoles(manager.projectIdOrKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var userRoles, groupRoles = map[string][]string{}, map[string][]string{}
+	var actorNames = map[string]string{}
+	for _, role := range projectRoles {
+		if !include(role.Name) {
+			continue
+		}
+
+		manager.roleIds[role.Name] = role.ID
+		actors, err := manager.client.ProjectRoleService().ReadProjectRoleActors(manager.projectIdOrKey, role.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, actor := range actors {
+			switch actor.Type {
+			case userRoleActor:
+				userRoles[actor.ActorUser.AccountID] = append(userRoles[actor.ActorUser.AccountID], role.Name)
+				actorNames[actor.ActorUser.AccountID] = actor.DisplayName
+			case groupRoleActor:
+				groupRoles[actor.ActorGroup.GroupId] = append(groupRoles[actor.ActorGroup.GroupId], role.Name)
+				actorNames[actor.ActorGroup.GroupId] = actor.DisplayName
+			}
+		}
+	}
+
+	lookupService := manager.actorLookupService
+	accountIds := collections.SortStrings(collections.GetKeysOfMap(userRoles))
+	groupIds := collections.SortStrings(collections.GetKeysOfMap(groupRoles))
+	if len(accountIds) > 0 {
+		lookupService.RegisterAccountIds(accountIds...)
+	}
+	lookupService.RegisterGroupIds(groupIds...)
+
+	var assignedRoles = &jira.ObjectRoles{Users: []jira.UserRoles{}, Groups: []jira.GroupRoles{}}
+	for _, accountId := range accountIds {
+		name := actorNames[accountId]
+		if found := lookupService.FindUserById(accountId); found != nil {
+			name = util.CoalesceString(found.EmailAddress, found.DisplayName)
+		}
+
+		assignedRoles.Users = append(assignedRoles.Users, jira.UserRoles{
+			Name:      name,
+			AccountId: accountId,
+			Roles:     userRoles[accountId],
+		})
+	}
+
+	for _, groupId := range groupIds {
+		assignedRoles.Groups = append(assignedRoles.Groups, jira.GroupRoles{
+			Name:      actorNames[groupId],
+			AccountId: groupId,
+			Roles:     groupRoles[groupId],
+		})
+	}
+
+	manager.assignedRoles = assignedRoles
+	return assignedRoles, nil
+}
+
+// UpdateUserRoles records the roles the user with the account ID is to hold, of the roles read.
+func (manager *ProjectRoleManager) UpdateUserRoles(accountId string, roles []string) error {
+	found := manager.actorLookupService.FindUserById(accountId)
+	if found == nil {
+		return fmt.Errorf("unable to find user %s", accountId)
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	var assigned []string
+	if userRoles := manager.assignedRoles.FindUser(found.AccountID); userRoles != nil {
+		assigned = userRoles.Roles
+	}
+
+	return manager.record(assigned, roles, func(change *roleChange, adding bool) {
+		if adding {
+			change.addingUsers = append(change.addingUsers, found.AccountID)
+		} else {
+			change.removingUsers = append(change.removingUsers, found.AccountID)
+		}
+	})
+}
+
+// UpdateGroupRoles records the roles the group with the group ID is to hold, of the roles read.
+func (manager *ProjectRoleManager) UpdateGroupRoles(groupId string, roles []string) error {
+	found := manager.actorLookupService.FindGroupById(groupId)
+	if found == nil {
+		return fmt.Errorf("unable to find group %s", groupId)
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	var assigned []string
+	if groupRoles := manager.assignedRoles.FindGroup(found.GroupId); groupRoles != nil {
+		assigned = groupRoles.Roles
+	}
+
+	return manager.record(assigned, roles, func(change *roleChange, adding bool) {
+		if adding {
+			change.addingGroups = append(change.addingGroups, found.GroupId)
+		} else {
+			change.removingGroups = append(change.removingGroups, found.GroupId)
+		}
+	})
+}
+
+// record adds the actor to the changes of the roles it gains and loses, going from the assigned roles to roles.
+func (manager *ProjectRoleManager) record(assigned []string, roles []string, add func(change *roleChange, adding bool)) error {
+	adding, removing := collections.Delta(assigned, roles)
+	for _, role := range adding {
+		change, err := manager.change(role)
+		if err != nil {
+			return err
+		}
+		add(change, true)
+	}
+
+	for _, role := range removing {
+		change, err := manager.change(role)
+		if err != nil {
+			return err
+		}
+		add(change, false)
+	}
+
+	return nil
+}
+
+func (manager *ProjectRoleManager) change(role string) (*roleChange, error) {
+	if _, ok := manager.roleIds[role]; !ok {
+		return nil, fmt.Errorf("role %s is not in project %s", role, manager.projectIdOrKey)
+	}
+
+	change, ok := manager.changes[role]
+	if !ok {
+		change = &roleChange{}
+		manager.changes[role] = change
+	}
+
+	return change, nil
+}
+
+// AssignedRoles returns the roles the actors hold, the roles read with the changes Apply sent since.
+func (manager *ProjectRoleManager) AssignedRoles() *jira.ObjectRoles {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	return manager.assignedRoles
+}
+
+// Snapshot returns a copy of AssignedRoles, which the changes applied later leave as it is.
+func (manager *ProjectRoleManager) Snapshot() *jira.ObjectRoles {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	return &jira.ObjectRoles{
+		Users:  slices.Clone(manager.assignedRoles.Users),
+		Groups: slices.Clone(manager.assignedRoles.Groups),
+	}
+}
+
+// Restore puts the actors back in the roles they held in snapshot, dropping the changes that were not sent.
+// The actors Apply added to a role are in AssignedRoles, so comparing it to the snapshot finds every change sent.
+func (manager *ProjectRoleManager) Restore(snapshot *jira.ObjectRoles) diag.Diagnostics {
+	manager.changes = map[string]*roleChange{}
+
+	var diags diag.Diagnostics
+	for _, user := range manager.assignedRoles.Users {
+		var roles []string
+		if previous := snapshot.FindUser(user.AccountId); previous != nil {
+			roles = previous.Roles
+		}
+
+		err := manager.record(user.Roles, roles, func(change *roleChange, adding bool) {
+			if adding {
+				change.addingUsers = append(change.addingUsers, user.AccountId)
+			} else {
+				change.removingUsers = append(change.removingUsers, user.AccountId)
+			}
+		})
+		if err != nil {
+			diags.AddError(failedToUpdateProjectRole, fmt.Sprintf("%s: %s", user.Name, err.Error()))
+		}
+	}
+
+	for _, group := range manager.assignedRoles.Groups {
+		var roles []string
+		if previous := snapshot.FindGroup(group.AccountId); previous != nil {
+			roles = previous.Roles
+		}
+
+		err := manager.record(group.Roles, roles, func(change *roleChange, adding bool) {
+			if adding {
+				change.addingGroups = append(change.addingGroups, group.AccountId)
+			} else {
+				change.removingGroups = append(change.removingGroups, group.AccountId)
+			}
+		})
+		if err != nil {
+			diags.AddError(failedToUpdateProjectRole, fmt.Sprintf("%s: %s", group.Name, err.Error()))
+		}
+	}
+
+	if diags.HasError() {
+		return diags
+	}
+
+	return manager.Apply()
+}
+
+// applied records that the users and groups were added to or removed from role.
+func (manager *ProjectRoleManager) applied(role string, accountIds []string, groupIds []string, added bool) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	for _, accountId := range accountIds {
+		index := slices.IndexFunc(manager.assignedRoles.Users, func(user jira.UserRoles) bool {
+			return user.AccountId == accountId
+		})
+		if index < 0 {
+			manager.assignedRoles.Users = append(manager.assignedRoles.Users, jira.UserRoles{AccountId: accountId})
+			index = len(manager.assignedRoles.Users) - 1
+		}
+
+		user := &manager.assignedRoles.Users[index]
+		user.Roles = withRole(user.Roles, role, added)
+	}
+
+	for _, groupId := range groupIds {
+		index := slices.IndexFunc(manager.assignedRoles.Groups, func(group jira.GroupRoles) bool {
+			return group.AccountId == groupId
+		})
+		if index < 0 {
+			manager.assignedRoles.Groups = append(manager.assignedRoles.Groups, jira.GroupRoles{AccountId: groupId})
+			index = len(manager.assignedRoles.Groups) - 1
+		}
+
+		group := &manager.assignedRoles.Groups[index]
+		group.Roles = withRole(group.Roles, role, added)
+	}
+}
+
+func withRole(roles []string, role string, added bool) []string {
+	roles = slices.DeleteFunc(slices.Clone(roles), func(held string) bool {
+		return held == role
+	})
+	if added {
+		roles = append(roles, role)
+	}
+
+	return roles
+}
+
+// Apply sends the recorded changes, adding the actors of a role before removing the others. A role stops at its
+// first request that fails while the other roles are still changed, the changes sent are not recorded again and
+// show in AssignedRoles.
+func (manager *ProjectRoleManager) Apply() diag.Diagnostics {
+	roles := collections.SortStrings(collections.GetKeysOfMap(manager.changes))
+	errs := parallel.ForEach(manager.Parallelism, len(roles), func(i int) error {
+		return manager.apply(roles[i])
+	})
+
+	var diags diag.Diagnostics
+	for _, err := range errs {
+		if err != nil {
+			diags.AddError(failedToUpdateProjectRole, err.Error())
+		}
+	}
+
+	return diags
+}
+
+func (manager *ProjectRoleManager) apply(role string) error {
+	service := manager.client.ProjectRoleService()
+
+	manager.mutex.Lock()
+	change := manager.changes[role]
+	manager.mutex.Unlock()
+	roleId := manager.roleIds[role]
+
+	err := service.AddProjectRole(manager.projectIdOrKey, roleId, change.addingUsers, change.addingGroups)
+	if err != nil {
+		return fmt.Errorf("Adding actors to role %s: %s", role, err.Error())
+	}
+	manager.applied(role, change.addingUsers, change.addingGroups, true)
+	change.addingUsers, change.addingGroups = nil, nil
+
+	for len(change.removingUsers) > 0 {
+		accountId := change.removingUsers[0]
+		err = service.RemoveProjectRole(manager.projectIdOrKey, roleId, []string{accountId}, nil)
+		if err != nil {
+			return fmt.Errorf("Removing user %s from role %s: %s", accountId, role, err.Error())
+		}
+		manager.applied(role, []string{accountId}, nil, false)
+		change.removingUsers = change.removingUsers[1:]
+	}
+
+	for len(change.removingGroups) > 0 {
+		groupId := change.removingGroups[0]
+		err = service.RemoveProjectRole(manager.projectIdOrKey, roleId, nil, []string{groupId})
+		if err != nil {
+			return fmt.Errorf("Removing group %s from role %s: %s", groupId, role, err.Error())
+		}
+		manager.applied(role, nil, []string{groupId}, false)
+		change.removingGroups = change.removingGroups[1:]
+	}
+
+	manager.mutex.Lock()
+	delete(manager.changes, role)
+	manager.mutex.Unlock()
+
+	return nil
+}
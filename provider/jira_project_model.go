@@ -22,10 +22,15 @@ type ProjectModel struct {
 	DefaultAssignee types.String `tfsdk:"default_assignee"`
 	DeleteToTrash   types.Bool   `tfsdk:"delete_to_trash"`
 
-	AssignmentVersion types.String `tfsdk:"assignment_version"`
-	Assignments       types.List   `tfsdk:"assignments"`
-	ComputedUsers     types.List   `tfsdk:"computed_users"`
-	ComputedGroups    types.List   `tfsdk:"computed_groups"`
+	AssignmentVersion      types.String `tfsdk:"assignment_version"`
+	Authoritative          types.Bool   `tfsdk:"authoritative"`
+	AuthoritativeAllowList types.List   `tfsdk:"authoritative_allow_list"`
+	OnUnknownPrincipal     types.String `tfsdk:"on_unknown_principal"`
+	MergeStrategy          types.String `tfsdk:"merge_strategy"`
+	RollbackOnFailure      types.Bool   `tfsdk:"rollback_on_failure"`
+	Assignments            types.List   `tfsdk:"assignments"`
+	ComputedUsers          types.List   `tfsdk:"computed_users"`
+	ComputedGroups         types.List   `tfsdk:"computed_groups"`
 }
 
 var _ ProjectRoleInterface = &ProjectModel{}
@@ -41,6 +46,36 @@ func (p ProjectModel) getProjectIdOrKey(ctx context.Context) string {
 	return p.Key.ValueString()
 }
 
+func (p ProjectModel) getAuthoritative(ctx context.Context) (bool, []string, diag.Diagnostics) {
+	if !p.Authoritative.ValueBool() {
+		return false, nil, nil
+	}
+
+	if p.AuthoritativeAllowList.IsNull() || p.AuthoritativeAllowList.IsUnknown() {
+		return true, jira.DefaultAuthoritativeAllowList, nil
+	}
+
+	var allowList = make([]string, 0)
+	diags := p.AuthoritativeAllowList.ElementsAs(ctx, &allowList, true)
+	return true, allowList, diags
+}
+
+func (p ProjectModel) getOnUnknownPrincipal() types.String {
+	return p.OnUnknownPrincipal
+}
+
+func (p ProjectModel) getMergeStrategy() string {
+	return p.MergeStrategy.ValueString()
+}
+
+func (p ProjectModel) getRollbackOnFailure() bool {
+	return p.RollbackOnFailure.ValueBool()
+}
+
+func (p ProjectModel) getAssignmentResult() jira.AssignmentResult {
+	return jira.AssignmentResult{ComputedUsers: p.ComputedUsers, ComputedGroups: p.ComputedGroups}
+}
+
 func NewProjectModel(plan ProjectModel, project *jiraApi.Project, assignmentResult *jira.AssignmentResult) *ProjectModel {
 	var categoryId types.Int64
 	if len(project.ProjectCategory.ID) > 0 {
@@ -51,18 +86,23 @@ func NewProjectModel(plan ProjectModel, project *jiraApi.Project, assignmentResu
 	}
 
 	return &ProjectModel{
-		RetainOnDelete:    plan.RetainOnDelete,
-		AccountId:         types.StringValue(project.ID),
-		Key:               types.StringValue(project.Key),
-		Name:              types.StringValue(project.Name),
-		ProjectType:       types.StringValue(project.ProjectTypeKey),
-		Description:       util.NullString(project.Description),
-		CategoryId:        categoryId,
-		LeadAccount:       types.StringValue(project.Lead.AccountID),
-		DefaultAssignee:   types.StringValue(project.AssigneeType),
-		AssignmentVersion: plan.AssignmentVersion,
-		Assignments:       plan.Assignments,
-		ComputedUsers:     assignmentResult.ComputedUsers,
-		ComputedGroups:    assignmentResult.ComputedGroups,
+		RetainOnDelete:         plan.RetainOnDelete,
+		AccountId:              types.StringValue(project.ID),
+		Key:                    types.StringValue(project.Key),
+		Name:                   types.StringValue(project.Name),
+		ProjectType:            types.StringValue(project.ProjectTypeKey),
+		Description:            util.NullString(project.Description),
+		CategoryId:             categoryId,
+		LeadAccount:            types.StringValue(project.Lead.AccountID),
+		DefaultAssignee:        types.StringValue(project.AssigneeType),
+		AssignmentVersion:      plan.AssignmentVersion,
+		Authoritative:          plan.Authoritative,
+		AuthoritativeAllowList: plan.AuthoritativeAllowList,
+		OnUnknownPrincipal:     plan.OnUnknownPrincipal,
+		MergeStrategy:          plan.MergeStrategy,
+		RollbackOnFailure:      plan.RollbackOnFailure,
+		Assignments:            plan.Assignments,
+		ComputedUsers:          assignmentResult.ComputedUsers,
+		ComputedGroups:         assignmentResult.ComputedGroups,
 	}
 }
@@ -2,19 +2,42 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/yunarta/golang-quality-of-life-pack/collections"
+	jiraApi "github.com/yunarta/terraform-atlassian-api-client/jira"
 	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
 	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/jira"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
+	"strings"
 )
 
 type ProjectRoleResource interface {
+	// getProviderConfig returns the configuration of the provider, nil until the resource is configured
+	getProviderConfig() *AtlassianCloudProviderConfig
 	getClient() *cloud.JiraClient
+	// getActorLookup returns the actor lookup cache of the client, shared by the resources of the provider
+	getActorLookup() *lookup.ActorLookupService
+	// getProviderAccountId returns the account ID of the provider, empty unless the credentials were validated
+	getProviderAccountId() string
 }
 
 type ProjectRoleInterface interface {
 	getAssignment(ctx context.Context) (jira.Assignments, diag.Diagnostics)
 	getProjectIdOrKey(ctx context.Context) string
+	// getAuthoritative returns whether actors outside the assignments are removed from the managed roles,
+	// and the roles and actors that are left alone
+	getAuthoritative(ctx context.Context) (bool, []string, diag.Diagnostics)
+	// getOnUnknownPrincipal returns the resource setting for users and groups that do not exist, null for the provider default
+	getOnUnknownPrincipal() types.String
+	// getMergeStrategy returns how the assignments naming the same actor are combined
+	getMergeStrategy() string
+	// getRollbackOnFailure returns whether the role changes of an apply that fails are undone
+	getRollbackOnFailure() bool
+	// getAssignmentResult returns the computed users and groups, with the IDs they resolved to
+	getAssignmentResult() jira.AssignmentResult
 }
 
 func CreateProjectRoleAssignments(ctx context.Context, receiver ProjectRoleResource, plan ProjectRoleInterface) (*jira.AssignmentResult, diag.Diagnostics) {
@@ -23,38 +46,193 @@ func CreateProjectRoleAssignments(ctx context.Context, receiver ProjectRoleResou
 		return nil, diags
 	}
 
-	plannedAssignmentOrder, diags := plannedAssignment.CreateAssignmentOrder(ctx)
+	plannedAssignmentOrder, diags := plannedAssignment.CreateAssignmentOrder(ctx, plan.getMergeStrategy())
+	if diags != nil {
+		return nil, diags
+	}
+
+	authoritative, allowList, diags := plan.getAuthoritative(ctx)
 	if diags != nil {
 		return nil, diags
 	}
 
 	projectIdOrKey := plan.getProjectIdOrKey(ctx)
 
-	updateService := cloud.NewProjectRoleManager(
+	updateService := jira.NewProjectRoleManager(
 		receiver.getClient(),
+		receiver.getActorLookup(),
 		projectIdOrKey,
 	)
+	updateService.Parallelism = assignmentParallelism(receiver.getProviderConfig())
 
 	// Read both in state and planned roles to fill in the update service with prepared data
-	_, _ = updateService.ReadRoles(plannedAssignmentOrder.Roles)
-	// Register all usernames and groupNames in play to prepare the data
-	receiver.getClient().ActorLookupService().RegisterUsernames(
-		plannedAssignmentOrder.UserNames...,
-	)
-	receiver.getClient().ActorLookupService().RegisterGroupNames(
-		plannedAssignmentOrder.GroupNames...,
-	)
-	defer updateService.Finalized()
+	assignedRoles, err := updateService.ReadRoles(plannedAssignmentOrder.Roles)
+	if err != nil {
+		return nil, []diag.Diagnostic{diag.NewErrorDiagnostic("Failed to read project roles", err.Error())}
+	}
+	// Register all users and groups in play to prepare the data
+	jira.RegisterActors(receiver.getActorLookup(), *plannedAssignmentOrder)
+	// the roles read are the ones a failed apply is rolled back to
+	snapshot := updateService.Snapshot()
 
-	return jira.ApplyNewAssignmentSet(ctx, receiver.getClient().ActorLookupService(),
+	principals := resolveProjectPrincipals(ctx, receiver, plan, *plannedAssignmentOrder)
+	if principals.HasError() {
+		return nil, principals
+	}
+
+	computation, diags := jira.ApplyNewAssignmentSet(ctx, receiver.getActorLookup(),
 		*plannedAssignmentOrder,
-		func(user string, requestedRoles []string) error {
-			return updateService.UpdateUserRoles(user, requestedRoles)
-		},
-		func(group string, requestedRoles []string) error {
-			return updateService.UpdateUserRoles(group, requestedRoles)
-		},
+		assignmentParallelism(receiver.getProviderConfig()),
+		updateService.UpdateUserRoles,
+		updateService.UpdateGroupRoles,
 	)
+	if diags != nil {
+		return failedProjectRoleAssignments(ctx, receiver, plan, updateService, snapshot, *plannedAssignmentOrder, nil, append(principals, diags...))
+	}
+
+	if authoritative {
+		diags = removeUnmanagedActors(receiver, updateService, assignedRoles, nil, *plannedAssignmentOrder, allowList)
+		if diags.HasError() {
+			return failedProjectRoleAssignments(ctx, receiver, plan, updateService, snapshot, *plannedAssignmentOrder, nil, append(principals, diags...))
+		}
+	}
+
+	diags = append(append(principals, diags...), updateService.Apply()...)
+	if diags.HasError() {
+		return failedProjectRoleAssignments(ctx, receiver, plan, updateService, snapshot, *plannedAssignmentOrder, nil, diags)
+	}
+
+	return computation, diags
+}
+
+// failedProjectRoleAssignments rolls the roles back to snapshot when the resource asks for it, and computes the
+// assignments the failed apply left.
+func failedProjectRoleAssignments(ctx context.Context, receiver ProjectRoleResource, plan ProjectRoleInterface,
+	updateService *jira.ProjectRoleManager, snapshot *jiraApi.ObjectRoles,
+	plannedAssignmentOrder jira.AssignmentOrder, inStateAssignmentOrder *jira.AssignmentOrder,
+	diags diag.Diagnostics) (*jira.AssignmentResult, diag.Diagnostics) {
+	if diags.HasError() && plan.getRollbackOnFailure() {
+		diags = append(diags, rollbackProjectRoles(plan.getProjectIdOrKey(ctx), updateService, snapshot)...)
+	}
+
+	return appliedProjectRoleAssignments(ctx, receiver, updateService, plannedAssignmentOrder, inStateAssignmentOrder), diags
+}
+
+// rollbackProjectRoles undoes the role changes sent before the failure, reporting whether the roles are back
+// to the ones read.
+func rollbackProjectRoles(projectIdOrKey string, updateService *jira.ProjectRoleManager, snapshot *jiraApi.ObjectRoles) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for _, failure := range updateService.Restore(snapshot).Errors() {
+		diags.AddError("Failed to roll back project roles",
+			fmt.Sprintf("Project %s keeps some of the changes made before the failure, the computed users and groups show the roles held. %s",
+				projectIdOrKey, failure.Detail()))
+	}
+
+	if diags.HasError() {
+		return diags
+	}
+
+	return diag.Diagnostics{diag.NewWarningDiagnostic("Rolled back project roles",
+		fmt.Sprintf("The roles of project %s are back to the ones held before the apply.", projectIdOrKey))}
+}
+
+// appliedProjectRoleAssignments computes the assignments an apply that failed part way left, for the state to record
+// the roles changed before the failure and the next plan to only show the remaining changes.
+func appliedProjectRoleAssignments(ctx context.Context, receiver ProjectRoleResource, updateService *jira.ProjectRoleManager,
+	plannedAssignmentOrder jira.AssignmentOrder, inStateAssignmentOrder *jira.AssignmentOrder) *jira.AssignmentResult {
+	computation, diags := jira.ComputeAppliedAssignment(ctx, receiver.getActorLookup(),
+		updateService.AssignedRoles(), plannedAssignmentOrder, inStateAssignmentOrder)
+	if diags != nil {
+		return nil
+	}
+
+	return computation
+}
+
+// removeUnmanagedActors takes the managed roles away from the actors that are not in the assignments, warning when
+// this includes the provider account itself. Actors of the in state assignments are skipped, as they have been
+// removed from every role already.
+func removeUnmanagedActors(receiver ProjectRoleResource, updateService *jira.ProjectRoleManager,
+	assignedRoles *jiraApi.ObjectRoles, inStateAssignmentOrder *jira.AssignmentOrder, assignmentOrder jira.AssignmentOrder,
+	allowList []string) diag.Diagnostics {
+	lookupService := receiver.getActorLookup()
+	unmanaged := jira.FindUnmanagedActors(lookupService, assignedRoles, assignmentOrder, allowList)
+	if inStateAssignmentOrder != nil {
+		unmanaged = unmanaged.Without(lookupService, *inStateAssignmentOrder)
+	}
+
+	var inState = map[string][]string{}
+	for _, user := range unmanaged.Users {
+		inState[user.AccountId] = user.Roles
+	}
+	warnings := selfAdminRemoval(receiver.getProviderAccountId(), "Administrators", inState, nil)
+
+	diags := unmanaged.Remove(assignedRoles, updateService.UpdateUserRoles, updateService.UpdateGroupRoles)
+
+	return append(warnings, diags...)
+}
+
+// PreviewProjectRoleAssignments computes the roles the planned assignments grant, for the plan to show them.
+// The state is nil when the project is to be created.
+func PreviewProjectRoleAssignments(ctx context.Context, receiver ProjectRoleResource, plan ProjectRoleInterface, state ProjectRoleInterface) (*jira.AssignmentResult, diag.Diagnostics) {
+	// assignments with unknown values cannot be previewed, they are resolved on apply
+	plannedAssignment, diags := plan.getAssignment(ctx)
+	if diags.HasError() {
+		return nil, nil
+	}
+
+	plannedAssignmentOrder, diags := plannedAssignment.CreateAssignmentOrder(ctx, plan.getMergeStrategy())
+	if diags != nil {
+		return nil, diags
+	}
+
+	var inStateAssignmentOrder *jira.AssignmentOrder
+	if state != nil {
+		inStateAssignments, diags := state.getAssignment(ctx)
+		if diags != nil {
+			return nil, diags
+		}
+
+		inStateAssignmentOrder, diags = inStateAssignments.CreateAssignmentOrder(ctx, state.getMergeStrategy())
+		if diags != nil {
+			return nil, diags
+		}
+
+		for _, assignmentOrder := range []*jira.AssignmentOrder{inStateAssignmentOrder, plannedAssignmentOrder} {
+			diags = assignmentOrder.UseKnownIds(ctx, state.getAssignmentResult())
+			if diags != nil {
+				return nil, diags
+			}
+		}
+	}
+
+	jira.RegisterActors(receiver.getActorLookup(), *plannedAssignmentOrder)
+
+	principals := resolveProjectPrincipals(ctx, receiver, plan, *plannedAssignmentOrder)
+	if principals.HasError() {
+		return nil, principals
+	}
+
+	if inStateAssignmentOrder != nil {
+		principals = append(principals, projectAdminRemoval(receiver, inStateAssignmentOrder, plannedAssignmentOrder)...)
+	}
+
+	preview, diags := jira.PreviewAssignment(ctx, receiver.getActorLookup(), *plannedAssignmentOrder)
+	return preview, append(principals, diags...)
+}
+
+// projectAdminRemoval warns when the planned assignments take Administrators away from the provider account. Every
+// role of the in state assignments is removed when there are no planned assignments.
+func projectAdminRemoval(receiver ProjectRoleResource, inStateAssignmentOrder *jira.AssignmentOrder, plannedAssignmentOrder *jira.AssignmentOrder) diag.Diagnostics {
+	inStateUsers, _ := inStateAssignmentOrder.ResolveUsers(receiver.getActorLookup())
+
+	var plannedRoles map[string][]string
+	if plannedAssignmentOrder != nil {
+		plannedUsers, _ := plannedAssignmentOrder.ResolveUsers(receiver.getActorLookup())
+		plannedRoles = jira.RolesById(plannedUsers)
+	}
+
+	return selfAdminRemoval(receiver.getProviderAccountId(), "Administrators", jira.RolesById(inStateUsers), plannedRoles)
 }
 
 func ComputeProjectRoleAssignments(ctx context.Context, receiver ProjectRoleResource, state ProjectRoleInterface) (*jira.AssignmentResult, diag.Diagnostics) {
@@ -63,32 +241,68 @@ func ComputeProjectRoleAssignments(ctx context.Context, receiver ProjectRoleReso
 		return nil, diags
 	}
 
-	assignmentOrder, diags := assignments.CreateAssignmentOrder(ctx)
+	assignmentOrder, diags := assignments.CreateAssignmentOrder(ctx, state.getMergeStrategy())
+	if diags != nil {
+		return nil, diags
+	}
+
+	diags = assignmentOrder.UseKnownIds(ctx, state.getAssignmentResult())
+	if diags != nil {
+		return nil, diags
+	}
+
+	diags = keepProjectLeftovers(ctx, state, assignmentOrder)
+	if diags != nil {
+		return nil, diags
+	}
+
+	authoritative, allowList, diags := state.getAuthoritative(ctx)
 	if diags != nil {
 		return nil, diags
 	}
 
 	projectIdOrKey := state.getProjectIdOrKey(ctx)
 
-	updateService := cloud.NewProjectRoleManager(
+	updateService := jira.NewProjectRoleManager(
 		receiver.getClient(),
+		receiver.getActorLookup(),
 		projectIdOrKey,
 	)
-	updateService.ReadOnly = true
 
 	assignedRoles, err := updateService.ReadRoles(assignmentOrder.Roles)
 	if err != nil {
 		return nil, []diag.Diagnostic{diag.NewErrorDiagnostic("Failed to read project roles", err.Error())}
 	}
 
-	return jira.ComputeJiraAssignment(ctx, assignedRoles, *assignmentOrder)
+	if !authoritative {
+		return jira.ComputeJiraAssignment(ctx, receiver.getActorLookup(), assignedRoles, *assignmentOrder)
+	}
+
+	// report the actors an apply would remove as drift
+	unmanaged := jira.FindUnmanagedActors(receiver.getActorLookup(), assignedRoles, *assignmentOrder, allowList)
+	computation, diags := jira.ComputeAuthoritativeAssignment(ctx, receiver.getActorLookup(), assignedRoles, *assignmentOrder, unmanaged)
+	if diags != nil {
+		return nil, diags
+	}
+
+	return computation, unmanaged.Drift(projectIdOrKey)
+}
+
+// keepProjectLeftovers adds the actors whose removal failed on the last apply to the in state assignments, for them to
+// be removed again. An authoritative state has them among the unmanaged actors instead.
+func keepProjectLeftovers(ctx context.Context, state ProjectRoleInterface, assignmentOrder *jira.AssignmentOrder) diag.Diagnostics {
+	authoritative, _, diags := state.getAuthoritative(ctx)
+	if diags != nil || authoritative {
+		return diags
+	}
+
+	return assignmentOrder.KeepLeftovers(ctx, state.getAssignmentResult())
 }
 
 func UpdateProjectRoleAssignments(ctx context.Context, receiver ProjectRoleResource,
 	plan ProjectRoleInterface,
 	state ProjectRoleInterface,
 	forceUpdate bool) (*jira.AssignmentResult, diag.Diagnostics) {
-
 	plannedAssignments, diags := plan.getAssignment(ctx)
 	if diags != nil {
 		return nil, diags
@@ -99,12 +313,30 @@ func UpdateProjectRoleAssignments(ctx context.Context, receiver ProjectRoleResou
 		return nil, diags
 	}
 
-	plannedAssignmentOrder, diags := plannedAssignments.CreateAssignmentOrder(ctx)
+	plannedAssignmentOrder, diags := plannedAssignments.CreateAssignmentOrder(ctx, plan.getMergeStrategy())
 	if diags != nil {
 		return nil, diags
 	}
 
-	inStateAssignmentOrder, diags := inStateAssignments.CreateAssignmentOrder(ctx)
+	inStateAssignmentOrder, diags := inStateAssignments.CreateAssignmentOrder(ctx, state.getMergeStrategy())
+	if diags != nil {
+		return nil, diags
+	}
+
+	// the actors of the last apply are looked up by the IDs they resolved to, when they were renamed since
+	for _, assignmentOrder := range []*jira.AssignmentOrder{inStateAssignmentOrder, plannedAssignmentOrder} {
+		diags = assignmentOrder.UseKnownIds(ctx, state.getAssignmentResult())
+		if diags != nil {
+			return nil, diags
+		}
+	}
+
+	diags = keepProjectLeftovers(ctx, state, inStateAssignmentOrder)
+	if diags != nil {
+		return nil, diags
+	}
+
+	authoritative, allowList, diags := plan.getAuthoritative(ctx)
 	if diags != nil {
 		return nil, diags
 	}
@@ -112,33 +344,56 @@ func UpdateProjectRoleAssignments(ctx context.Context, receiver ProjectRoleResou
 	// the plan does not have computed value deployment ID
 	projectIdOrKey := state.getProjectIdOrKey(ctx)
 
-	updateService := cloud.NewProjectRoleManager(
+	updateService := jira.NewProjectRoleManager(
 		receiver.getClient(),
+		receiver.getActorLookup(),
 		projectIdOrKey,
 	)
+	updateService.Parallelism = assignmentParallelism(receiver.getProviderConfig())
 
 	// Read both in state and planned roles to fill in the update service with prepared data
-	_, _ = updateService.ReadRoles(append(inStateAssignmentOrder.Roles, plannedAssignmentOrder.Roles...))
-	// Register all usernames and groupNames in play to prepare the data
-	receiver.getClient().ActorLookupService().RegisterUsernames(
-		collections.Unique(append(inStateAssignmentOrder.UserNames, plannedAssignmentOrder.UserNames...))...,
-	)
-	receiver.getClient().ActorLookupService().RegisterGroupNames(
-		collections.Unique(append(inStateAssignmentOrder.GroupNames, plannedAssignmentOrder.GroupNames...))...,
-	)
-	defer updateService.Finalized()
+	assignedRoles, err := updateService.ReadRoles(append(inStateAssignmentOrder.Roles, plannedAssignmentOrder.Roles...))
+	if err != nil {
+		return nil, []diag.Diagnostic{diag.NewErrorDiagnostic("Failed to read project roles", err.Error())}
+	}
+	// Register all users and groups in play to prepare the data
+	jira.RegisterActors(receiver.getActorLookup(), *inStateAssignmentOrder, *plannedAssignmentOrder)
+	// the roles read are the ones a failed apply is rolled back to
+	snapshot := updateService.Snapshot()
+
+	principals := resolveProjectPrincipals(ctx, receiver, plan, *plannedAssignmentOrder)
+	if principals.HasError() {
+		return nil, principals
+	}
+
+	warnings := append(principals, projectAdminRemoval(receiver, inStateAssignmentOrder, plannedAssignmentOrder)...)
 
-	return jira.UpdateAssignment(ctx, receiver.getClient().ActorLookupService(),
+	// an authoritative resource also reverts the roles of configured actors that were changed outside Terraform
+	computation, diags := jira.UpdateAssignment(ctx, receiver.getActorLookup(),
 		*inStateAssignmentOrder,
 		*plannedAssignmentOrder,
-		forceUpdate,
-		func(user string, requestedRoles []string) error {
-			return updateService.UpdateUserRoles(user, requestedRoles)
-		},
-		func(group string, requestedRoles []string) error {
-			return updateService.UpdateGroupRoles(group, requestedRoles)
-		},
+		forceUpdate || authoritative,
+		assignmentParallelism(receiver.getProviderConfig()),
+		updateService.UpdateUserRoles,
+		updateService.UpdateGroupRoles,
 	)
+	if diags != nil {
+		return failedProjectRoleAssignments(ctx, receiver, plan, updateService, snapshot, *plannedAssignmentOrder, inStateAssignmentOrder, append(warnings, diags...))
+	}
+
+	if authoritative {
+		diags = removeUnmanagedActors(receiver, updateService, assignedRoles, inStateAssignmentOrder, *plannedAssignmentOrder, allowList)
+		if diags.HasError() {
+			return failedProjectRoleAssignments(ctx, receiver, plan, updateService, snapshot, *plannedAssignmentOrder, inStateAssignmentOrder, append(warnings, diags...))
+		}
+	}
+
+	diags = append(append(warnings, diags...), updateService.Apply()...)
+	if diags.HasError() {
+		return failedProjectRoleAssignments(ctx, receiver, plan, updateService, snapshot, *plannedAssignmentOrder, inStateAssignmentOrder, diags)
+	}
+
+	return computation, diags
 }
 
 func DeleteProjectRoleAssignments(ctx context.Context, receiver ProjectRoleResource, state ProjectRoleInterface) diag.Diagnostics {
@@ -147,34 +402,111 @@ func DeleteProjectRoleAssignments(ctx context.Context, receiver ProjectRoleResou
 		return diags
 	}
 
-	inStateAssignmentOrder, diags := assignments.CreateAssignmentOrder(ctx)
+	inStateAssignmentOrder, diags := assignments.CreateAssignmentOrder(ctx, state.getMergeStrategy())
+	if diags != nil {
+		return diags
+	}
+
+	diags = inStateAssignmentOrder.UseKnownIds(ctx, state.getAssignmentResult())
+	if diags != nil {
+		return diags
+	}
+
+	diags = keepProjectLeftovers(ctx, state, inStateAssignmentOrder)
 	if diags != nil {
 		return diags
 	}
 
 	projectIdOrKey := state.getProjectIdOrKey(ctx)
 
-	updateService := cloud.NewProjectRoleManager(
+	updateService := jira.NewProjectRoleManager(
 		receiver.getClient(),
+		receiver.getActorLookup(),
 		projectIdOrKey,
 	)
+	updateService.Parallelism = assignmentParallelism(receiver.getProviderConfig())
 
 	// Read both in state and planned roles to fill in the update service with prepared data
-	assignedRoles, _ := updateService.ReadRoles(inStateAssignmentOrder.Roles)
-	// Register all usernames and groupNames in play to prepare the data
-	receiver.getClient().ActorLookupService().RegisterUsernames(
-		inStateAssignmentOrder.UserNames...,
+	assignedRoles, err := updateService.ReadRoles(inStateAssignmentOrder.Roles)
+	if err != nil {
+		return []diag.Diagnostic{diag.NewErrorDiagnostic("Failed to read project roles", err.Error())}
+	}
+	// Register all users and groups in play to prepare the data
+	jira.RegisterActors(receiver.getActorLookup(), *inStateAssignmentOrder)
+	warnings := projectAdminRemoval(receiver, inStateAssignmentOrder, nil)
+
+	diags = jira.RemoveAssignment(ctx, receiver.getActorLookup(), assignedRoles, inStateAssignmentOrder,
+		assignmentParallelism(receiver.getProviderConfig()),
+		updateService.UpdateUserRoles,
+		updateService.UpdateGroupRoles,
 	)
-	receiver.getClient().ActorLookupService().RegisterGroupNames(
-		inStateAssignmentOrder.GroupNames...,
+	if diags != nil {
+		return append(warnings, diags...)
+	}
+
+	return append(warnings, updateService.Apply()...)
+}
+
+// resolveProjectPrincipals reports the users and groups of the assignments that do not exist, as configured by on_unknown_principal.
+func resolveProjectPrincipals(ctx context.Context, receiver ProjectRoleResource, plan ProjectRoleInterface, assignmentOrder jira.AssignmentOrder) diag.Diagnostics {
+	lookupService := receiver.getActorLookup()
+	unresolved := append(
+		unresolvedPrincipals("user", assignmentOrder.UserReferences(), assignmentOrder.UserPriorities, func(name string) bool {
+			return assignmentOrder.FindUser(lookupService, name) != nil
+		}),
+		unresolvedPrincipals("group", assignmentOrder.GroupReferences(), assignmentOrder.GroupPriorities, func(name string) bool {
+			return assignmentOrder.FindGroup(lookupService, name) != nil
+		})...,
 	)
-	defer updateService.Finalized()
-
-	return jira.RemoveAssignment(ctx, assignedRoles, inStateAssignmentOrder,
-		func(user string, requestedRoles []string) error {
-			return updateService.UpdateUserRoles(user, requestedRoles)
-		},
-		func(group string, requestedRoles []string) error {
-			return updateService.UpdateGroupRoles(group, requestedRoles)
-		})
+
+	return unknownPrincipals(unknownPrincipalMode(receiver.getProviderConfig(), plan.getOnUnknownPrincipal()),
+		"atlassian_jira_project "+plan.getProjectIdOrKey(ctx), unresolved)
+}
+
+// ValidateProjectRoles checks the roles of the planned assignments against the roles of the site, so that a
+// misspelled role fails the plan instead of the apply. The roles of the existing project are used when
+// existingProjectIdOrKey is set, a project that is still to be created gets the global roles.
+func ValidateProjectRoles(ctx context.Context, receiver ProjectRoleResource, plan ProjectRoleInterface, existingProjectIdOrKey string) diag.Diagnostics {
+	// assignments with unknown values are checked on apply
+	assignments, diags := plan.getAssignment(ctx)
+	if diags.HasError() {
+		return nil
+	}
+
+	var available []string
+	if existingProjectIdOrKey != "" {
+		projectRoles, err := receiver.getClient().ProjectRoleService().ReadProjectRoles(existingProjectIdOrKey)
+		if err != nil {
+			return []diag.Diagnostic{diag.NewErrorDiagnostic("Failed to read project roles", err.Error())}
+		}
+
+		for _, role := range projectRoles {
+			available = append(available, role.Name)
+		}
+	} else {
+		roles, err := receiver.getClient().ProjectRoleService().ReadAllRole()
+		if err != nil {
+			return []diag.Diagnostic{diag.NewErrorDiagnostic("Failed to read project roles", err.Error())}
+		}
+
+		for _, role := range roles {
+			available = append(available, role.Name)
+		}
+	}
+	collections.SortStrings(available)
+
+	for i, assignment := range assignments {
+		for _, role := range assignment.Roles {
+			if collections.Contains(available, role) {
+				continue
+			}
+
+			diags.AddAttributeError(path.Root("assignments").AtListIndex(i).AtName("roles"), "Unknown project role",
+				fmt.Sprintf("Role %q in the assignment with priority %d does not exist in %s, the available roles are: %s",
+					role, assignment.Priority, plan.getProjectIdOrKey(ctx), strings.Join(available, ", ")),
+			)
+		}
+	}
+
+	return diags
 }
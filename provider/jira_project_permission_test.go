@@ -0,0 +1,867 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-api-transport/transport"
+	jiraApi "github.com/yunarta/terraform-atlassian-api-client/jira"
+	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/jira"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/test"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func projectModel(t *testing.T, key string, assignments ...jira.Assignment) ProjectModel {
+	list, diags := types.ListValueFrom(context.Background(), jira.AssignmentSchema().NestedObject.Type(), assignments)
+	assert.False(t, diags.HasError())
+
+	return ProjectModel{Key: types.StringValue(key), Assignments: list}
+}
+
+// projectResource returns a resource configured like the provider does, with an actor lookup cache of its own
+func projectResource(fake transport.PayloadTransport, config *AtlassianCloudProviderConfig) *ProjectResource {
+	client := cloud.NewJiraClient(fake)
+
+	receiver := &ProjectResource{}
+	receiver.SetConfig(config, client, lookup.NewActorLookupService(client.ActorService()))
+	return receiver
+}
+
+func TestProjectRoleAssignments(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	developer := fake.AddUser("developer@example.com", "Developer")
+	developers := fake.AddGroup("jira-developers")
+
+	receiver := projectResource(fake, nil)
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	created := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"developer@example.com"}, Roles: []string{"Administrators"}, Priority: 1},
+		jira.Assignment{Groups: []string{"jira-developers"}, Roles: []string{"Developer"}, Priority: 2},
+	)
+	result, diags := CreateProjectRoleAssignments(ctx, receiver, created)
+	assert.False(t, diags.HasError())
+	assert.Len(t, result.ComputedGroups.Elements(), 1)
+
+	accountIds, _ := fake.RoleActors("TEST", "Administrators")
+	_, groupIds := fake.RoleActors("TEST", "Developer")
+	assert.Equal(t, []string{developer.AccountID}, accountIds)
+	assert.Equal(t, []string{developers.GroupId}, groupIds)
+
+	computed, diags := ComputeProjectRoleAssignments(ctx, receiver, created)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, result.ComputedUsers, computed.ComputedUsers)
+	assert.Equal(t, result.ComputedGroups, computed.ComputedGroups)
+
+	updated := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"developer@example.com"}, Roles: []string{"Developer"}, Priority: 1},
+	)
+	_, diags = UpdateProjectRoleAssignments(ctx, receiver, updated, created, false)
+	assert.False(t, diags.HasError())
+
+	accountIds, _ = fake.RoleActors("TEST", "Administrators")
+	assert.Empty(t, accountIds)
+	accountIds, groupIds = fake.RoleActors("TEST", "Developer")
+	assert.Equal(t, []string{developer.AccountID}, accountIds)
+	assert.Empty(t, groupIds)
+
+	diags = DeleteProjectRoleAssignments(ctx, receiver, updated)
+	assert.False(t, diags.HasError())
+
+	accountIds, _ = fake.RoleActors("TEST", "Developer")
+	assert.Empty(t, accountIds)
+}
+
+func TestCreateProjectRoleAssignments_Groups(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	administrators := fake.AddGroup("jira-administrators")
+	developers := fake.AddGroup("jira-developers")
+
+	receiver := projectResource(fake, nil)
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	// groups are granted their roles as groups, not looked up as users
+	model := projectModel(t, "TEST",
+		jira.Assignment{Groups: []string{"jira-administrators"}, Roles: []string{"Administrators"}, Priority: 1},
+		jira.Assignment{Groups: []string{"jira-developers"}, Roles: []string{"Developer"}, Priority: 2},
+	)
+	result, diags := CreateProjectRoleAssignments(ctx, receiver, model)
+	assert.Empty(t, diags)
+	assert.Len(t, result.ComputedUsers.Elements(), 0)
+
+	var groups []jira.ComputedAssignment
+	assert.False(t, result.ComputedGroups.ElementsAs(ctx, &groups, false).HasError())
+	assert.Equal(t, []jira.ComputedAssignment{
+		{Name: "jira-administrators", Id: administrators.GroupId, Roles: []string{"Administrators"}},
+		{Name: "jira-developers", Id: developers.GroupId, Roles: []string{"Developer"}},
+	}, groups)
+
+	accountIds, groupIds := fake.RoleActors("TEST", "Administrators")
+	assert.Empty(t, accountIds)
+	assert.Equal(t, []string{administrators.GroupId}, groupIds)
+	accountIds, groupIds = fake.RoleActors("TEST", "Developer")
+	assert.Empty(t, accountIds)
+	assert.Equal(t, []string{developers.GroupId}, groupIds)
+}
+
+func TestProjectRoleAssignments_Authoritative(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	developer := fake.AddUser("developer@example.com", "Developer")
+	stranger := fake.AddUser("stranger@example.com", "Stranger")
+	administrators := fake.AddGroup("jira-administrators")
+
+	receiver := projectResource(fake, nil)
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	model := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"developer@example.com"}, Roles: []string{"Administrators", "Developer"}, Priority: 1},
+	)
+	model.Authoritative = types.BoolValue(true)
+	model.AuthoritativeAllowList, _ = types.ListValueFrom(ctx, types.StringType, []string{"jira-administrators"})
+
+	_, diags := CreateProjectRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+
+	// changes made outside Terraform
+	assert.Nil(t, fake.AddRoleActors("TEST", "Administrators", []string{stranger.AccountID}, []string{administrators.GroupId}))
+	assert.Nil(t, fake.AddRoleActors("TEST", "Member", []string{stranger.AccountID}, nil))
+
+	computed, diags := ComputeProjectRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, 1, diags.WarningsCount())
+	assert.Len(t, computed.ComputedUsers.Elements(), 2)
+	assert.Len(t, computed.ComputedGroups.Elements(), 0)
+
+	_, diags = UpdateProjectRoleAssignments(ctx, receiver, model, model, false)
+	assert.False(t, diags.HasError())
+
+	accountIds, groupIds := fake.RoleActors("TEST", "Administrators")
+	assert.Equal(t, []string{developer.AccountID}, accountIds)
+	assert.Equal(t, []string{administrators.GroupId}, groupIds)
+	accountIds, _ = fake.RoleActors("TEST", "Member")
+	assert.Equal(t, []string{stranger.AccountID}, accountIds)
+
+	_, diags = ComputeProjectRoleAssignments(ctx, receiver, model)
+	assert.Empty(t, diags)
+}
+
+func TestProjectRoleAssignments_Drift(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	developer := fake.AddUser("developer@example.com", "Developer")
+
+	receiver := projectResource(fake, nil)
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	model := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"developer@example.com"}, Roles: []string{"Developer"}, Priority: 1},
+	)
+	created, diags := CreateProjectRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+
+	// removed outside Terraform
+	assert.Nil(t, receiver.client.ProjectRoleService().RemoveProjectRole("TEST", "10008", []string{developer.AccountID}, nil))
+
+	computed, diags := ComputeProjectRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+	assert.False(t, computed.ComputedUsers.Equal(created.ComputedUsers))
+
+	var users []jira.ComputedAssignment
+	assert.False(t, computed.ComputedUsers.ElementsAs(ctx, &users, false).HasError())
+	assert.Equal(t, []jira.ComputedAssignment{{Name: "developer@example.com", Id: developer.AccountID, Roles: []string{}}}, users)
+
+	_, diags = UpdateProjectRoleAssignments(ctx, receiver, model, model, true)
+	assert.False(t, diags.HasError())
+
+	accountIds, _ := fake.RoleActors("TEST", "Developer")
+	assert.Equal(t, []string{developer.AccountID}, accountIds)
+}
+
+func TestPreviewProjectRoleAssignments(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	developer := fake.AddUser("developer@example.com", "Developer")
+	fake.AddGroup("jira-developers")
+
+	receiver := projectResource(fake, nil)
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	model := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"developer@example.com", "unknown@example.com"}, Roles: []string{"Member", "Developer"}, Priority: 1},
+		jira.Assignment{Groups: []string{"jira-developers"}, Roles: []string{"Viewer"}, Priority: 2},
+	)
+	preview, diags := PreviewProjectRoleAssignments(ctx, receiver, model, nil)
+	assert.False(t, diags.HasError())
+
+	var users []jira.ComputedAssignment
+	assert.False(t, preview.ComputedUsers.ElementsAs(ctx, &users, false).HasError())
+	assert.Equal(t, []jira.ComputedAssignment{{Name: "developer@example.com", Id: developer.AccountID, Roles: []string{"Developer", "Member"}}}, users)
+
+	accountIds, _ := fake.RoleActors("TEST", "Developer")
+	assert.Empty(t, accountIds)
+
+	result, diags := CreateProjectRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, preview, result)
+}
+
+func TestProjectRoleAssignments_SelfAdminRemoval(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	provider := fake.AddUser("provider@example.com", "Provider")
+
+	receiver := projectResource(fake, &AtlassianCloudProviderConfig{jiraAccountId: provider.AccountID})
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	created := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"provider@example.com"}, Roles: []string{"Administrators"}, Priority: 1},
+	)
+	result, diags := CreateProjectRoleAssignments(ctx, receiver, created)
+	assert.False(t, diags.HasError())
+	created.ComputedUsers, created.ComputedGroups = result.ComputedUsers, result.ComputedGroups
+
+	_, diags = PreviewProjectRoleAssignments(ctx, receiver, created, created)
+	assert.Empty(t, diags)
+
+	// the plan warns before the role is taken away
+	updated := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"provider@example.com"}, Roles: []string{"Developer"}, Priority: 1},
+	)
+	_, diags = PreviewProjectRoleAssignments(ctx, receiver, updated, created)
+	assert.False(t, diags.HasError())
+	if assert.Equal(t, 1, diags.WarningsCount()) {
+		assert.Equal(t, "Provider account removes its own admin access", diags[0].Summary())
+	}
+
+	diags = DeleteProjectRoleAssignments(ctx, receiver, created)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, 1, diags.WarningsCount())
+
+	accountIds, _ := fake.RoleActors("TEST", "Administrators")
+	assert.Empty(t, accountIds)
+}
+
+func TestProjectRoleAssignments_ActorIds(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	developer := fake.AddUser("developer@example.com", "Developer")
+	tester := fake.AddUser("tester@example.com", "Tester")
+	developers := fake.AddGroup("jira-developers")
+	testers := fake.AddGroup("jira-testers")
+
+	receiver := projectResource(fake, nil)
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	model := projectModel(t, "TEST",
+		jira.Assignment{AccountIds: []string{developer.AccountID}, GroupIds: []string{developers.GroupId}, Roles: []string{"Developer"}, Priority: 1},
+		jira.Assignment{Users: []string{"tester@example.com"}, Groups: []string{"jira-testers"}, Roles: []string{"Viewer"}, Priority: 2},
+	)
+	result, diags := CreateProjectRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+
+	accountIds, groupIds := fake.RoleActors("TEST", "Developer")
+	assert.Equal(t, []string{developer.AccountID}, accountIds)
+	assert.Equal(t, []string{developers.GroupId}, groupIds)
+
+	var users []jira.ComputedAssignment
+	assert.False(t, result.ComputedUsers.ElementsAs(ctx, &users, false).HasError())
+	assert.ElementsMatch(t, []jira.ComputedAssignment{
+		{Name: developer.AccountID, Id: developer.AccountID, Roles: []string{"Developer"}},
+		{Name: "tester@example.com", Id: tester.AccountID, Roles: []string{"Viewer"}},
+	}, users)
+
+	// renamed outside Terraform, the next run finds them by the IDs kept in the state
+	assert.Nil(t, fake.ChangeEmail(tester.AccountID, "qa@example.com"))
+	assert.Nil(t, fake.RenameGroup(testers.GroupId, "jira-qa"))
+	receiver = projectResource(fake, nil)
+	model.ComputedUsers, model.ComputedGroups = result.ComputedUsers, result.ComputedGroups
+
+	computed, diags := ComputeProjectRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, result.ComputedUsers, computed.ComputedUsers)
+	assert.Equal(t, result.ComputedGroups, computed.ComputedGroups)
+
+	requests := len(fake.Requests())
+	_, diags = UpdateProjectRoleAssignments(ctx, receiver, model, model, false)
+	assert.False(t, diags.HasError())
+	for _, request := range fake.Requests()[requests:] {
+		assert.NotContains(t, request, "DELETE")
+	}
+
+	accountIds, groupIds = fake.RoleActors("TEST", "Viewer")
+	assert.Equal(t, []string{tester.AccountID}, accountIds)
+	assert.Equal(t, []string{testers.GroupId}, groupIds)
+
+	// the same user by email and by account ID
+	named := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"developer@example.com"}, Roles: []string{"Developer"}, Priority: 1},
+		jira.Assignment{AccountIds: []string{developer.AccountID}, Roles: []string{"Viewer"}, Priority: 2},
+	)
+	_, diags = CreateProjectRoleAssignments(ctx, receiver, named)
+	assert.True(t, diags.HasError())
+	assert.Equal(t, "User named twice in assignments", diags.Errors()[0].Summary())
+
+	// users hiding their email under the same display name each get their own roles
+	first := fake.AddUser("", "Contractor")
+	second := fake.AddUser("", "Contractor")
+	receiver = projectResource(fake, nil)
+	contractors := projectModel(t, "TEST",
+		jira.Assignment{AccountIds: []string{first.AccountID}, Roles: []string{"Member"}, Priority: 1},
+		jira.Assignment{AccountIds: []string{second.AccountID}, Roles: []string{"Administrators"}, Priority: 2},
+	)
+	_, diags = CreateProjectRoleAssignments(ctx, receiver, contractors)
+	assert.False(t, diags.HasError())
+
+	accountIds, _ = fake.RoleActors("TEST", "Member")
+	assert.Equal(t, []string{first.AccountID}, accountIds)
+	accountIds, _ = fake.RoleActors("TEST", "Administrators")
+	assert.Equal(t, []string{second.AccountID}, accountIds)
+}
+
+// countRequests counts the requests made since the first skipped ones, whose "METHOD url" starts with prefix.
+func countRequests(requests []string, skip int, prefix string) int {
+	var count int
+	for _, request := range requests[skip:] {
+		if strings.HasPrefix(request, prefix) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestProjectRoleAssignments_Batched(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	var users, accountIds []string
+	for i := 0; i < 50; i++ {
+		user := fake.AddUser(fmt.Sprintf("member%d@example.com", i), fmt.Sprintf("Member %d", i))
+		users = append(users, user.EmailAddress)
+		accountIds = append(accountIds, user.AccountID)
+	}
+	fake.AddGroup("jira-developers")
+	fake.AddGroup("jira-testers")
+
+	receiver := projectResource(fake, nil)
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	created := projectModel(t, "TEST",
+		jira.Assignment{Users: users, Groups: []string{"jira-developers", "jira-testers"}, Roles: []string{"Developer", "Member"}, Priority: 1},
+	)
+	requests := len(fake.Requests())
+	_, diags := CreateProjectRoleAssignments(ctx, receiver, created)
+	assert.False(t, diags.HasError())
+
+	// one request per role, not per actor and role
+	assert.Equal(t, 2, countRequests(fake.Requests(), requests, "POST /rest/api/latest/project/TEST/role/"))
+	members, groupIds := fake.RoleActors("TEST", "Member")
+	assert.Len(t, members, 50)
+	assert.Len(t, groupIds, 2)
+
+	updated := projectModel(t, "TEST",
+		jira.Assignment{Users: users, Groups: []string{"jira-developers", "jira-testers"}, Roles: []string{"Viewer"}, Priority: 1},
+	)
+	receiver = projectResource(fake, nil)
+	requests = len(fake.Requests())
+	_, diags = UpdateProjectRoleAssignments(ctx, receiver, updated, created, false)
+	assert.False(t, diags.HasError())
+
+	// actors are added with one request per role, but removed one at a time
+	assert.Equal(t, 1, countRequests(fake.Requests(), requests, "POST /rest/api/latest/project/TEST/role/"))
+	assert.Equal(t, 2*52, countRequests(fake.Requests(), requests, "DELETE /rest/api/latest/project/TEST/role/"))
+	// the actors holding the roles are looked up together
+	assert.Equal(t, 1, countRequests(fake.Requests(), requests, "GET /rest/api/latest/user/bulk"))
+	viewers, _ := fake.RoleActors("TEST", "Viewer")
+	assert.Len(t, viewers, 50)
+
+	// a failed removal names the actor, the actors removed before it stay removed
+	fake.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.Method == http.MethodDelete && request.URL.Query().Get("user") == accountIds[1] {
+				writer.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	})
+	removed := projectModel(t, "TEST",
+		jira.Assignment{Users: users[2:], Groups: []string{"jira-developers", "jira-testers"}, Roles: []string{"Viewer"}, Priority: 1},
+	)
+	receiver = projectResource(fake, nil)
+	_, diags = UpdateProjectRoleAssignments(ctx, receiver, removed, updated, false)
+	assert.True(t, diags.HasError())
+	assert.Contains(t, diags.Errors()[0].Detail(), accountIds[1])
+	viewers, _ = fake.RoleActors("TEST", "Viewer")
+	assert.NotContains(t, viewers, accountIds[0])
+	assert.Contains(t, viewers, accountIds[1])
+
+	// a failed batch is reported
+	fake.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.Method == http.MethodPost {
+				writer.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	})
+	_, diags = UpdateProjectRoleAssignments(ctx, receiver, created, updated, false)
+	assert.True(t, diags.HasError())
+	assert.Equal(t, "Failed to update project role", diags.Errors()[0].Summary())
+}
+
+func TestProjectRoleAssignments_Parallel(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	developer := fake.AddUser("developer@example.com", "Developer")
+	fake.AddGroup("jira-developers")
+
+	receiver := projectResource(fake, &AtlassianCloudProviderConfig{AssignmentParallelism: types.Int64Value(4)})
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	roles, err := receiver.client.ProjectRoleService().ReadProjectRoles("TEST")
+	assert.Nil(t, err)
+	var developerRoleId string
+	for _, role := range roles {
+		if role.Name == "Developer" {
+			developerRoleId = role.ID
+		}
+	}
+
+	// the Developer role fails, the other roles are still changed
+	fake.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if request.Method == http.MethodPost && strings.HasSuffix(request.URL.Path, "/role/"+developerRoleId) {
+				writer.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	})
+
+	model := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"developer@example.com"}, Groups: []string{"jira-developers"}, Roles: []string{"Administrators", "Developer", "Member", "Viewer"}, Priority: 1},
+	)
+	result, diags := CreateProjectRoleAssignments(ctx, receiver, model)
+	if assert.Len(t, diags.Errors(), 1) {
+		assert.Contains(t, diags.Errors()[0].Detail(), "role Developer")
+	}
+
+	for _, role := range []string{"Administrators", "Member", "Viewer"} {
+		accountIds, groupIds := fake.RoleActors("TEST", role)
+		assert.Contains(t, accountIds, developer.AccountID, role)
+		assert.Len(t, groupIds, 1, role)
+	}
+	accountIds, _ := fake.RoleActors("TEST", "Developer")
+	assert.NotContains(t, accountIds, developer.AccountID)
+
+	// the roles changed before the failure are recorded
+	var computedUsers []jira.ComputedAssignment
+	assert.False(t, result.ComputedUsers.ElementsAs(ctx, &computedUsers, false).HasError())
+	if assert.Len(t, computedUsers, 1) {
+		assert.Equal(t, []string{"Administrators", "Member", "Viewer"}, computedUsers[0].Roles)
+	}
+}
+
+func TestProjectRoleAssignments_PartialFailure(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	developer := fake.AddUser("developer@example.com", "Developer")
+	tester := fake.AddUser("tester@example.com", "Tester")
+
+	receiver := projectResource(fake, nil)
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	roles, err := receiver.client.ProjectRoleService().ReadProjectRoles("TEST")
+	assert.Nil(t, err)
+	var memberRoleId string
+	for _, role := range roles {
+		if role.Name == "Member" {
+			memberRoleId = role.ID
+		}
+	}
+
+	// the changes to the Member role fail
+	failing := true
+	fake.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if failing && request.Method != http.MethodGet && strings.HasSuffix(request.URL.Path, "/role/"+memberRoleId) {
+				writer.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	})
+
+	created := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"developer@example.com"}, Roles: []string{"Developer", "Member"}, Priority: 1},
+	)
+	result, diags := CreateProjectRoleAssignments(ctx, receiver, created)
+	assert.True(t, diags.HasError())
+
+	// the role given before the failure is recorded
+	var users []jira.ComputedAssignment
+	assert.False(t, result.ComputedUsers.ElementsAs(ctx, &users, false).HasError())
+	assert.Equal(t, []jira.ComputedAssignment{
+		{Name: "developer@example.com", Id: developer.AccountID, Roles: []string{"Developer"}},
+	}, users)
+
+	// the next apply only sends the remaining change
+	failing = false
+	created.ComputedUsers, created.ComputedGroups = result.ComputedUsers, result.ComputedGroups
+	receiver = projectResource(fake, nil)
+	requests := len(fake.Requests())
+	result, diags = UpdateProjectRoleAssignments(ctx, receiver, created, created, true)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, 1, countRequests(fake.Requests(), requests, "POST /rest/api/latest/project/TEST/role/"+memberRoleId))
+	accountIds, _ := fake.RoleActors("TEST", "Member")
+	assert.Equal(t, []string{developer.AccountID}, accountIds)
+	created.ComputedUsers, created.ComputedGroups = result.ComputedUsers, result.ComputedGroups
+
+	// a user that failed to be removed is kept in the state until it is removed
+	failing = true
+	updated := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"tester@example.com"}, Roles: []string{"Developer"}, Priority: 1},
+	)
+	result, diags = UpdateProjectRoleAssignments(ctx, receiver, updated, created, false)
+	assert.True(t, diags.HasError())
+	assert.False(t, result.ComputedUsers.ElementsAs(ctx, &users, false).HasError())
+	assert.ElementsMatch(t, []jira.ComputedAssignment{
+		{Name: "developer@example.com", Id: developer.AccountID, Roles: []string{"Member"}},
+		{Name: "tester@example.com", Id: tester.AccountID, Roles: []string{"Developer"}},
+	}, users)
+
+	updated.ComputedUsers, updated.ComputedGroups = result.ComputedUsers, result.ComputedGroups
+	computed, diags := ComputeProjectRoleAssignments(ctx, receiver, updated)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, result.ComputedUsers, computed.ComputedUsers)
+
+	failing = false
+	result, diags = UpdateProjectRoleAssignments(ctx, receiver, updated, updated, true)
+	assert.False(t, diags.HasError())
+	assert.False(t, result.ComputedUsers.ElementsAs(ctx, &users, false).HasError())
+	assert.Equal(t, []jira.ComputedAssignment{
+		{Name: "tester@example.com", Id: tester.AccountID, Roles: []string{"Developer"}},
+	}, users)
+	accountIds, _ = fake.RoleActors("TEST", "Member")
+	assert.Empty(t, accountIds)
+}
+
+func TestProjectRoleAssignments_Rollback(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	developer := fake.AddUser("developer@example.com", "Developer")
+
+	receiver := projectResource(fake, nil)
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	roles, err := receiver.client.ProjectRoleService().ReadProjectRoles("TEST")
+	assert.Nil(t, err)
+	var roleIds = map[string]string{}
+	for _, role := range roles {
+		roleIds[role.Name] = role.ID
+	}
+
+	state := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"developer@example.com"}, Roles: []string{"Viewer"}, Priority: 1},
+	)
+	result, diags := CreateProjectRoleAssignments(ctx, receiver, state)
+	assert.False(t, diags.HasError())
+	state.ComputedUsers, state.ComputedGroups = result.ComputedUsers, result.ComputedGroups
+
+	// the requests to the failing roles are refused, by method and role name
+	var failing = map[string]bool{}
+	fake.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			for role, roleId := range roleIds {
+				if failing[request.Method+" "+role] && strings.HasSuffix(request.URL.Path, "/role/"+roleId) {
+					writer.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	})
+
+	// the Developer role given before the Member role failed is taken back
+	failing["POST Member"] = true
+	plan := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"developer@example.com"}, Roles: []string{"Developer", "Member"}, Priority: 1},
+	)
+	plan.RollbackOnFailure = types.BoolValue(true)
+	result, diags = UpdateProjectRoleAssignments(ctx, receiver, plan, state, false)
+	assert.Len(t, diags.Errors(), 1)
+	assert.Equal(t, "Failed to update project role", diags.Errors()[0].Summary())
+	assert.Len(t, diags.Warnings(), 1)
+	assert.Equal(t, "Rolled back project roles", diags.Warnings()[0].Summary())
+
+	for role, expected := range map[string][]string{"Developer": nil, "Member": nil, "Viewer": {developer.AccountID}} {
+		accountIds, _ := fake.RoleActors("TEST", role)
+		assert.ElementsMatch(t, expected, accountIds, role)
+	}
+
+	// the state records the roles held again, for the next plan to show every change
+	var users []jira.ComputedAssignment
+	assert.False(t, result.ComputedUsers.ElementsAs(ctx, &users, false).HasError())
+	assert.Equal(t, []jira.ComputedAssignment{
+		{Name: "developer@example.com", Id: developer.AccountID, Roles: []string{"Viewer"}},
+	}, users)
+
+	// a rollback that fails is reported next to the original failure
+	failing["DELETE Developer"] = true
+	receiver = projectResource(fake, nil)
+	_, diags = UpdateProjectRoleAssignments(ctx, receiver, plan, state, false)
+	assert.Len(t, diags.Errors(), 2)
+	assert.Equal(t, "Failed to update project role", diags.Errors()[0].Summary())
+	assert.Equal(t, "Failed to roll back project roles", diags.Errors()[1].Summary())
+	assert.Empty(t, diags.Warnings())
+
+	accountIds, _ := fake.RoleActors("TEST", "Developer")
+	assert.Equal(t, []string{developer.AccountID}, accountIds)
+}
+
+func TestProjectRoleAssignments_UnknownPrincipal(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+	developer := fake.AddUser("developer@example.com", "Developer")
+
+	receiver := projectResource(fake, &AtlassianCloudProviderConfig{OnUnknownPrincipal: types.StringValue(unknownPrincipalError)})
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	model := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"developer@example.com", "developer@exmaple.com"}, Roles: []string{"Developer"}, Priority: 1},
+		jira.Assignment{Groups: []string{"jira-developer"}, Roles: []string{"Viewer"}, Priority: 2},
+	)
+	_, diags := CreateProjectRoleAssignments(ctx, receiver, model)
+	assert.True(t, diags.HasError())
+	assert.Contains(t, diags[0].Detail(), `user "developer@exmaple.com" in the assignment with priority 1`)
+	assert.Contains(t, diags[0].Detail(), `group "jira-developer" in the assignment with priority 2`)
+
+	accountIds, _ := fake.RoleActors("TEST", "Developer")
+	assert.Empty(t, accountIds)
+
+	// the resource setting overrides the provider
+	model.OnUnknownPrincipal = types.StringValue(unknownPrincipalWarn)
+	_, diags = CreateProjectRoleAssignments(ctx, receiver, model)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, 1, diags.WarningsCount())
+
+	accountIds, _ = fake.RoleActors("TEST", "Developer")
+	assert.Equal(t, []string{developer.AccountID}, accountIds)
+}
+
+func TestValidateProjectRoles(t *testing.T) {
+	ctx := context.Background()
+
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+
+	receiver := projectResource(fake, nil)
+
+	model := projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"lead@example.com"}, Roles: []string{"Administrators"}, Priority: 1},
+		jira.Assignment{Groups: []string{"jira-developers"}, Roles: []string{"Developer", "Developers"}, Priority: 2},
+	)
+
+	// the project is not created yet, the global roles are used
+	diags := ValidateProjectRoles(ctx, receiver, model, "")
+	assert.Equal(t, 1, diags.ErrorsCount())
+	assert.Contains(t, diags.Errors()[0].Detail(), `Role "Developers" in the assignment with priority 2 does not exist in TEST`)
+
+	_, err := receiver.client.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	diags = ValidateProjectRoles(ctx, receiver, model, "TEST")
+	assert.Equal(t, 1, diags.ErrorsCount())
+
+	diags = ValidateProjectRoles(ctx, receiver, projectModel(t, "TEST",
+		jira.Assignment{Users: []string{"lead@example.com"}, Roles: []string{"Administrators", "Viewer"}, Priority: 1},
+	), "TEST")
+	assert.False(t, diags.HasError())
+
+	diags = ValidateProjectRoles(ctx, receiver, model, "MISSING")
+	assert.True(t, diags.HasError())
+	assert.Equal(t, "Failed to read project roles", diags.Errors()[0].Summary())
+}
+
+func TestAssignmentsValidator(t *testing.T) {
+	ctx := context.Background()
+
+	validate := func(assignments ...jira.Assignment) diag.Diagnostics {
+		list, diags := types.ListValueFrom(ctx, jira.AssignmentSchema().NestedObject.Type(), assignments)
+		assert.False(t, diags.HasError())
+
+		var response validator.ListResponse
+		for _, listValidator := range jira.AssignmentSchema().Validators {
+			listValidator.ValidateList(ctx, validator.ListRequest{Path: path.Root("assignments"), ConfigValue: list}, &response)
+		}
+		return response.Diagnostics
+	}
+
+	diags := validate(
+		jira.Assignment{Users: []string{"developer@example.com"}, Roles: []string{"Developer"}, Priority: 1},
+		jira.Assignment{Groups: []string{"jira-developers"}, Roles: []string{"Viewer"}, Priority: 2},
+	)
+	assert.False(t, diags.HasError())
+
+	diags = validate(
+		jira.Assignment{Users: []string{"developer@example.com"}, Roles: []string{"Developer"}, Priority: 1},
+		jira.Assignment{Groups: []string{"jira-developers"}, Roles: []string{"Viewer"}, Priority: 1},
+	)
+	assert.Equal(t, 1, diags.ErrorsCount())
+	assert.Equal(t, "Duplicate assignment priority", diags.Errors()[0].Summary())
+
+	diags = validate(
+		jira.Assignment{Users: []string{"developer@example.com"}, Roles: []string{"Developer"}, Priority: 1},
+		jira.Assignment{Users: []string{}, Roles: []string{"Viewer"}, Priority: 2},
+	)
+	assert.Equal(t, 1, diags.ErrorsCount())
+	assert.Equal(t, "Empty assignment", diags.Errors()[0].Summary())
+
+	// actors referenced only by ID are enough
+	diags = validate(
+		jira.Assignment{AccountIds: []string{"5b10ac8d82e05b22cc7d4ef5"}, Roles: []string{"Developer"}, Priority: 1},
+		jira.Assignment{GroupIds: []string{"276f955c-63d7-42c8-9520-92d01dca0625"}, Roles: []string{"Viewer"}, Priority: 2},
+	)
+	assert.False(t, diags.HasError())
+}
+
+func TestCreateAssignmentOrder_MergeStrategy(t *testing.T) {
+	ctx := context.Background()
+
+	assignments := jira.Assignments{
+		{Users: []string{"oncall@example.com"}, Roles: []string{"Administrators"}, Priority: 2},
+		{Users: []string{"developer@example.com", "oncall@example.com"}, Groups: []string{"jira-developers"}, Roles: []string{"Developer"}, Priority: 1},
+		{Groups: []string{"jira-developers"}, Roles: []string{"Developer", "Viewer"}, Priority: 3},
+	}
+
+	order, diags := assignments.CreateAssignmentOrder(ctx, jira.MergeOverride)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, []string{"Administrators"}, order.Users["oncall@example.com"])
+	assert.Equal(t, []string{"Developer"}, order.Users["developer@example.com"])
+	assert.Equal(t, []string{"Developer", "Viewer"}, order.Groups["jira-developers"])
+
+	order, diags = assignments.CreateAssignmentOrder(ctx, jira.MergeUnion)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, []string{"Developer", "Administrators"}, order.Users["oncall@example.com"])
+	assert.Equal(t, []string{"Developer"}, order.Users["developer@example.com"])
+	assert.Equal(t, []string{"Developer", "Viewer"}, order.Groups["jira-developers"])
+	assert.Equal(t, []int64{1, 2}, order.UserPriorities["oncall@example.com"])
+}
@@ -0,0 +1,196 @@
+package lookup
+
+import (
+	"github.com/yunarta/terraform-atlassian-api-client/jira"
+	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	"github.com/yunarta/terraform-atlassian-api-client/util"
+	"sync"
+)
+
+// ActorLookupService caches the users and groups looked up, like the lookup service of the API client, but may be used
+// by many resources at once. The lock is only held while the caches are read or written, never during a request, and
+// an actor that is being fetched is waited for rather than fetched again.
+type ActorLookupService struct {
+	actorService *cloud.ActorService
+
+	mutex      sync.Mutex
+	usernames  map[string]*jira.User
+	accountIds map[string]*jira.User
+	groupNames map[string]*jira.Group
+	groupIds   map[string]*jira.Group
+	// inflight are the keys being fetched, closed once the fetch is done
+	inflight map[string]chan struct{}
+}
+
+func NewActorLookupService(actorService *cloud.ActorService) *ActorLookupService {
+	return &ActorLookupService{
+		actorService: actorService,
+		usernames:    map[string]*jira.User{},
+		accountIds:   map[string]*jira.User{},
+		groupNames:   map[string]*jira.Group{},
+		groupIds:     map[string]*jira.Group{},
+		inflight:     map[string]chan struct{}{},
+	}
+}
+
+const (
+	usernameKey  = "username:"
+	accountIdKey = "accountId:"
+	groupNameKey = "groupName:"
+	groupIdKey   = "groupId:"
+)
+
+// RegisterAccountIds looks up the users that are not cached yet with one request.
+func (service *ActorLookupService) RegisterAccountIds(accountIds ...string) {
+	service.fetch(accountIdKey, accountIds, func(accountId string) bool {
+		return service.accountIds[accountId] != nil
+	}, func(accountIds []string) {
+		users, _ := service.actorService.BulkGetUsers(accountIds)
+		service.syncUsers(users...)
+	})
+}
+
+// RegisterUsernames looks up the users that are not cached yet by email, one request per user.
+func (service *ActorLookupService) RegisterUsernames(usernames ...string) {
+	service.fetch(usernameKey, usernames, func(username string) bool {
+		return service.usernames[username] != nil
+	}, func(usernames []string) {
+		wg := new(sync.WaitGroup)
+		for _, username := range usernames {
+			wg.Add(1)
+			go func(username string) {
+				defer wg.Done()
+				if user, _ := service.actorService.ReadUser(username); user != nil {
+					service.syncUsers(*user)
+				}
+			}(username)
+		}
+		wg.Wait()
+	})
+}
+
+// FindUser returns the user with the email, or the display name of a user hiding its email, nil when there is none.
+func (service *ActorLookupService) FindUser(username string) *jira.User {
+	service.RegisterUsernames(username)
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	return service.usernames[username]
+}
+
+// FindUserById returns the user with the account ID, nil when there is none.
+func (service *ActorLookupService) FindUserById(accountId string) *jira.User {
+	service.RegisterAccountIds(accountId)
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	return service.accountIds[accountId]
+}
+
+// RegisterGroupIds looks up the groups that are not cached yet with one request.
+func (service *ActorLookupService) RegisterGroupIds(groupIds ...string) {
+	service.fetch(groupIdKey, groupIds, func(groupId string) bool {
+		return service.groupIds[groupId] != nil
+	}, func(groupIds []string) {
+		groups, _ := service.actorService.BulkGetGroupsById(groupIds)
+		service.syncGroups(groups...)
+	})
+}
+
+// RegisterGroupNames looks up the groups that are not cached yet with one request.
+func (service *ActorLookupService) RegisterGroupNames(groupNames ...string) {
+	service.fetch(groupNameKey, groupNames, func(groupName string) bool {
+		return service.groupNames[groupName] != nil
+	}, func(groupNames []string) {
+		groups, _ := service.actorService.BulkGetGroupsByName(groupNames)
+		service.syncGroups(groups...)
+	})
+}
+
+// FindGroup returns the group with the name, nil when there is none.
+func (service *ActorLookupService) FindGroup(groupName string) *jira.Group {
+	service.fetch(groupNameKey, []string{groupName}, func(groupName string) bool {
+		return service.groupNames[groupName] != nil
+	}, func(groupNames []string) {
+		if group, _ := service.actorService.ReadGroup(groupNames[0]); group != nil {
+			service.syncGroups(*group)
+		}
+	})
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	return service.groupNames[groupName]
+}
+
+// FindGroupById returns the group with the group ID, nil when there is none.
+func (service *ActorLookupService) FindGroupById(groupId string) *jira.Group {
+	service.RegisterGroupIds(groupId)
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+	return service.groupIds[groupId]
+}
+
+// fetch calls lookup with the keys that are neither cached nor being fetched, and waits for the other fetches of
+// keys to finish. cached is called with the lock held.
+func (service *ActorLookupService) fetch(kind string, keys []string, cached func(key string) bool, lookup func(keys []string)) {
+	done := make(chan struct{})
+
+	var owned []string
+	var waiting []chan struct{}
+	service.mutex.Lock()
+	for _, key := range keys {
+		if key == "" || cached(key) {
+			continue
+		}
+
+		if other, ok := service.inflight[kind+key]; ok {
+			if other != done {
+				waiting = append(waiting, other)
+			}
+			continue
+		}
+
+		service.inflight[kind+key] = done
+		owned = append(owned, key)
+	}
+	service.mutex.Unlock()
+
+	if len(owned) > 0 {
+		lookup(owned)
+
+		service.mutex.Lock()
+		for _, key := range owned {
+			delete(service.inflight, kind+key)
+		}
+		service.mutex.Unlock()
+	}
+	// closed before waiting for the others, which may be waiting for this one
+	close(done)
+
+	for _, other := range waiting {
+		<-other
+	}
+}
+
+func (service *ActorLookupService) syncUsers(users ...jira.User) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	for _, user := range users {
+		user := user
+		service.usernames[util.CoalesceString(user.EmailAddress, user.DisplayName)] = &user
+		service.accountIds[user.AccountID] = &user
+	}
+}
+
+func (service *ActorLookupService) syncGroups(groups ...jira.Group) {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	for _, group := range groups {
+		group := group
+		service.groupNames[group.Name] = &group
+		service.groupIds[group.GroupId] = &group
+	}
+}
@@ -0,0 +1,54 @@
+package lookup
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/test"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestActorLookupService_Concurrent(t *testing.T) {
+	jiraTransport := test.NewJiraTransport()
+	user := jiraTransport.AddUser("user@example.com", "User")
+	group := jiraTransport.AddGroup("developers")
+
+	service := NewActorLookupService(cloud.NewJiraClient(jiraTransport).ActorService())
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			if found := service.FindUser("user@example.com"); assert.NotNil(t, found) {
+				assert.Equal(t, user.AccountID, found.AccountID)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if found := service.FindUserById(user.AccountID); assert.NotNil(t, found) {
+				assert.Equal(t, "user@example.com", found.EmailAddress)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if found := service.FindGroupById(group.GroupId); assert.NotNil(t, found) {
+				assert.Equal(t, "developers", found.Name)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, service.FindUser("unknown@example.com"))
+		}()
+	}
+	wg.Wait()
+
+	var userSearches int
+	for _, request := range jiraTransport.Requests() {
+		if strings.Contains(request, "user@example.com") {
+			userSearches++
+		}
+	}
+	assert.LessOrEqual(t, userSearches, 1, "concurrent lookups of a user are sent once")
+}
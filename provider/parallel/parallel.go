@@ -0,0 +1,73 @@
+package parallel
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"slices"
+	"sync"
+)
+
+// ForEach calls work with every index below count on at most parallelism workers, one after the other when
+// parallelism is below 2. Every call runs even when others fail, the errors are returned in the order of the indexes.
+func ForEach(parallelism int, count int, work func(i int) error) []error {
+	errs := make([]error, count)
+	if parallelism < 2 {
+		for i := 0; i < count; i++ {
+			errs[i] = work(i)
+		}
+
+		return errs
+	}
+
+	jobs := make(chan int)
+	wg := new(sync.WaitGroup)
+	for worker := 0; worker < min(parallelism, count); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				errs[i] = work(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errs
+}
+
+// Update changes the roles or permissions of one actor, found by its ID. The name reports it in the diagnostic, of
+// which Failure is the summary.
+type Update struct {
+	Name    string
+	Id      string
+	Grants  []string
+	Apply   func(id string, grants []string) error
+	Failure string
+}
+
+// Run runs the updates with ForEach.
+//
+// The actors must have been resolved before, so that the update functions only read the actor lookup cache. Each update
+// gets its own copy of the grants, as actors of the same assignment share them.
+func Run(parallelism int, updates []Update) []error {
+	return ForEach(parallelism, len(updates), func(i int) error {
+		return updates[i].Apply(updates[i].Id, slices.Clone(updates[i].Grants))
+	})
+}
+
+// Diagnostics reports the failed updates with the name of their actor, in the order of the updates.
+func Diagnostics(updates []Update, errs []error) diag.Diagnostics {
+	var diags diag.Diagnostics
+	for i, err := range errs {
+		if err != nil {
+			diags = append(diags, diag.NewErrorDiagnostic(updates[i].Failure, fmt.Sprintf("%s: %s", updates[i].Name, err.Error())))
+		}
+	}
+
+	return diags
+}
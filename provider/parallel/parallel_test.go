@@ -0,0 +1,41 @@
+package parallel
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEach(t *testing.T) {
+	for _, parallelism := range []int{0, 1, 3} {
+		var running, highest atomic.Int32
+		errs := ForEach(parallelism, 10, func(i int) error {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				seen := highest.Load()
+				if current <= seen || highest.CompareAndSwap(seen, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+
+			if i%4 == 1 {
+				return fmt.Errorf("failed %d", i)
+			}
+			return nil
+		})
+
+		assert.LessOrEqual(t, highest.Load(), int32(max(parallelism, 1)))
+		assert.Len(t, errs, 10)
+		for i, err := range errs {
+			if i%4 == 1 {
+				assert.EqualError(t, err, fmt.Sprintf("failed %d", i))
+			} else {
+				assert.Nil(t, err)
+			}
+		}
+	}
+}
@@ -2,14 +2,21 @@ package provider
 
 import (
 	"context"
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"regexp"
 )
 
 type AtlassianCloudProvider struct {
 	Version string
+	// TransportFactory is used by tests to serve the API without an Atlassian site
+	TransportFactory TransportFactory
 }
 
 func (p *AtlassianCloudProvider) Metadata(ctx context.Context, request provider.MetadataRequest, response *provider.MetadataResponse) {
@@ -21,13 +28,110 @@ func (p *AtlassianCloudProvider) Schema(ctx context.Context, request provider.Sc
 	response.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
 			"endpoint": schema.StringAttribute{
-				Required: true,
+				Optional: true,
 			},
 			"username": schema.StringAttribute{
-				Required: true,
+				Optional: true,
 			},
 			"token": schema.StringAttribute{
-				Required:  true,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"credentials_file": schema.StringAttribute{
+				Optional: true,
+			},
+			"max_retries": schema.Int64Attribute{
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"requests_per_second": schema.Float64Attribute{
+				Optional: true,
+				Validators: []validator.Float64{
+					float64validator.AtLeast(0),
+				},
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"validate_credentials": schema.BoolAttribute{
+				Optional: true,
+			},
+			"cassette_directory": schema.StringAttribute{
+				Optional: true,
+			},
+			"read_only": schema.BoolAttribute{
+				Optional: true,
+			},
+			"assignment_parallelism": schema.Int64Attribute{
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"on_unknown_principal": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(unknownPrincipalError, unknownPrincipalWarn, unknownPrincipalIgnore),
+				},
+			},
+			"retry_max_wait": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(
+						regexp.MustCompile(`^([0-9]+(ms|s|m|h))+$`),
+						"value must be a duration such as 30s or 1m30s",
+					),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"jira":       productBlock(),
+			"confluence": productBlock(),
+			"auth": schema.SingleNestedBlock{
+				Attributes: map[string]schema.Attribute{
+					"type": schema.StringAttribute{
+						Optional: true,
+						Validators: []validator.String{
+							stringvalidator.OneOf(authBasic, authBearer, authOAuth),
+						},
+					},
+					"token": schema.StringAttribute{
+						Optional:  true,
+						Sensitive: true,
+					},
+					"client_id": schema.StringAttribute{
+						Optional: true,
+					},
+					"client_secret": schema.StringAttribute{
+						Optional:  true,
+						Sensitive: true,
+					},
+					"cloud_id": schema.StringAttribute{
+						Optional: true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// productBlock overrides the top-level endpoint and credentials for a single product.
+func productBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		Attributes: map[string]schema.Attribute{
+			"endpoint": schema.StringAttribute{
+				Optional: true,
+			},
+			"username": schema.StringAttribute{
+				Optional: true,
+			},
+			"token": schema.StringAttribute{
+				Optional:  true,
 				Sensitive: true,
 			},
 		},
@@ -43,8 +147,25 @@ func (p *AtlassianCloudProvider) Configure(ctx context.Context, request provider
 		return
 	}
 
-	response.DataSourceData = config
-	response.ResourceData = config
+	config.transportFactory = p.TransportFactory
+
+	diags = ResolveProviderConfig(config)
+	response.Diagnostics.Append(diags...)
+	if response.Diagnostics.HasError() {
+		return
+	}
+
+	data := NewAtlassianCloudProviderData(ctx, config)
+	if config.ValidateCredentials.ValueBool() {
+		diags = data.ValidateCredentials()
+		response.Diagnostics.Append(diags...)
+		if response.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	response.DataSourceData = data
+	response.ResourceData = data
 }
 
 func (p *AtlassianCloudProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
@@ -0,0 +1,328 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	atlassianTransport "github.com/yunarta/terraform-provider-atlassian-cloud/provider/transport"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	envEndpoint        = "ATLASSIAN_ENDPOINT"
+	envUsername        = "ATLASSIAN_USERNAME"
+	envToken           = "ATLASSIAN_TOKEN"
+	envCredentialsFile = "ATLASSIAN_CREDENTIALS_FILE"
+	envClientId        = "ATLASSIAN_CLIENT_ID"
+	envClientSecret    = "ATLASSIAN_CLIENT_SECRET"
+	envCloudId         = "ATLASSIAN_CLOUD_ID"
+)
+
+// credentials holds the values read from a credentials file.
+type credentials struct {
+	Endpoint string `json:"endpoint"`
+	Username string `json:"username"`
+	Token    string `json:"token"`
+}
+
+// ResolveProviderConfig fills in endpoint, username and token that are not set in the provider block.
+// Each value is taken from the configuration first, then from the ATLASSIAN_* environment variables,
+// and finally from the credentials file. The jira and confluence blocks override the resolved values
+// for their product, and only the values required by the auth type must be present for each product.
+func ResolveProviderConfig(config *AtlassianCloudProviderConfig) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for _, attribute := range []string{"endpoint", "username", "token", "credentials_file"} {
+		if config.attributeValue(attribute).IsUnknown() {
+			diags.Append(unknownValue(path.Root(attribute), attribute))
+		}
+	}
+	for _, product := range []struct {
+		name   string
+		config *AtlassianCloudProductConfig
+	}{{"jira", config.Jira}, {"confluence", config.Confluence}} {
+		if product.config == nil {
+			continue
+		}
+
+		for _, attribute := range []string{"endpoint", "username", "token"} {
+			if product.config.attributeValue(attribute).IsUnknown() {
+				diags.Append(unknownValue(path.Root(product.name).AtName(attribute), product.name+" "+attribute))
+			}
+		}
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	endpoint := coalesce(config.EndPoint.ValueString(), os.Getenv(envEndpoint))
+	username := coalesce(config.Username.ValueString(), os.Getenv(envUsername))
+	token := coalesce(config.Token.ValueString(), os.Getenv(envToken))
+
+	credentialsFile := coalesce(config.CredentialsFile.ValueString(), os.Getenv(envCredentialsFile))
+	if credentialsFile != "" && (endpoint == "" || username == "" || token == "") {
+		fromFile, err := readCredentialsFile(credentialsFile, endpoint)
+		if err != nil {
+			diags.AddAttributeError(path.Root("credentials_file"),
+				"Unable to read Atlassian credentials file",
+				fmt.Sprintf("Failed to read credentials from %s: %s", credentialsFile, err.Error()),
+			)
+			return diags
+		}
+
+		endpoint = coalesce(endpoint, fromFile.Endpoint)
+		username = coalesce(username, fromFile.Username)
+		token = coalesce(token, fromFile.Token)
+	}
+
+	config.EndPoint = types.StringValue(endpoint)
+	config.Username = types.StringValue(username)
+	config.Token = types.StringValue(token)
+
+	// a configured cloud ID belongs to the top-level site, a product on another site looks up its own
+	cloudIds := map[string]string{}
+	if config.Auth != nil {
+		if cloudId := coalesce(config.Auth.CloudId.ValueString(), os.Getenv(envCloudId)); cloudId != "" {
+			cloudIds[endpoint] = cloudId
+		}
+	}
+
+	config.jiraConfig = config.forProduct(config.Jira)
+	config.confluenceConfig = config.forProduct(config.Confluence)
+
+	// both products usually share the top-level values, identical diagnostics are only reported once
+	diags.Append(resolveAuth(config.jiraConfig, cloudIds)...)
+	diags.Append(resolveAuth(config.confluenceConfig, cloudIds)...)
+
+	return diags
+}
+
+func resolveAuth(config *AtlassianCloudProviderConfig, cloudIds map[string]string) diag.Diagnostics {
+	switch config.authType() {
+	case authBearer:
+		return resolveBearerAuth(config, cloudIds)
+	case authOAuth:
+		return resolveOAuthAuth(config, cloudIds)
+	default:
+		return resolveBasicAuth(config)
+	}
+}
+
+func resolveBasicAuth(config *AtlassianCloudProviderConfig) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if config.EndPoint.ValueString() == "" {
+		diags.Append(missingEndpoint())
+	}
+	if config.Username.ValueString() == "" {
+		diags.AddAttributeError(path.Root("username"),
+			"Missing Atlassian username",
+			fmt.Sprintf("Set the username attribute in the provider block, the %s environment variable, "+
+				"or a username in the credentials file.", envUsername),
+		)
+	}
+	if config.Token.ValueString() == "" {
+		diags.AddAttributeError(path.Root("token"),
+			"Missing Atlassian token",
+			fmt.Sprintf("Set the token attribute in the provider block, the %s environment variable, "+
+				"or a token in the credentials file.", envToken),
+		)
+	}
+
+	return diags
+}
+
+func resolveBearerAuth(config *AtlassianCloudProviderConfig, cloudIds map[string]string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	token := coalesce(config.Auth.Token.ValueString(), config.Token.ValueString())
+	if token == "" {
+		diags.AddAttributeError(path.Root("auth").AtName("token"),
+			"Missing Atlassian bearer token",
+			fmt.Sprintf("Set the token attribute in the auth block, the token attribute in the provider block, "+
+				"or the %s environment variable.", envToken),
+		)
+		return diags
+	}
+
+	config.Auth.Token = types.StringValue(token)
+	return resolveCloudId(config, cloudIds)
+}
+
+func resolveOAuthAuth(config *AtlassianCloudProviderConfig, cloudIds map[string]string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	clientId := coalesce(config.Auth.ClientId.ValueString(), os.Getenv(envClientId))
+	clientSecret := coalesce(config.Auth.ClientSecret.ValueString(), os.Getenv(envClientSecret))
+	if clientId == "" {
+		diags.AddAttributeError(path.Root("auth").AtName("client_id"),
+			"Missing OAuth client ID",
+			fmt.Sprintf("Set the client_id attribute in the auth block or the %s environment variable.", envClientId),
+		)
+	}
+	if clientSecret == "" {
+		diags.AddAttributeError(path.Root("auth").AtName("client_secret"),
+			"Missing OAuth client secret",
+			fmt.Sprintf("Set the client_secret attribute in the auth block or the %s environment variable.", envClientSecret),
+		)
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	config.Auth.ClientId = types.StringValue(clientId)
+	config.Auth.ClientSecret = types.StringValue(clientSecret)
+	return resolveCloudId(config, cloudIds)
+}
+
+// resolveCloudId reads the cloud ID of the site when it is not configured,
+// as the gateway addresses sites by cloud ID instead of host name.
+// Known cloud IDs are kept by endpoint so that each site is looked up once.
+func resolveCloudId(config *AtlassianCloudProviderConfig, cloudIds map[string]string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	cloudId := cloudIds[config.EndPoint.ValueString()]
+	if cloudId == "" {
+		var err error
+
+		if config.EndPoint.ValueString() == "" {
+			diags.Append(missingEndpoint())
+			return diags
+		}
+
+		cloudId, err = atlassianTransport.ReadCloudId(config.EndPoint.ValueString())
+		if err != nil {
+			diags.AddAttributeError(path.Root("auth").AtName("cloud_id"),
+				"Unable to resolve Atlassian cloud ID",
+				fmt.Sprintf("Set the cloud_id attribute in the auth block or the %s environment variable. "+
+					"Lookup failed with: %s", envCloudId, err.Error()),
+			)
+			return diags
+		}
+	}
+
+	cloudIds[config.EndPoint.ValueString()] = cloudId
+	config.Auth.CloudId = types.StringValue(cloudId)
+	return diags
+}
+
+func unknownValue(attributePath path.Path, attribute string) diag.Diagnostic {
+	return diag.NewAttributeErrorDiagnostic(attributePath,
+		fmt.Sprintf("Unknown Atlassian %s", strings.ReplaceAll(attribute, "_", " ")),
+		fmt.Sprintf("The provider cannot create the Atlassian API client as there is an unknown configuration value for %s. "+
+			"Either set the value statically in the configuration, or use the environment variables instead.", attribute),
+	)
+}
+
+func missingEndpoint() diag.Diagnostic {
+	return diag.NewAttributeErrorDiagnostic(path.Root("endpoint"),
+		"Missing Atlassian endpoint",
+		fmt.Sprintf("Set the endpoint attribute in the provider block, the %s environment variable, "+
+			"or an endpoint in the credentials file.", envEndpoint),
+	)
+}
+
+func (config *AtlassianCloudProviderConfig) attributeValue(attribute string) types.String {
+	switch attribute {
+	case "endpoint":
+		return config.EndPoint
+	case "username":
+		return config.Username
+	case "token":
+		return config.Token
+	default:
+		return config.CredentialsFile
+	}
+}
+
+func (product *AtlassianCloudProductConfig) attributeValue(attribute string) types.String {
+	switch attribute {
+	case "endpoint":
+		return product.EndPoint
+	case "username":
+		return product.Username
+	default:
+		return product.Token
+	}
+}
+
+// readCredentialsFile reads either a JSON document with endpoint, username and token keys,
+// or a netrc file. For netrc the machine matching the endpoint host is used, and when no
+// endpoint is known yet the first machine entry provides it.
+func readCredentialsFile(name string, endpoint string) (*credentials, error) {
+	content, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(content)), "{") {
+		var fromFile credentials
+		err = json.Unmarshal(content, &fromFile)
+		if err != nil {
+			return nil, err
+		}
+
+		return &fromFile, nil
+	}
+
+	return parseNetrc(string(content), endpoint)
+}
+
+func parseNetrc(content string, endpoint string) (*credentials, error) {
+	var host string
+	if endpoint != "" {
+		parsed, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		host = parsed.Hostname()
+	}
+
+	var machines []credentials
+	var current *credentials
+
+	tokens := strings.Fields(content)
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i] {
+		case "machine", "default":
+			machines = append(machines, credentials{})
+			current = &machines[len(machines)-1]
+			if tokens[i] == "machine" && i+1 < len(tokens) {
+				i++
+				current.Endpoint = "https://" + tokens[i]
+			}
+		case "login", "password":
+			if current == nil || i+1 >= len(tokens) {
+				continue
+			}
+			i++
+			if tokens[i-1] == "login" {
+				current.Username = tokens[i]
+			} else {
+				current.Token = tokens[i]
+			}
+		}
+	}
+
+	for _, machine := range machines {
+		if host == "" || machine.Endpoint == "" || machine.Endpoint == "https://"+host {
+			return &machine, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no netrc entry found for %s", host)
+}
+
+func coalesce(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
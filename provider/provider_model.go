@@ -4,31 +4,334 @@ import (
 	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/yunarta/terraform-api-transport/transport"
 	confluence "github.com/yunarta/terraform-atlassian-api-client/confluence/cloud"
 	jira "github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
+	atlassianTransport "github.com/yunarta/terraform-provider-atlassian-cloud/provider/transport"
 	"github.com/yunarta/terraform-provider-commons/util"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
 )
 
 const (
 	dataSourceConfigureTypeError = "Unexpected Data Source Configure Type"
-	expectedTypeErrorString      = "Expected *AtlassianCloudProviderModel, got: %T. Please report this issue to the provider developers."
+	expectedTypeErrorString      = "Expected *AtlassianCloudProviderData, got: %T. Please report this issue to the provider developers."
 )
 
 type AtlassianCloudProviderConfig struct {
 	EndPoint types.String `tfsdk:"endpoint"`
 	Username types.String `tfsdk:"username"`
 	Token    types.String `tfsdk:"token"`
+
+	CredentialsFile types.String `tfsdk:"credentials_file"`
+	MaxRetries      types.Int64  `tfsdk:"max_retries"`
+	RetryMaxWait    types.String `tfsdk:"retry_max_wait"`
+
+	RequestsPerSecond     types.Float64 `tfsdk:"requests_per_second"`
+	MaxConcurrentRequests types.Int64   `tfsdk:"max_concurrent_requests"`
+
+	ValidateCredentials types.Bool   `tfsdk:"validate_credentials"`
+	CassetteDirectory   types.String `tfsdk:"cassette_directory"`
+	ReadOnly            types.Bool   `tfsdk:"read_only"`
+	OnUnknownPrincipal  types.String `tfsdk:"on_unknown_principal"`
+
+	AssignmentParallelism types.Int64 `tfsdk:"assignment_parallelism"`
+
+	Auth *AtlassianCloudAuthConfig `tfsdk:"auth"`
+
+	Jira       *AtlassianCloudProductConfig `tfsdk:"jira"`
+	Confluence *AtlassianCloudProductConfig `tfsdk:"confluence"`
+
+	// jiraConfig and confluenceConfig are the effective per product configurations set by ResolveProviderConfig
+	jiraConfig       *AtlassianCloudProviderConfig
+	confluenceConfig *AtlassianCloudProviderConfig
+
+	// transportFactory replaces the transport to the site, set from AtlassianCloudProvider.TransportFactory
+	transportFactory TransportFactory
+
+	// jiraAccountId and confluenceAccountId identify the provider account once the credentials are validated
+	jiraAccountId       string
+	confluenceAccountId string
+}
+
+// AtlassianCloudProductConfig overrides the top-level endpoint and credentials for one product,
+// for Confluence hosted on a different site than Jira or a separate service account per product.
+type AtlassianCloudProductConfig struct {
+	EndPoint types.String `tfsdk:"endpoint"`
+	Username types.String `tfsdk:"username"`
+	Token    types.String `tfsdk:"token"`
+}
+
+const (
+	authBasic  = "basic"
+	authBearer = "bearer"
+	authOAuth  = "oauth"
+)
+
+type AtlassianCloudAuthConfig struct {
+	Type         types.String `tfsdk:"type"`
+	Token        types.String `tfsdk:"token"`
+	ClientId     types.String `tfsdk:"client_id"`
+	ClientSecret types.String `tfsdk:"client_secret"`
+	CloudId      types.String `tfsdk:"cloud_id"`
+}
+
+func (config *AtlassianCloudProviderConfig) authType() string {
+	if config.Auth == nil || config.Auth.Type.ValueString() == "" {
+		return authBasic
+	}
+
+	return config.Auth.Type.ValueString()
+}
+
+// TransportFactory creates the transport a product uses instead of talking to the configured site,
+// retries, rate limits and logging still apply on top of it.
+type TransportFactory func(product string, config *AtlassianCloudProviderConfig) transport.PayloadTransport
+
+// forProduct returns a copy of the configuration where the values set in the product block replace the top-level ones.
+func (config *AtlassianCloudProviderConfig) forProduct(product *AtlassianCloudProductConfig) *AtlassianCloudProviderConfig {
+	productConfig := *config
+	productConfig.jiraConfig = nil
+	productConfig.confluenceConfig = nil
+
+	if config.Auth != nil {
+		auth := *config.Auth
+		productConfig.Auth = &auth
+	}
+
+	if product != nil {
+		if product.EndPoint.ValueString() != "" {
+			productConfig.EndPoint = product.EndPoint
+		}
+		if product.Username.ValueString() != "" {
+			productConfig.Username = product.Username
+		}
+		if product.Token.ValueString() != "" {
+			productConfig.Token = product.Token
+		}
+	}
+
+	return &productConfig
+}
+
+// productConfigs returns the Jira and Confluence configurations, which are derived on the spot
+// when ResolveProviderConfig has not been called.
+func (config *AtlassianCloudProviderConfig) productConfigs() (*AtlassianCloudProviderConfig, *AtlassianCloudProviderConfig) {
+	jiraConfig, confluenceConfig := config.jiraConfig, config.confluenceConfig
+	if jiraConfig == nil {
+		jiraConfig = config.forProduct(config.Jira)
+	}
+	if confluenceConfig == nil {
+		confluenceConfig = config.forProduct(config.Confluence)
+	}
+
+	return jiraConfig, confluenceConfig
+}
+
+// newPayloadTransport creates the transport for the configured authentication.
+// Bearer and OAuth requests go through the api.atlassian.com gateway, basic authentication talks to the site directly.
+// Throttled and failed requests are retried according to max_retries and retry_max_wait,
+// and every attempt goes through the limiter shared by all clients of the provider before it is logged
+// to the subsystem of the product. With a recorder every exchange is also written to a cassette.
+// A read only provider refuses every request except GET before it reaches any of them.
+func newPayloadTransport(ctx context.Context, product string, config *AtlassianCloudProviderConfig,
+	limiter *atlassianTransport.RateLimiter, recorder *atlassianTransport.CassetteRecorder) transport.PayloadTransport {
+	var payloadTransport transport.PayloadTransport
+
+	switch {
+	case config.transportFactory != nil:
+		payloadTransport = config.transportFactory(product, config)
+	case config.authType() == authBearer:
+		payloadTransport = atlassianTransport.NewGatewayPayloadTransport(
+			config.Auth.CloudId.ValueString(),
+			atlassianTransport.StaticToken(config.Auth.Token.ValueString()),
+		)
+	case config.authType() == authOAuth:
+		payloadTransport = atlassianTransport.NewGatewayPayloadTransport(
+			config.Auth.CloudId.ValueString(),
+			atlassianTransport.NewClientCredentialsToken(
+				config.Auth.ClientId.ValueString(),
+				config.Auth.ClientSecret.ValueString(),
+			),
+		)
+	default:
+		payloadTransport = atlassianTransport.NewHttpPayloadTransport(config.EndPoint.ValueString(),
+			transport.BasicAuthentication{
+				Username: config.Username.ValueString(),
+				Password: config.Token.ValueString(),
+			},
+		)
+	}
+
+	maxRetries := atlassianTransport.DefaultMaxRetries
+	if !config.MaxRetries.IsNull() {
+		maxRetries = int(config.MaxRetries.ValueInt64())
+	}
+
+	// the schema validator only accepts valid durations
+	retryMaxWait, err := time.ParseDuration(config.RetryMaxWait.ValueString())
+	if err != nil {
+		retryMaxWait = atlassianTransport.DefaultRetryMaxWait
+	}
+
+	loggingContext := newLoggingContext(ctx, product, config)
+	payloadTransport = &atlassianTransport.RetryPayloadTransport{
+		Transport: &atlassianTransport.LimitedPayloadTransport{
+			Transport: &atlassianTransport.LoggingPayloadTransport{
+				Transport: &atlassianTransport.StatusPayloadTransport{
+					Transport: payloadTransport,
+				},
+				Context:   loggingContext,
+				Subsystem: product,
+			},
+			Limiter: limiter,
+			Context: stopContext(),
+		},
+		MaxRetries: maxRetries,
+		MaxWait:    retryMaxWait,
+		Context:    stopContext(),
+	}
+
+	if recorder != nil {
+		payloadTransport = &atlassianTransport.CassettePayloadTransport{
+			Transport: payloadTransport,
+			Recorder:  recorder,
+			Context:   loggingContext,
+			Subsystem: product,
+		}
+	} else {
+		payloadTransport = &util.RecordingHttpPayloadTransport{
+			Transport: payloadTransport,
+		}
+	}
+
+	if config.ReadOnly.ValueBool() {
+		return &atlassianTransport.ReadOnlyPayloadTransport{
+			Transport: payloadTransport,
+		}
+	}
+
+	return payloadTransport
+}
+
+// stopContext is done once Terraform is interrupted, which interrupts its providers along with it, so that a retry
+// stops waiting.
+var stopContext = sync.OnceValue(func() context.Context {
+	ctx, _ := signal.NotifyContext(context.Background(), os.Interrupt)
+	return ctx
+})
+
+// assignmentParallelism returns how many users and groups the assignments update at once, and how many Jira project
+// roles are then changed at once, one at a time by default.
+func assignmentParallelism(config *AtlassianCloudProviderConfig) int {
+	if config == nil || config.AssignmentParallelism.IsNull() || config.AssignmentParallelism.IsUnknown() {
+		return 1
+	}
+
+	return int(config.AssignmentParallelism.ValueInt64())
+}
+
+// testReadOnly adds an error and returns true when the provider is read only, for the resource
+// operations that change the site. The transport refuses the requests anyway, but the operation must
+// fail as a whole instead of leaving a partly applied change behind.
+func testReadOnly(diagnostics *diag.Diagnostics, config *AtlassianCloudProviderConfig, operation string, resource string) bool {
+	if config == nil || !config.ReadOnly.ValueBool() {
+		return false
+	}
+
+	diagnostics.AddError(
+		"Provider is read only",
+		fmt.Sprintf("Refusing to %s %s, the provider is configured with read_only = true.", operation, resource),
+	)
+	return true
+}
+
+// newLoggingContext creates the log subsystem of a product from the context of Configure. The transports outlive that
+// call, so only its root logger is kept: the cancellation of the RPC is dropped, and the subsystem does not copy the
+// fields of the RPC such as its request ID, which would otherwise be attached to the requests of every later operation.
+func newLoggingContext(ctx context.Context, product string, config *AtlassianCloudProviderConfig) context.Context {
+	return atlassianTransport.NewLoggingContext(context.WithoutCancel(ctx), product, config.secrets()...)
+}
+
+// newCassetteRecorder returns the recorder for cassette_directory, nil when recording is not enabled.
+func newCassetteRecorder(config *AtlassianCloudProviderConfig, productConfigs ...*AtlassianCloudProviderConfig) *atlassianTransport.CassetteRecorder {
+	directory := coalesce(config.CassetteDirectory.ValueString(), os.Getenv(atlassianTransport.CassetteDirectoryEnv))
+	if directory == "" {
+		return nil
+	}
+
+	var secrets []string
+	for _, productConfig := range productConfigs {
+		secrets = append(secrets, productConfig.secrets()...)
+	}
+
+	return atlassianTransport.NewCassetteRecorder(directory, secrets...)
+}
+
+// secrets returns the credentials that must never appear in logs or cassettes.
+func (config *AtlassianCloudProviderConfig) secrets() []string {
+	secrets := []string{config.Token.ValueString()}
+	if config.Auth != nil {
+		secrets = append(secrets, config.Auth.Token.ValueString(), config.Auth.ClientSecret.ValueString())
+	}
+
+	return secrets
+}
+
+// AtlassianCloudProviderData is handed to every resource and data source, so they all share
+// the same clients and the actor lookup caches of these clients.
+type AtlassianCloudProviderData struct {
+	config           *AtlassianCloudProviderConfig
+	jiraClient       *jira.JiraClient
+	confluenceClient *confluence.ConfluenceClient
+	// the actor lookup caches are safe for concurrent use, unlike the lookup service of the API client
+	jiraActorLookup       *lookup.ActorLookupService
+	confluenceActorLookup *lookup.ActorLookupService
+
+	jiraTransport       transport.PayloadTransport
+	confluenceTransport transport.PayloadTransport
+}
+
+// NewAtlassianCloudProviderData creates the clients, ctx must carry the provider logger used to log API calls.
+func NewAtlassianCloudProviderData(ctx context.Context, config *AtlassianCloudProviderConfig) *AtlassianCloudProviderData {
+	limiter := atlassianTransport.NewRateLimiter(
+		config.RequestsPerSecond.ValueFloat64(),
+		int(config.MaxConcurrentRequests.ValueInt64()),
+	)
+
+	jiraConfig, confluenceConfig := config.productConfigs()
+	// both products write into one sequence of cassettes
+	recorder := newCassetteRecorder(config, jiraConfig, confluenceConfig)
+
+	jiraTransport := newPayloadTransport(ctx, "jira", jiraConfig, limiter, recorder)
+	confluenceTransport := newPayloadTransport(ctx, "confluence", confluenceConfig, limiter, recorder)
+
+	jiraClient := jira.NewJiraClient(jiraTransport)
+	confluenceClient := confluence.NewConfluenceClient(confluenceTransport)
+
+	return &AtlassianCloudProviderData{
+		config:                config,
+		jiraClient:            jiraClient,
+		confluenceClient:      confluenceClient,
+		jiraActorLookup:       lookup.NewActorLookupService(jiraClient.ActorService()),
+		confluenceActorLookup: lookup.NewActorLookupService(confluenceClient.ActorService()),
+		jiraTransport:         jiraTransport,
+		confluenceTransport:   confluenceTransport,
+	}
 }
 
 type ConfigurableForJira interface {
-	SetConfig(config *AtlassianCloudProviderConfig, client *jira.JiraClient)
+	SetConfig(config *AtlassianCloudProviderConfig, client *jira.JiraClient, actorLookup *lookup.ActorLookupService)
 }
 
 type ConfigurableForConfluence interface {
-	SetConfig(config *AtlassianCloudProviderConfig, client *confluence.ConfluenceClient)
+	SetConfig(config *AtlassianCloudProviderConfig, client *confluence.ConfluenceClient, actorLookup *lookup.ActorLookupService)
 }
 
 func ConfigureJiraResource(receiver ConfigurableForJira, ctx context.Context, request resource.ConfigureRequest, response *resource.ConfigureResponse) {
@@ -36,7 +339,7 @@ func ConfigureJiraResource(receiver ConfigurableForJira, ctx context.Context, re
 		return
 	}
 
-	config, ok := request.ProviderData.(*AtlassianCloudProviderConfig)
+	data, ok := request.ProviderData.(*AtlassianCloudProviderData)
 	if !ok {
 		response.Diagnostics.AddError(
 			dataSourceConfigureTypeError,
@@ -45,16 +348,7 @@ func ConfigureJiraResource(receiver ConfigurableForJira, ctx context.Context, re
 		return
 	}
 
-	receiver.SetConfig(config, jira.NewJiraClient(
-		&util.RecordingHttpPayloadTransport{
-			Transport: transport.NewHttpPayloadTransport(config.EndPoint.ValueString(),
-				transport.BasicAuthentication{
-					Username: config.Username.ValueString(),
-					Password: config.Token.ValueString(),
-				},
-			),
-		},
-	))
+	receiver.SetConfig(data.config, data.jiraClient, data.jiraActorLookup)
 }
 
 func ConfigureConfluenceResource(receiver ConfigurableForConfluence, ctx context.Context, request resource.ConfigureRequest, response *resource.ConfigureResponse) {
@@ -62,7 +356,7 @@ func ConfigureConfluenceResource(receiver ConfigurableForConfluence, ctx context
 		return
 	}
 
-	config, ok := request.ProviderData.(*AtlassianCloudProviderConfig)
+	data, ok := request.ProviderData.(*AtlassianCloudProviderData)
 	if !ok {
 		response.Diagnostics.AddError(
 			dataSourceConfigureTypeError,
@@ -71,16 +365,7 @@ func ConfigureConfluenceResource(receiver ConfigurableForConfluence, ctx context
 		return
 	}
 
-	receiver.SetConfig(config, confluence.NewConfluenceClient(
-		&util.RecordingHttpPayloadTransport{
-			Transport: transport.NewHttpPayloadTransport(config.EndPoint.ValueString(),
-				transport.BasicAuthentication{
-					Username: config.Username.ValueString(),
-					Password: config.Token.ValueString(),
-				},
-			),
-		},
-	))
+	receiver.SetConfig(data.config, data.confluenceClient, data.confluenceActorLookup)
 }
 
 func ConfigureJiraDataSource(receiver ConfigurableForJira, ctx context.Context, request datasource.ConfigureRequest, response *datasource.ConfigureResponse) {
@@ -88,7 +373,7 @@ func ConfigureJiraDataSource(receiver ConfigurableForJira, ctx context.Context,
 		return
 	}
 
-	config, ok := request.ProviderData.(*AtlassianCloudProviderConfig)
+	data, ok := request.ProviderData.(*AtlassianCloudProviderData)
 	if !ok {
 		response.Diagnostics.AddError(
 			dataSourceConfigureTypeError,
@@ -97,14 +382,5 @@ func ConfigureJiraDataSource(receiver ConfigurableForJira, ctx context.Context,
 		return
 	}
 
-	receiver.SetConfig(config, jira.NewJiraClient(
-		&util.RecordingHttpPayloadTransport{
-			Transport: transport.NewHttpPayloadTransport(config.EndPoint.ValueString(),
-				transport.BasicAuthentication{
-					Username: config.Username.ValueString(),
-					Password: config.Token.ValueString(),
-				},
-			),
-		},
-	))
+	receiver.SetConfig(data.config, data.jiraClient, data.jiraActorLookup)
 }
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-api-transport/transport"
+	jiraApi "github.com/yunarta/terraform-atlassian-api-client/jira"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/test"
+	atlassianTransport "github.com/yunarta/terraform-provider-atlassian-cloud/provider/transport"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConfigureJiraResource_SharesClient(t *testing.T) {
+	data := NewAtlassianCloudProviderData(context.Background(), &AtlassianCloudProviderConfig{
+		EndPoint: types.StringValue("https://example.atlassian.net"),
+		Username: types.StringValue("ci@example.com"),
+		Token:    types.StringValue("token"),
+	})
+
+	first := &ProjectResource{}
+	second := &ProjectResource{}
+	for _, receiver := range []*ProjectResource{first, second} {
+		response := &resource.ConfigureResponse{}
+		ConfigureJiraResource(receiver, context.Background(), resource.ConfigureRequest{ProviderData: data}, response)
+		assert.False(t, response.Diagnostics.HasError())
+	}
+
+	assert.Same(t, first.getClient(), second.getClient())
+	assert.Same(t, data.jiraClient, first.getClient())
+	assert.Same(t, first.getActorLookup(), second.getActorLookup())
+	assert.Same(t, data.jiraActorLookup, first.getActorLookup())
+}
+
+func TestNewAtlassianCloudProviderData_ReadOnly(t *testing.T) {
+	fake := test.NewJiraTransport()
+	lead := fake.AddUser("lead@example.com", "Project Lead")
+
+	data := NewAtlassianCloudProviderData(context.Background(), &AtlassianCloudProviderConfig{
+		EndPoint:         types.StringValue("https://example.atlassian.net"),
+		Username:         types.StringValue("ci@example.com"),
+		Token:            types.StringValue("token"),
+		ReadOnly:         types.BoolValue(true),
+		transportFactory: func(product string, config *AtlassianCloudProviderConfig) transport.PayloadTransport { return fake },
+	})
+
+	_, err := data.jiraClient.ProjectService().Create(jiraApi.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	var readOnlyError atlassianTransport.ReadOnlyError
+	assert.ErrorAs(t, err, &readOnlyError)
+	assert.Nil(t, fake.Project("TEST"))
+	assert.Empty(t, fake.Requests())
+
+	user, err := data.jiraClient.ActorService().ReadUser("lead@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, lead.AccountID, user.AccountID)
+}
+
+func TestNewAtlassianCloudProviderData_Logging(t *testing.T) {
+	t.Setenv(atlassianTransport.LogLevelEnv, "DEBUG")
+	fake := test.NewJiraTransport()
+	fake.AddUser("lead@example.com", "Project Lead")
+
+	// the context of Configure, which is done once the provider is configured
+	var output bytes.Buffer
+	ctx, cancel := context.WithCancel(tflog.SetField(tflogtest.RootLogger(context.Background(), &output), "tf_req_id", "configure"))
+	data := NewAtlassianCloudProviderData(ctx, &AtlassianCloudProviderConfig{
+		EndPoint:         types.StringValue("https://example.atlassian.net"),
+		Username:         types.StringValue("ci@example.com"),
+		Token:            types.StringValue("token"),
+		transportFactory: func(product string, config *AtlassianCloudProviderConfig) transport.PayloadTransport { return fake },
+	})
+	cancel()
+
+	_, err := data.jiraClient.ActorService().ReadUser("lead@example.com")
+	assert.Nil(t, err)
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	assert.Nil(t, err)
+	if assert.NotEmpty(t, entries) {
+		for _, entry := range entries {
+			assert.Equal(t, "provider.jira", entry["@module"])
+			assert.NotContains(t, entry, "tf_req_id")
+		}
+	}
+}
+
+func TestNewAtlassianCloudProviderData_RetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		user, _, _ := request.BasicAuth()
+		assert.Equal(t, "ci@example.com", user)
+
+		// a POST is only retried when the site says when to, with Retry-After
+		if attempts.Add(1) == 1 {
+			writer.Header().Set("Retry-After", "1")
+			writer.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		writer.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	data := NewAtlassianCloudProviderData(context.Background(), &AtlassianCloudProviderConfig{
+		EndPoint: types.StringValue(server.URL),
+		Username: types.StringValue("ci@example.com"),
+		Token:    types.StringValue("token"),
+	})
+
+	started := time.Now()
+	reply, err := data.jiraTransport.SendWithExpectedStatus(&transport.PayloadRequest{
+		Method:  http.MethodPost,
+		Url:     "/rest/api/latest/project",
+		Payload: transport.JsonPayloadData{Payload: map[string]string{"key": "KEY"}},
+	}, http.StatusCreated)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusCreated, reply.StatusCode)
+	assert.Equal(t, int32(2), attempts.Load())
+	assert.GreaterOrEqual(t, time.Since(started), time.Second)
+}
+
+func TestTestReadOnly(t *testing.T) {
+	var diags diag.Diagnostics
+	assert.False(t, testReadOnly(&diags, nil, "create", "atlassian_jira_project TEST"))
+	assert.False(t, testReadOnly(&diags, &AtlassianCloudProviderConfig{}, "create", "atlassian_jira_project TEST"))
+	assert.False(t, diags.HasError())
+
+	assert.True(t, testReadOnly(&diags, &AtlassianCloudProviderConfig{ReadOnly: types.BoolValue(true)}, "delete", "atlassian_confluence_space TEST"))
+	assert.Equal(t, "Provider is read only", diags.Errors()[0].Summary())
+	assert.Contains(t, diags.Errors()[0].Detail(), "delete atlassian_confluence_space TEST")
+}
@@ -4,67 +4,79 @@ import (
 	"context"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
-	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/test"
+	"testing"
 )
 
+// TestAtlassianCloudProvider shares the schema and configuration of AtlassianCloudProvider
+// but only exposes the data sources and resources under test.
 type TestAtlassianCloudProvider struct {
-	Version string
+	AtlassianCloudProvider
 }
 
-func (p *TestAtlassianCloudProvider) Metadata(ctx context.Context, request provider.MetadataRequest, response *provider.MetadataResponse) {
-	response.TypeName = "atlassian"
-	response.Version = p.Version
+func (p *TestAtlassianCloudProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		NewUserDataSource,
+	}
 }
 
-func (p *TestAtlassianCloudProvider) Schema(ctx context.Context, request provider.SchemaRequest, response *provider.SchemaResponse) {
-	response.Schema = schema.Schema{
-		Attributes: map[string]schema.Attribute{
-			"endpoint": schema.StringAttribute{
-				Required: true,
-			},
-			"username": schema.StringAttribute{
-				Required: true,
-			},
-			"token": schema.StringAttribute{
-				Required:  true,
-				Sensitive: true,
-			},
-		},
+func (p *TestAtlassianCloudProvider) Resources(ctx context.Context) []func() resource.Resource {
+	return []func() resource.Resource{
+		NewProjectResource,
+		NewConfluenceSpaceResource,
 	}
 }
 
-func (p *TestAtlassianCloudProvider) Configure(ctx context.Context, request provider.ConfigureRequest, response *provider.ConfigureResponse) {
-	var config *AtlassianCloudProviderConfig
+var _ provider.Provider = &TestAtlassianCloudProvider{}
 
-	diags := request.Config.Get(ctx, &config)
-	response.Diagnostics.Append(diags...)
-	if response.Diagnostics.HasError() {
-		return
+func NewTestProvider(Version string) func() provider.Provider {
+	return func() provider.Provider {
+		return &TestAtlassianCloudProvider{
+			AtlassianCloudProvider: AtlassianCloudProvider{
+				Version: Version,
+			},
+		}
 	}
+}
 
-	response.DataSourceData = config
-	response.ResourceData = config
+// NewReplayTestProvider serves the API from the cassettes in directory instead of a live site.
+func NewReplayTestProvider(t *testing.T, directory string) func() provider.Provider {
+	return newTransportTestProvider(test.NewReplayTransport(t, directory, test.ReplayByRequest))
 }
 
-func (p *TestAtlassianCloudProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{
-		NewUserDataSource,
+// NewFakeTestProvider serves the API from an in-memory Jira and Confluence, so that resources can be created,
+// updated, imported and destroyed without a site. A nil confluence serves Confluence on the same site as jira.
+func NewFakeTestProvider(jira *test.JiraTransport, confluence *test.ConfluenceTransport) func() provider.Provider {
+	if confluence == nil {
+		confluence = test.NewConfluenceTransport(jira)
 	}
-}
 
-func (p *TestAtlassianCloudProvider) Resources(ctx context.Context) []func() resource.Resource {
-	return []func() resource.Resource{
-		NewProjectResource,
+	return func() provider.Provider {
+		return &TestAtlassianCloudProvider{
+			AtlassianCloudProvider: AtlassianCloudProvider{
+				Version: "test",
+				TransportFactory: func(product string, config *AtlassianCloudProviderConfig) transport.PayloadTransport {
+					if product == "confluence" {
+						return confluence
+					}
+					return jira
+				},
+			},
+		}
 	}
 }
 
-var _ provider.Provider = &TestAtlassianCloudProvider{}
-
-func New(Version string) func() provider.Provider {
+func newTransportTestProvider(payloadTransport transport.PayloadTransport) func() provider.Provider {
 	return func() provider.Provider {
 		return &TestAtlassianCloudProvider{
-			Version: Version,
+			AtlassianCloudProvider: AtlassianCloudProvider{
+				Version: "test",
+				TransportFactory: func(product string, config *AtlassianCloudProviderConfig) transport.PayloadTransport {
+					return payloadTransport
+				},
+			},
 		}
 	}
 }
@@ -0,0 +1,294 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/yunarta/golang-quality-of-life-pack/collections"
+	"github.com/yunarta/terraform-api-transport/transport"
+	atlassianTransport "github.com/yunarta/terraform-provider-atlassian-cloud/provider/transport"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	jiraServerInfoUrl     = "/rest/api/3/serverInfo"
+	jiraMyselfUrl         = "/rest/api/3/myself"
+	jiraMyPermissionsUrl  = "/rest/api/3/mypermissions?permissions=ADMINISTER"
+	confluenceCurrentUser = "/wiki/rest/api/user/current?expand=operations"
+
+	wrongSiteSummary = "Wrong Atlassian site"
+)
+
+type jiraServerInfo struct {
+	BaseUrl        string `json:"baseUrl"`
+	DeploymentType string `json:"deploymentType"`
+}
+
+type jiraMyself struct {
+	AccountId string `json:"accountId"`
+}
+
+type jiraMyPermissions struct {
+	Permissions map[string]struct {
+		HavePermission bool `json:"havePermission"`
+	} `json:"permissions"`
+}
+
+type confluenceCurrentUserResponse struct {
+	Type       string `json:"type"`
+	AccountId  string `json:"accountId"`
+	Operations []struct {
+		Operation  string `json:"operation"`
+		TargetType string `json:"targetType"`
+	} `json:"operations"`
+}
+
+// ValidateCredentials checks that the configured products accept the credentials and that the provider account
+// is an administrator, so a misconfiguration fails in Configure instead of halfway through an apply.
+// The account IDs of the provider account are kept in the configuration handed to the resources.
+// A product without an endpoint is not validated. A product that the shared site does not have is only reported
+// with a warning, unless it has its own block or the other product is not found either.
+func (data *AtlassianCloudProviderData) ValidateCredentials() diag.Diagnostics {
+	var diags, jiraDiags, confluenceDiags diag.Diagnostics
+
+	jiraConfig, confluenceConfig := data.config.productConfigs()
+
+	if configured(jiraConfig) {
+		data.config.jiraAccountId, jiraDiags = validateJiraCredentials(data.jiraTransport, siteName(jiraConfig))
+	}
+
+	if configured(confluenceConfig) {
+		data.config.confluenceAccountId, confluenceDiags = validateConfluenceCredentials(data.confluenceTransport, siteName(confluenceConfig))
+	}
+
+	switch {
+	case data.config.Jira == nil && productMissing(jiraDiags) && configured(confluenceConfig) && !productMissing(confluenceDiags):
+		jiraDiags = diag.Diagnostics{productUnavailable("Jira", siteName(jiraConfig))}
+	case data.config.Confluence == nil && productMissing(confluenceDiags) && configured(jiraConfig) && !productMissing(jiraDiags):
+		confluenceDiags = diag.Diagnostics{productUnavailable("Confluence", siteName(confluenceConfig))}
+	}
+
+	diags.Append(jiraDiags...)
+	diags.Append(confluenceDiags...)
+	return diags
+}
+
+// configured tells whether the product has a site to talk to, either an endpoint or a cloud ID of the gateway.
+func configured(config *AtlassianCloudProviderConfig) bool {
+	return config.authType() != authBasic || config.EndPoint.ValueString() != ""
+}
+
+// productMissing tells whether the validation of a product failed because the site does not have it.
+func productMissing(diags diag.Diagnostics) bool {
+	for _, diagnostic := range diags.Errors() {
+		if diagnostic.Summary() == wrongSiteSummary {
+			return true
+		}
+	}
+
+	return false
+}
+
+func productUnavailable(product string, site string) diag.Diagnostic {
+	return diag.NewWarningDiagnostic("Atlassian product not available",
+		fmt.Sprintf("%s was not found on %s, its resources cannot be managed until it is added to the site "+
+			"or given its own endpoint in the %s block of the provider.", product, site, strings.ToLower(product)),
+	)
+}
+
+func validateJiraCredentials(payloadTransport transport.PayloadTransport, site string) (string, diag.Diagnostics) {
+	var (
+		diags       diag.Diagnostics
+		serverInfo  jiraServerInfo
+		myself      jiraMyself
+		permissions jiraMyPermissions
+	)
+
+	err := getJson(payloadTransport, jiraServerInfoUrl, &serverInfo)
+	if err != nil {
+		diags.Append(credentialDiagnostic("Jira", site, err))
+		return "", diags
+	}
+
+	if serverInfo.DeploymentType != "Cloud" {
+		diags.AddError("Not an Atlassian Cloud site",
+			fmt.Sprintf("%s reports the Jira deployment type %q, this provider only supports Atlassian Cloud.",
+				site, serverInfo.DeploymentType),
+		)
+		return "", diags
+	}
+
+	err = getJson(payloadTransport, jiraMyselfUrl, &myself)
+	if err != nil {
+		diags.Append(credentialDiagnostic("Jira", site, err))
+		return "", diags
+	}
+
+	err = getJson(payloadTransport, jiraMyPermissionsUrl, &permissions)
+	if err != nil {
+		diags.Append(credentialDiagnostic("Jira", site, err))
+		return myself.AccountId, diags
+	}
+
+	if !permissions.Permissions["ADMINISTER"].HavePermission {
+		diags.AddError("Missing Jira administrator permission",
+			fmt.Sprintf("The provider account %s is not a Jira administrator on %s, "+
+				"which is required to create projects and manage their roles.", myself.AccountId, site),
+		)
+	}
+
+	return myself.AccountId, diags
+}
+
+func validateConfluenceCredentials(payloadTransport transport.PayloadTransport, site string) (string, diag.Diagnostics) {
+	var (
+		diags       diag.Diagnostics
+		currentUser confluenceCurrentUserResponse
+	)
+
+	err := getJson(payloadTransport, confluenceCurrentUser, &currentUser)
+	if err != nil {
+		diags.Append(credentialDiagnostic("Confluence", site, err))
+		return "", diags
+	}
+
+	// Confluence answers requests with unknown credentials as the anonymous user instead of rejecting them
+	if currentUser.Type == "anonymous" || currentUser.AccountId == "" {
+		diags.Append(credentialDiagnostic("Confluence", site, atlassianTransport.ResponseError{
+			StatusCode: http.StatusUnauthorized,
+		}))
+		return "", diags
+	}
+
+	for _, operation := range currentUser.Operations {
+		if operation.Operation == "administer" {
+			return currentUser.AccountId, diags
+		}
+	}
+
+	diags.AddError("Missing Confluence administrator permission",
+		fmt.Sprintf("The provider account %s is not a Confluence administrator on %s, "+
+			"which is required to create spaces and manage their permissions.", currentUser.AccountId, site),
+	)
+	return currentUser.AccountId, diags
+}
+
+func getJson(payloadTransport transport.PayloadTransport, url string, value any) error {
+	reply, err := payloadTransport.SendWithExpectedStatus(&transport.PayloadRequest{
+		Method: http.MethodGet,
+		Url:    url,
+	}, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal([]byte(reply.Body), value)
+}
+
+func credentialDiagnostic(product string, site string, err error) diag.Diagnostic {
+	var responseError atlassianTransport.ResponseError
+	if errors.As(err, &responseError) {
+		switch responseError.StatusCode {
+		case http.StatusUnauthorized:
+			return diag.NewErrorDiagnostic("Invalid Atlassian credentials",
+				fmt.Sprintf("%s on %s rejected the credentials, check the username and token of the provider, "+
+					"and that the token has not expired or been revoked.", product, site),
+			)
+		case http.StatusForbidden:
+			return diag.NewErrorDiagnostic("Atlassian credentials not permitted",
+				fmt.Sprintf("%s on %s accepted the credentials but denied access: %s", product, site, err.Error()),
+			)
+		case http.StatusNotFound:
+			return diag.NewErrorDiagnostic(wrongSiteSummary,
+				fmt.Sprintf("%s was not found on %s, check the endpoint of the provider.", product, site),
+			)
+		}
+	}
+
+	return diag.NewErrorDiagnostic("Unable to reach Atlassian site",
+		fmt.Sprintf("Failed to connect to %s on %s: %s", product, site, err.Error()),
+	)
+}
+
+func siteName(config *AtlassianCloudProviderConfig) string {
+	if config.authType() != authBasic {
+		return fmt.Sprintf("cloud ID %s", config.Auth.CloudId.ValueString())
+	}
+
+	return config.EndPoint.ValueString()
+}
+
+// selfAdminRemoval warns when the assignments are about to take the admin role away from the provider account,
+// after which the provider may no longer be able to manage the resource. The roles are keyed by account ID.
+func selfAdminRemoval(accountId string, adminRole string, inState map[string][]string, planned map[string][]string) diag.Diagnostics {
+	if accountId == "" {
+		return nil
+	}
+
+	if !collections.Contains(inState[accountId], adminRole) || collections.Contains(planned[accountId], adminRole) {
+		return nil
+	}
+
+	return diag.Diagnostics{diag.NewWarningDiagnostic("Provider account removes its own admin access",
+		fmt.Sprintf("The assignments remove %s from %s, which is the account used by the provider. "+
+			"Further changes to this resource may fail once the role is removed.", adminRole, accountId),
+	)}
+}
+
+const (
+	unknownPrincipalError  = "error"
+	unknownPrincipalWarn   = "warn"
+	unknownPrincipalIgnore = "ignore"
+)
+
+// unknownPrincipalMode returns how users and groups of the assignments that do not exist are reported.
+// The setting of the resource overrides the one of the provider, and both default to a warning.
+func unknownPrincipalMode(config *AtlassianCloudProviderConfig, override types.String) string {
+	if !override.IsNull() && !override.IsUnknown() {
+		return override.ValueString()
+	}
+
+	if config != nil && !config.OnUnknownPrincipal.IsNull() {
+		return config.OnUnknownPrincipal.ValueString()
+	}
+
+	return unknownPrincipalWarn
+}
+
+// unresolvedPrincipals lists the actors that cannot be found, with the priorities of the assignments naming them.
+func unresolvedPrincipals(kind string, names []string, priorities map[string][]int64, found func(name string) bool) []string {
+	var unresolved []string
+	for _, name := range collections.Unique(names) {
+		if found(name) {
+			continue
+		}
+
+		var assignments []string
+		for _, priority := range priorities[name] {
+			assignments = append(assignments, strconv.FormatInt(priority, 10))
+		}
+		unresolved = append(unresolved, fmt.Sprintf("%s %q in the assignment with priority %s",
+			kind, name, strings.Join(assignments, ", ")))
+	}
+
+	return unresolved
+}
+
+// unknownPrincipals reports the unresolved users and groups of the assignments of resource according to mode.
+func unknownPrincipals(mode string, resource string, unresolved []string) diag.Diagnostics {
+	if len(unresolved) == 0 || mode == unknownPrincipalIgnore {
+		return nil
+	}
+
+	detail := fmt.Sprintf("The assignments of %s name users or groups that do not exist, they are not granted anything:\n  %s",
+		resource, strings.Join(unresolved, "\n  "))
+	if mode == unknownPrincipalError {
+		return diag.Diagnostics{diag.NewErrorDiagnostic("Unknown users or groups in assignments", detail)}
+	}
+
+	return diag.Diagnostics{diag.NewWarningDiagnostic("Unknown users or groups in assignments", detail)}
+}
@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newValidationSite(t *testing.T, token string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/3/serverInfo", func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(`{"baseUrl":"https://example.atlassian.net","deploymentType":"Cloud"}`))
+	})
+	mux.HandleFunc("/rest/api/3/myself", func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(`{"accountId":"jira-account"}`))
+	})
+	mux.HandleFunc("/rest/api/3/mypermissions", func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(`{"permissions":{"ADMINISTER":{"havePermission":true}}}`))
+	})
+	mux.HandleFunc("/wiki/rest/api/user/current", func(writer http.ResponseWriter, request *http.Request) {
+		_, _ = writer.Write([]byte(`{"type":"known","accountId":"confluence-account","operations":[{"operation":"administer","targetType":"application"}]}`))
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		_, password, _ := request.BasicAuth()
+		if password != token && request.URL.Path != "/rest/api/3/serverInfo" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		mux.ServeHTTP(writer, request)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestValidateCredentials(t *testing.T) {
+	site := newValidationSite(t, "token")
+
+	config := &AtlassianCloudProviderConfig{
+		EndPoint: types.StringValue(site.URL),
+		Username: types.StringValue("ci@example.com"),
+		Token:    types.StringValue("token"),
+	}
+
+	diags := NewAtlassianCloudProviderData(context.Background(), config).ValidateCredentials()
+	assert.False(t, diags.HasError())
+	assert.Equal(t, "jira-account", config.jiraAccountId)
+	assert.Equal(t, "confluence-account", config.confluenceAccountId)
+}
+
+func TestValidateCredentials_InvalidToken(t *testing.T) {
+	site := newValidationSite(t, "token")
+
+	config := &AtlassianCloudProviderConfig{
+		EndPoint: types.StringValue(site.URL),
+		Username: types.StringValue("ci@example.com"),
+		Token:    types.StringValue("revoked"),
+	}
+
+	diags := NewAtlassianCloudProviderData(context.Background(), config).ValidateCredentials()
+	assert.True(t, diags.HasError())
+	for _, diagnostic := range diags.Errors() {
+		assert.Equal(t, "Invalid Atlassian credentials", diagnostic.Summary())
+	}
+	assert.Empty(t, config.jiraAccountId)
+}
+
+func TestValidateCredentials_WrongSite(t *testing.T) {
+	site := httptest.NewServer(http.NotFoundHandler())
+	defer site.Close()
+
+	config := &AtlassianCloudProviderConfig{
+		EndPoint: types.StringValue(site.URL),
+		Username: types.StringValue("ci@example.com"),
+		Token:    types.StringValue("token"),
+	}
+
+	diags := NewAtlassianCloudProviderData(context.Background(), config).ValidateCredentials()
+	assert.True(t, diags.HasError())
+	assert.Equal(t, "Wrong Atlassian site", diags.Errors()[0].Summary())
+}
+
+func TestValidateCredentials_ProductNotOnSite(t *testing.T) {
+	jiraOnly := newValidationSite(t, "token")
+	site := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if strings.HasPrefix(request.URL.Path, "/wiki/") {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		jiraOnly.Config.Handler.ServeHTTP(writer, request)
+	}))
+	defer site.Close()
+
+	config := &AtlassianCloudProviderConfig{
+		EndPoint: types.StringValue(site.URL),
+		Username: types.StringValue("ci@example.com"),
+		Token:    types.StringValue("token"),
+	}
+
+	diags := NewAtlassianCloudProviderData(context.Background(), config).ValidateCredentials()
+	assert.False(t, diags.HasError())
+	if assert.Equal(t, 1, diags.WarningsCount()) {
+		assert.Equal(t, "Atlassian product not available", diags.Warnings()[0].Summary())
+	}
+	assert.Equal(t, "jira-account", config.jiraAccountId)
+
+	// a product given its own block is expected to be there
+	config = &AtlassianCloudProviderConfig{
+		EndPoint:   types.StringValue(site.URL),
+		Username:   types.StringValue("ci@example.com"),
+		Token:      types.StringValue("token"),
+		Confluence: &AtlassianCloudProductConfig{EndPoint: types.StringValue(site.URL)},
+	}
+
+	diags = NewAtlassianCloudProviderData(context.Background(), config).ValidateCredentials()
+	assert.True(t, diags.HasError())
+	assert.Equal(t, "Wrong Atlassian site", diags.Errors()[0].Summary())
+}
+
+func TestValidateCredentials_ProductNotConfigured(t *testing.T) {
+	site := newValidationSite(t, "token")
+
+	config := &AtlassianCloudProviderConfig{
+		Username:   types.StringValue("ci@example.com"),
+		Token:      types.StringValue("token"),
+		Confluence: &AtlassianCloudProductConfig{EndPoint: types.StringValue(site.URL)},
+	}
+
+	diags := NewAtlassianCloudProviderData(context.Background(), config).ValidateCredentials()
+	assert.Empty(t, diags)
+	assert.Empty(t, config.jiraAccountId)
+	assert.Equal(t, "confluence-account", config.confluenceAccountId)
+}
+
+func TestUnknownPrincipalMode(t *testing.T) {
+	assert.Equal(t, unknownPrincipalWarn, unknownPrincipalMode(nil, types.StringNull()))
+	assert.Equal(t, unknownPrincipalWarn, unknownPrincipalMode(&AtlassianCloudProviderConfig{}, types.StringNull()))
+
+	config := &AtlassianCloudProviderConfig{OnUnknownPrincipal: types.StringValue(unknownPrincipalError)}
+	assert.Equal(t, unknownPrincipalError, unknownPrincipalMode(config, types.StringNull()))
+	assert.Equal(t, unknownPrincipalIgnore, unknownPrincipalMode(config, types.StringValue(unknownPrincipalIgnore)))
+}
+
+func TestUnknownPrincipals(t *testing.T) {
+	unresolved := unresolvedPrincipals("user", []string{"known@example.com", "typo@example.com", "typo@example.com"},
+		map[string][]int64{"typo@example.com": {1, 3}}, func(name string) bool {
+			return name == "known@example.com"
+		})
+	assert.Equal(t, []string{`user "typo@example.com" in the assignment with priority 1, 3`}, unresolved)
+
+	diags := unknownPrincipals(unknownPrincipalError, "atlassian_jira_project TEST", unresolved)
+	assert.True(t, diags.HasError())
+	assert.Contains(t, diags[0].Detail(), `user "typo@example.com"`)
+
+	diags = unknownPrincipals(unknownPrincipalWarn, "atlassian_jira_project TEST", unresolved)
+	assert.False(t, diags.HasError())
+	assert.Equal(t, 1, diags.WarningsCount())
+
+	assert.Empty(t, unknownPrincipals(unknownPrincipalIgnore, "atlassian_jira_project TEST", unresolved))
+	assert.Empty(t, unknownPrincipals(unknownPrincipalError, "atlassian_jira_project TEST", nil))
+}
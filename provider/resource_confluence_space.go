@@ -3,29 +3,35 @@ package provider
 import (
 	"context"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	confluenceApi "github.com/yunarta/terraform-atlassian-api-client/confluence"
 	"github.com/yunarta/terraform-atlassian-api-client/confluence/cloud"
 	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/confluence"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
 	"github.com/yunarta/terraform-provider-commons/util"
 	"regexp"
 )
 
 type ConfluenceSpaceResource struct {
-	client *cloud.ConfluenceClient
-	model  *AtlassianCloudProviderConfig
+	client      *cloud.ConfluenceClient
+	actorLookup *lookup.ActorLookupService
+	model       *AtlassianCloudProviderConfig
 }
 
 var (
 	_ resource.Resource                = &ConfluenceSpaceResource{}
 	_ resource.ResourceWithConfigure   = &ConfluenceSpaceResource{}
 	_ resource.ResourceWithImportState = &ConfluenceSpaceResource{}
+	_ resource.ResourceWithModifyPlan  = &ConfluenceSpaceResource{}
 	_ ConfigurableForConfluence        = &ConfluenceSpaceResource{}
 	_ SpaceRoleResource                = &ConfluenceSpaceResource{}
 )
@@ -38,9 +44,41 @@ func (receiver *ConfluenceSpaceResource) getClient() *cloud.ConfluenceClient {
 	return receiver.client
 }
 
-func (receiver *ConfluenceSpaceResource) SetConfig(config *AtlassianCloudProviderConfig, client *cloud.ConfluenceClient) {
+func (receiver *ConfluenceSpaceResource) getActorLookup() *lookup.ActorLookupService {
+	return receiver.actorLookup
+}
+
+func (receiver *ConfluenceSpaceResource) getProviderConfig() *AtlassianCloudProviderConfig {
+	return receiver.model
+}
+
+func (receiver *ConfluenceSpaceResource) getProviderAccountId() string {
+	if receiver.model == nil {
+		return ""
+	}
+
+	return receiver.model.confluenceAccountId
+}
+
+// getSpaceCreator returns the account ID the provider creates spaces with, or its username when the credentials
+// were not validated
+func (receiver *ConfluenceSpaceResource) getSpaceCreator() string {
+	if receiver.model == nil {
+		return ""
+	}
+
+	if receiver.model.confluenceAccountId != "" {
+		return receiver.model.confluenceAccountId
+	}
+
+	_, confluenceConfig := receiver.model.productConfigs()
+	return confluenceConfig.Username.ValueString()
+}
+
+func (receiver *ConfluenceSpaceResource) SetConfig(config *AtlassianCloudProviderConfig, client *cloud.ConfluenceClient, actorLookup *lookup.ActorLookupService) {
 	receiver.model = config
 	receiver.client = client
+	receiver.actorLookup = actorLookup
 }
 
 func (receiver *ConfluenceSpaceResource) Metadata(ctx context.Context, request resource.MetadataRequest, response *resource.MetadataResponse) {
@@ -75,7 +113,34 @@ func (receiver *ConfluenceSpaceResource) Schema(ctx context.Context, request res
 			},
 
 			"assignment_version": schema.StringAttribute{
+				Optional:           true,
+				DeprecationMessage: "Drift of the assignments is detected on plan, changing assignment_version is no longer needed to reapply them.",
+			},
+			"authoritative": schema.BoolAttribute{
+				Optional: true,
+			},
+			"merge_strategy": schema.StringAttribute{
 				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(confluence.MergeOverride),
+				Validators: []validator.String{
+					stringvalidator.OneOf(confluence.MergeOverride, confluence.MergeUnion),
+				},
+			},
+			"rollback_on_failure": schema.BoolAttribute{
+				Optional: true,
+			},
+			"on_unknown_principal": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(unknownPrincipalError, unknownPrincipalWarn, unknownPrincipalIgnore),
+				},
+			},
+			"authoritative_allow_list": schema.ListAttribute{
+				Optional:    true,
+				Computed:    true,
+				ElementType: types.StringType,
+				Default:     listdefault.StaticValue(types.ListValueMust(types.StringType, []attr.Value{})),
 			},
 			"computed_users":  confluence.ComputedAssignmentSchema,
 			"computed_groups": confluence.ComputedAssignmentSchema,
@@ -103,6 +168,10 @@ func (receiver *ConfluenceSpaceResource) Create(ctx context.Context, request res
 		return
 	}
 
+	if testReadOnly(&response.Diagnostics, receiver.model, "create", "atlassian_confluence_space "+plan.Key.ValueString()) {
+		return
+	}
+
 	createSpace, err := receiver.client.SpaceService().Create(confluenceApi.CreateSpace{
 		Key:  plan.Key.ValueString(),
 		Name: plan.Name.ValueString(),
@@ -125,6 +194,10 @@ func (receiver *ConfluenceSpaceResource) Create(ctx context.Context, request res
 
 	computation, diags := CreateSpaceRoleAssignments(ctx, receiver, plan)
 	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		// the changes made before the failure are recorded, for the next plan to only show the remaining ones
+		if computation != nil {
+			response.Diagnostics.Append(response.State.Set(ctx, NewSpaceModel(plan, createSpace, computation))...)
+		}
 		return
 	}
 
@@ -136,6 +209,47 @@ func (receiver *ConfluenceSpaceResource) Create(ctx context.Context, request res
 	}
 }
 
+// ModifyPlan fills computed_users and computed_groups with the permissions the planned assignments grant,
+// so that the plan shows who gains or loses access.
+func (receiver *ConfluenceSpaceResource) ModifyPlan(ctx context.Context, request resource.ModifyPlanRequest, response *resource.ModifyPlanResponse) {
+	// nothing to preview on destroy, or before the provider is configured
+	if request.Plan.Raw.IsNull() || receiver.client == nil {
+		return
+	}
+
+	var plan SpaceModel
+	diags := request.Plan.Get(ctx, &plan)
+	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		return
+	}
+
+	var inState SpaceRoleInterface
+	if !request.State.Raw.IsNull() {
+		var state SpaceModel
+		diags = request.State.Get(ctx, &state)
+		if util.TestDiagnostic(&response.Diagnostics, diags) {
+			return
+		}
+
+		inState = state
+	}
+
+	preview, diags := PreviewSpaceRoleAssignments(ctx, receiver, plan, inState)
+	if util.TestDiagnostic(&response.Diagnostics, diags) || preview == nil {
+		return
+	}
+
+	diags = response.Plan.SetAttribute(ctx, path.Root("computed_users"), preview.ComputedUsers)
+	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		return
+	}
+
+	diags = response.Plan.SetAttribute(ctx, path.Root("computed_groups"), preview.ComputedGroups)
+	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		return
+	}
+}
+
 func (receiver *ConfluenceSpaceResource) Read(ctx context.Context, request resource.ReadRequest, response *resource.ReadResponse) {
 	var (
 		diags diag.Diagnostics
@@ -150,6 +264,11 @@ func (receiver *ConfluenceSpaceResource) Read(ctx context.Context, request resou
 		return
 	}
 
+	// state written before merge_strategy existed has none, and is read with the default of the schema
+	if state.MergeStrategy.IsNull() {
+		state.MergeStrategy = types.StringValue(confluence.MergeOverride)
+	}
+
 	space, err = receiver.client.SpaceService().Read(state.Key.ValueString())
 	if util.TestError(&response.Diagnostics, err, "failed to remove project") {
 		return
@@ -187,6 +306,10 @@ func (receiver *ConfluenceSpaceResource) Update(ctx context.Context, request res
 		return
 	}
 
+	if testReadOnly(&response.Diagnostics, receiver.model, "update", "atlassian_confluence_space "+state.Key.ValueString()) {
+		return
+	}
+
 	space, err := receiver.client.SpaceService().Update(state.Key.ValueString(), confluenceApi.UpdateSpace{
 		Name: plan.Name.ValueString(),
 		Description: confluenceApi.Description{
@@ -200,9 +323,18 @@ func (receiver *ConfluenceSpaceResource) Update(ctx context.Context, request res
 		return
 	}
 
-	forceUpdate := !plan.AssignmentVersion.Equal(state.AssignmentVersion)
+	// the planned computed assignments differ from the state when the permissions read from the site drifted
+	forceUpdate := !plan.AssignmentVersion.Equal(state.AssignmentVersion) ||
+		!plan.ComputedUsers.Equal(state.ComputedUsers) || !plan.ComputedGroups.Equal(state.ComputedGroups)
 	computation, diags = UpdateSpaceRoleAssignments(ctx, receiver, plan, state, forceUpdate)
 	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		// the changes made before the failure are recorded, and the assignments stay the ones in state for the next
+		// plan to show the remaining changes
+		if computation != nil {
+			model := NewSpaceModel(plan, space, computation)
+			model.Assignments = state.Assignments
+			response.Diagnostics.Append(response.State.Set(ctx, model)...)
+		}
 		return
 	}
 
@@ -227,6 +359,10 @@ func (receiver *ConfluenceSpaceResource) Delete(ctx context.Context, request res
 		return
 	}
 
+	if testReadOnly(&response.Diagnostics, receiver.model, "delete", "atlassian_confluence_space "+state.Key.ValueString()) {
+		return
+	}
+
 	if !state.RetainOnDelete.ValueBool() {
 		err = receiver.client.SpaceService().Delete(state.Key.ValueString())
 		if util.TestError(&response.Diagnostics, err, "failed to remove project") {
@@ -242,4 +378,10 @@ func (receiver *ConfluenceSpaceResource) ImportState(ctx context.Context, reques
 	if util.TestDiagnostic(&response.Diagnostics, diags) {
 		return
 	}
+
+	// an imported resource is read with the default strategy, as the site does not know how assignments were merged
+	diags = response.State.SetAttribute(ctx, path.Root("merge_strategy"), confluence.MergeOverride)
+	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		return
+	}
 }
@@ -0,0 +1,128 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	frameworkResource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/confluence"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/test"
+	"testing"
+)
+
+func TestConfluenceSpaceResource(t *testing.T) {
+	jira := test.NewJiraTransport()
+	creator := jira.AddUser("lead@example.com", "Space Creator")
+	writer := jira.AddUser("writer@example.com", "Writer")
+	readers := jira.AddGroup("confluence-readers")
+	jira.SetCurrentUser(creator.AccountID)
+
+	confluence := test.NewConfluenceTransport(jira)
+
+	spaceConfig := func(name string, assignments string) string {
+		return fakeProviderConfig + fmt.Sprintf(`
+resource "atlassian_confluence_space" "test" {
+  key              = "TEST"
+  name             = %q
+  description      = "Test space"
+  retain_on_delete = false
+%s
+}`, name, assignments)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: map[string]func() (tfprotov6.ProviderServer, error){
+			"atlassian": providerserver.NewProtocol6WithError(NewFakeTestProvider(jira, confluence)()),
+		},
+		CheckDestroy: func(state *terraform.State) error {
+			if confluence.Space("TEST") != nil {
+				return fmt.Errorf("space TEST still exists")
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: spaceConfig("Test", `
+  assignments {
+    users       = ["writer@example.com"]
+    permissions = ["read_space", "create_page"]
+    priority    = 1
+  }
+
+  assignments {
+    groups      = ["confluence-readers"]
+    permissions = ["read_space"]
+    priority    = 2
+  }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("atlassian_confluence_space.test", "account_id"),
+					resource.TestCheckResourceAttr("atlassian_confluence_space.test", "computed_users.0.name", "writer@example.com"),
+					resource.TestCheckResourceAttr("atlassian_confluence_space.test", "computed_groups.0.name", "confluence-readers"),
+					func(state *terraform.State) error {
+						users, groups := confluence.Permissions("TEST")
+						assert.Equal(t, []string{"create_page", "read_space"}, users[writer.AccountID])
+						assert.Equal(t, []string{"read_space"}, groups[readers.GroupId])
+						return nil
+					},
+				),
+			},
+			{
+				Config: spaceConfig("Renamed", `
+  assignments {
+    users       = ["writer@example.com"]
+    permissions = ["read_space"]
+    priority    = 1
+  }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("atlassian_confluence_space.test", "name", "Renamed"),
+					resource.TestCheckResourceAttr("atlassian_confluence_space.test", "computed_groups.#", "0"),
+					func(state *terraform.State) error {
+						users, groups := confluence.Permissions("TEST")
+						assert.Equal(t, []string{"read_space"}, users[writer.AccountID])
+						assert.NotContains(t, groups, readers.GroupId)
+						return nil
+					},
+				),
+			},
+			{
+				ResourceName:                         "atlassian_confluence_space.test",
+				ImportState:                          true,
+				ImportStateId:                        "TEST",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "key",
+				ImportStateVerifyIgnore: []string{
+					"assignments", "computed_users", "computed_groups", "retain_on_delete", "authoritative_allow_list",
+				},
+			},
+		},
+	})
+}
+
+func TestConfluenceSpaceResource_ImportState(t *testing.T) {
+	ctx := context.Background()
+	receiver := &ConfluenceSpaceResource{}
+
+	var schemaResponse frameworkResource.SchemaResponse
+	receiver.Schema(ctx, frameworkResource.SchemaRequest{}, &schemaResponse)
+
+	response := frameworkResource.ImportStateResponse{
+		State: tfsdk.State{
+			Schema: schemaResponse.Schema,
+			Raw:    tftypes.NewValue(schemaResponse.Schema.Type().TerraformType(ctx), nil),
+		},
+	}
+	receiver.ImportState(ctx, frameworkResource.ImportStateRequest{ID: "TEST"}, &response)
+	assert.False(t, response.Diagnostics.HasError())
+
+	var mergeStrategy types.String
+	assert.False(t, response.State.GetAttribute(ctx, path.Root("merge_strategy"), &mergeStrategy).HasError())
+	assert.Equal(t, confluence.MergeOverride, mergeStrategy.ValueString())
+}
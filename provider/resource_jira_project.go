@@ -3,29 +3,35 @@ package provider
 import (
 	"context"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	jiraApi "github.com/yunarta/terraform-atlassian-api-client/jira"
 	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
 	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/jira"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/lookup"
 	"github.com/yunarta/terraform-provider-commons/util"
 	"regexp"
 )
 
 type ProjectResource struct {
-	client *cloud.JiraClient
-	model  *AtlassianCloudProviderConfig
+	client      *cloud.JiraClient
+	actorLookup *lookup.ActorLookupService
+	model       *AtlassianCloudProviderConfig
 }
 
 var (
 	_ resource.Resource                = &ProjectResource{}
 	_ resource.ResourceWithConfigure   = &ProjectResource{}
 	_ resource.ResourceWithImportState = &ProjectResource{}
+	_ resource.ResourceWithModifyPlan  = &ProjectResource{}
 	_ ConfigurableForJira              = &ProjectResource{}
 	_ ProjectRoleResource              = &ProjectResource{}
 )
@@ -38,9 +44,26 @@ func (receiver *ProjectResource) getClient() *cloud.JiraClient {
 	return receiver.client
 }
 
-func (receiver *ProjectResource) SetConfig(config *AtlassianCloudProviderConfig, client *cloud.JiraClient) {
+func (receiver *ProjectResource) getActorLookup() *lookup.ActorLookupService {
+	return receiver.actorLookup
+}
+
+func (receiver *ProjectResource) getProviderConfig() *AtlassianCloudProviderConfig {
+	return receiver.model
+}
+
+func (receiver *ProjectResource) getProviderAccountId() string {
+	if receiver.model == nil {
+		return ""
+	}
+
+	return receiver.model.jiraAccountId
+}
+
+func (receiver *ProjectResource) SetConfig(config *AtlassianCloudProviderConfig, client *cloud.JiraClient, actorLookup *lookup.ActorLookupService) {
 	receiver.model = config
 	receiver.client = client
+	receiver.actorLookup = actorLookup
 }
 
 func (receiver *ProjectResource) Metadata(ctx context.Context, request resource.MetadataRequest, response *resource.MetadataResponse) {
@@ -96,8 +119,37 @@ func (receiver *ProjectResource) Schema(ctx context.Context, request resource.Sc
 			},
 
 			"assignment_version": schema.StringAttribute{
+				Optional:           true,
+				DeprecationMessage: "Drift of the assignments is detected on plan, changing assignment_version is no longer needed to reapply them.",
+			},
+			"authoritative": schema.BoolAttribute{
 				Optional: true,
 			},
+			"merge_strategy": schema.StringAttribute{
+				Optional: true,
+				Computed: true,
+				Default:  stringdefault.StaticString(jira.MergeOverride),
+				Validators: []validator.String{
+					stringvalidator.OneOf(jira.MergeOverride, jira.MergeUnion),
+				},
+			},
+			"rollback_on_failure": schema.BoolAttribute{
+				Optional: true,
+			},
+			"on_unknown_principal": schema.StringAttribute{
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(unknownPrincipalError, unknownPrincipalWarn, unknownPrincipalIgnore),
+				},
+			},
+			"authoritative_allow_list": schema.ListAttribute{
+				Optional:    true,
+				Computed:    true,
+				ElementType: types.StringType,
+				Default: listdefault.StaticValue(types.ListValueMust(types.StringType, []attr.Value{
+					types.StringValue("atlassian-addons-project-access"),
+				})),
+			},
 			"computed_users":  jira.ComputedAssignmentSchema,
 			"computed_groups": jira.ComputedAssignmentSchema,
 		},
@@ -124,6 +176,10 @@ func (receiver *ProjectResource) Create(ctx context.Context, request resource.Cr
 		return
 	}
 
+	if testReadOnly(&response.Diagnostics, receiver.model, "create", "atlassian_jira_project "+plan.Key.ValueString()) {
+		return
+	}
+
 	project := jiraApi.CreateProject{
 		Key:            plan.Key.ValueString(),
 		Name:           plan.Name.ValueString(),
@@ -148,6 +204,10 @@ func (receiver *ProjectResource) Create(ctx context.Context, request resource.Cr
 
 	computation, diags := CreateProjectRoleAssignments(ctx, receiver, plan)
 	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		// the changes made before the failure are recorded, for the next plan to only show the remaining ones
+		if computation != nil {
+			response.Diagnostics.Append(response.State.Set(ctx, NewProjectModel(plan, createdProject, computation))...)
+		}
 		return
 	}
 
@@ -159,6 +219,54 @@ func (receiver *ProjectResource) Create(ctx context.Context, request resource.Cr
 	}
 }
 
+// ModifyPlan rejects roles that do not exist on the site, and fills computed_users and computed_groups with the
+// roles the planned assignments grant, so that the plan shows who gains or loses access.
+func (receiver *ProjectResource) ModifyPlan(ctx context.Context, request resource.ModifyPlanRequest, response *resource.ModifyPlanResponse) {
+	// nothing to preview on destroy, or before the provider is configured
+	if request.Plan.Raw.IsNull() || receiver.client == nil {
+		return
+	}
+
+	var plan ProjectModel
+	diags := request.Plan.Get(ctx, &plan)
+	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		return
+	}
+
+	var existingKey string
+	var inState ProjectRoleInterface
+	if !request.State.Raw.IsNull() {
+		var state ProjectModel
+		diags = request.State.Get(ctx, &state)
+		if util.TestDiagnostic(&response.Diagnostics, diags) {
+			return
+		}
+
+		existingKey = state.getProjectIdOrKey(ctx)
+		inState = state
+	}
+
+	diags = ValidateProjectRoles(ctx, receiver, plan, existingKey)
+	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		return
+	}
+
+	preview, diags := PreviewProjectRoleAssignments(ctx, receiver, plan, inState)
+	if util.TestDiagnostic(&response.Diagnostics, diags) || preview == nil {
+		return
+	}
+
+	diags = response.Plan.SetAttribute(ctx, path.Root("computed_users"), preview.ComputedUsers)
+	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		return
+	}
+
+	diags = response.Plan.SetAttribute(ctx, path.Root("computed_groups"), preview.ComputedGroups)
+	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		return
+	}
+}
+
 func (receiver *ProjectResource) Read(ctx context.Context, request resource.ReadRequest, response *resource.ReadResponse) {
 	var (
 		diags diag.Diagnostics
@@ -173,6 +281,11 @@ func (receiver *ProjectResource) Read(ctx context.Context, request resource.Read
 		return
 	}
 
+	// state written before merge_strategy existed has none, and is read with the default of the schema
+	if state.MergeStrategy.IsNull() {
+		state.MergeStrategy = types.StringValue(jira.MergeOverride)
+	}
+
 	project, err = receiver.client.ProjectService().Read(state.Key.ValueString())
 	if util.TestError(&response.Diagnostics, err, "failed to remove project") {
 		return
@@ -210,6 +323,10 @@ func (receiver *ProjectResource) Update(ctx context.Context, request resource.Up
 		return
 	}
 
+	if testReadOnly(&response.Diagnostics, receiver.model, "update", "atlassian_jira_project "+state.Key.ValueString()) {
+		return
+	}
+
 	var categoryId = -1
 	if !plan.CategoryId.IsNull() {
 		categoryId = int(plan.CategoryId.ValueInt64())
@@ -227,9 +344,18 @@ func (receiver *ProjectResource) Update(ctx context.Context, request resource.Up
 		return
 	}
 
-	forceUpdate := !plan.AssignmentVersion.Equal(state.AssignmentVersion)
+	// the planned computed assignments differ from the state when the roles read from the site drifted
+	forceUpdate := !plan.AssignmentVersion.Equal(state.AssignmentVersion) ||
+		!plan.ComputedUsers.Equal(state.ComputedUsers) || !plan.ComputedGroups.Equal(state.ComputedGroups)
 	computation, diags = UpdateProjectRoleAssignments(ctx, receiver, plan, state, forceUpdate)
 	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		// the changes made before the failure are recorded, and the assignments stay the ones in state for the next
+		// plan to show the remaining changes
+		if computation != nil {
+			model := NewProjectModel(plan, project, computation)
+			model.Assignments = state.Assignments
+			response.Diagnostics.Append(response.State.Set(ctx, model)...)
+		}
 		return
 	}
 
@@ -254,6 +380,10 @@ func (receiver *ProjectResource) Delete(ctx context.Context, request resource.De
 		return
 	}
 
+	if testReadOnly(&response.Diagnostics, receiver.model, "delete", "atlassian_jira_project "+state.Key.ValueString()) {
+		return
+	}
+
 	if !state.RetainOnDelete.ValueBool() {
 		_, err = receiver.client.ProjectService().Delete(state.Key.ValueString(), state.DeleteToTrash.ValueBool())
 		if util.TestError(&response.Diagnostics, err, "failed to remove project") {
@@ -269,4 +399,10 @@ func (receiver *ProjectResource) ImportState(ctx context.Context, request resour
 	if util.TestDiagnostic(&response.Diagnostics, diags) {
 		return
 	}
+
+	// an imported resource is read with the default strategy, as the site does not know how assignments were merged
+	diags = response.State.SetAttribute(ctx, path.Root("merge_strategy"), jira.MergeOverride)
+	if util.TestDiagnostic(&response.Diagnostics, diags) {
+		return
+	}
 }
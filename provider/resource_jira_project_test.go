@@ -0,0 +1,214 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	frameworkResource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/jira"
+	"github.com/yunarta/terraform-provider-atlassian-cloud/provider/test"
+	"regexp"
+	"testing"
+)
+
+const fakeProviderConfig = `
+provider "atlassian" {
+  endpoint = "https://example.atlassian.net"
+  username = "lead@example.com"
+  token    = "fake"
+}
+`
+
+func TestProjectResource(t *testing.T) {
+	jira := test.NewJiraTransport()
+	lead := jira.AddUser("lead@example.com", "Project Lead")
+	developer := jira.AddUser("developer@example.com", "Developer")
+	developers := jira.AddGroup("jira-developers")
+
+	projectConfig := func(name string, assignments string) string {
+		return fakeProviderConfig + fmt.Sprintf(`
+resource "atlassian_jira_project" "test" {
+  key              = "TEST"
+  name             = %q
+  project_type     = "software"
+  lead_account     = %q
+  default_assignee = "PROJECT_LEAD"
+  retain_on_delete = false
+%s
+}`, name, lead.AccountID, assignments)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: map[string]func() (tfprotov6.ProviderServer, error){
+			"atlassian": providerserver.NewProtocol6WithError(NewFakeTestProvider(jira, nil)()),
+		},
+		CheckDestroy: func(state *terraform.State) error {
+			if jira.Project("TEST") != nil {
+				return fmt.Errorf("project TEST still exists")
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: projectConfig("Test", `
+  assignments {
+    users    = ["developer@example.com"]
+    roles    = ["Administrators"]
+    priority = 1
+  }
+
+  assignments {
+    groups   = ["jira-developers"]
+    roles    = ["Developer"]
+    priority = 2
+  }`),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet("atlassian_jira_project.test", "account_id"),
+					resource.TestCheckResourceAttr("atlassian_jira_project.test", "computed_users.0.name", "developer@example.com"),
+					resource.TestCheckResourceAttr("atlassian_jira_project.test", "computed_users.0.roles.0", "Administrators"),
+					resource.TestCheckResourceAttr("atlassian_jira_project.test", "computed_groups.0.name", "jira-developers"),
+					func(state *terraform.State) error {
+						accountIds, _ := jira.RoleActors("TEST", "Administrators")
+						_, groupIds := jira.RoleActors("TEST", "Developer")
+						assert.Equal(t, []string{developer.AccountID}, accountIds)
+						assert.Equal(t, []string{developers.GroupId}, groupIds)
+						return nil
+					},
+				),
+			},
+			{
+				Config: projectConfig("Renamed", `
+  assignments {
+    users    = ["developer@example.com"]
+    roles    = ["Developer"]
+    priority = 1
+  }`),
+				// the roles granted by the assignments are shown in the plan
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue("atlassian_jira_project.test",
+							tfjsonpath.New("computed_users").AtSliceIndex(0).AtMapKey("roles"),
+							knownvalue.ListExact([]knownvalue.Check{knownvalue.StringExact("Developer")}),
+						),
+						plancheck.ExpectKnownValue("atlassian_jira_project.test",
+							tfjsonpath.New("computed_groups"),
+							knownvalue.ListExact([]knownvalue.Check{}),
+						),
+					},
+				},
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("atlassian_jira_project.test", "name", "Renamed"),
+					resource.TestCheckResourceAttr("atlassian_jira_project.test", "computed_users.0.roles.0", "Developer"),
+					resource.TestCheckResourceAttr("atlassian_jira_project.test", "computed_groups.#", "0"),
+					func(state *terraform.State) error {
+						administrators, _ := jira.RoleActors("TEST", "Administrators")
+						accountIds, groupIds := jira.RoleActors("TEST", "Developer")
+						assert.Empty(t, administrators)
+						assert.Equal(t, []string{developer.AccountID}, accountIds)
+						assert.Empty(t, groupIds)
+						return nil
+					},
+				),
+			},
+			{
+				// a role removed outside Terraform is planned as drift and restored
+				PreConfig: func() {
+					err := cloud.NewJiraClient(jira).ProjectRoleService().RemoveProjectRole("TEST", "10008", []string{developer.AccountID}, nil)
+					assert.Nil(t, err)
+				},
+				Config: projectConfig("Renamed", `
+  assignments {
+    users    = ["developer@example.com"]
+    roles    = ["Developer"]
+    priority = 1
+  }`),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("atlassian_jira_project.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: func(state *terraform.State) error {
+					accountIds, _ := jira.RoleActors("TEST", "Developer")
+					assert.Equal(t, []string{developer.AccountID}, accountIds)
+					return nil
+				},
+			},
+			{
+				ResourceName:                         "atlassian_jira_project.test",
+				ImportState:                          true,
+				ImportStateId:                        "TEST",
+				ImportStateVerify:                    true,
+				ImportStateVerifyIdentifierAttribute: "key",
+				ImportStateVerifyIgnore: []string{
+					"assignments", "computed_users", "computed_groups", "retain_on_delete", "delete_to_trash", "authoritative_allow_list",
+				},
+			},
+		},
+	})
+}
+
+func TestProjectResource_ReadOnly(t *testing.T) {
+	jira := test.NewJiraTransport()
+	lead := jira.AddUser("lead@example.com", "Project Lead")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: map[string]func() (tfprotov6.ProviderServer, error){
+			"atlassian": providerserver.NewProtocol6WithError(NewFakeTestProvider(jira, nil)()),
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "atlassian" {
+  endpoint  = "https://example.atlassian.net"
+  username  = "lead@example.com"
+  token     = "fake"
+  read_only = true
+}
+
+resource "atlassian_jira_project" "test" {
+  key              = "TEST"
+  name             = "Test"
+  project_type     = "software"
+  lead_account     = %q
+  default_assignee = "PROJECT_LEAD"
+}`, lead.AccountID),
+				ExpectError: regexp.MustCompile("Provider is read only"),
+			},
+		},
+	})
+
+	assert.Nil(t, jira.Project("TEST"))
+}
+
+func TestProjectResource_ImportState(t *testing.T) {
+	ctx := context.Background()
+	receiver := &ProjectResource{}
+
+	var schemaResponse frameworkResource.SchemaResponse
+	receiver.Schema(ctx, frameworkResource.SchemaRequest{}, &schemaResponse)
+
+	response := frameworkResource.ImportStateResponse{
+		State: tfsdk.State{
+			Schema: schemaResponse.Schema,
+			Raw:    tftypes.NewValue(schemaResponse.Schema.Type().TerraformType(ctx), nil),
+		},
+	}
+	receiver.ImportState(ctx, frameworkResource.ImportStateRequest{ID: "TEST"}, &response)
+	assert.False(t, response.Diagnostics.HasError())
+
+	var mergeStrategy types.String
+	assert.False(t, response.State.GetAttribute(ctx, path.Root("merge_strategy"), &mergeStrategy).HasError())
+	assert.Equal(t, jira.MergeOverride, mergeStrategy.ValueString())
+}
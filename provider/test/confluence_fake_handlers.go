@@ -0,0 +1,358 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/yunarta/terraform-atlassian-api-client/confluence"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const permissionPageSize = 25
+
+var spaceKeyPattern = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+
+// spaceOperations are the permission slugs that can be granted on a space.
+var spaceOperations = []string{
+	"read_space",
+	"delete_space",
+	"create_page",
+	"delete_page",
+	"archive_page",
+	"create_blogpost",
+	"delete_blogpost",
+	"create_comment",
+	"delete_comment",
+	"create_attachment",
+	"delete_attachment",
+	"restrict_content_space",
+	"export_space",
+	"administer_space",
+}
+
+func (c *ConfluenceTransport) routes() {
+	c.router.HandleFunc("/wiki/rest/api/user/current", c.locked(c.currentUser)).Methods(http.MethodGet)
+	c.router.HandleFunc("/wiki/rest/api/longtask/{taskId}", c.locked(c.readTask)).Methods(http.MethodGet)
+
+	c.router.HandleFunc("/wiki/rest/api/space", c.locked(c.readSpaces)).Methods(http.MethodGet)
+	c.router.HandleFunc("/wiki/rest/api/space", c.locked(c.createSpace)).Methods(http.MethodPost)
+	c.router.HandleFunc("/wiki/rest/api/space/{spaceKey}", c.locked(c.updateSpace)).Methods(http.MethodPut)
+	c.router.HandleFunc("/wiki/rest/api/space/{spaceKey}", c.locked(c.deleteSpace)).Methods(http.MethodDelete)
+
+	c.router.HandleFunc("/wiki/rest/api/space/{spaceKey}/permission", c.locked(c.createPermission)).Methods(http.MethodPost)
+	c.router.HandleFunc("/wiki/rest/api/space/{spaceKey}/permission/{permissionId}", c.locked(c.deletePermission)).Methods(http.MethodDelete)
+	c.router.HandleFunc("/wiki/api/v2/spaces/{spaceId}/permissions", c.locked(c.readPermissions)).Methods(http.MethodGet)
+}
+
+// locked serializes the handlers, the provider looks up users concurrently.
+func (c *ConfluenceTransport) locked(handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		handler(writer, request)
+	}
+}
+
+// writeConfluenceError writes the error document of the Confluence REST API.
+func writeConfluenceError(writer http.ResponseWriter, status int, format string, args ...any) {
+	writeJson(writer, status, map[string]any{
+		"statusCode": status,
+		"message":    fmt.Sprintf(format, args...),
+	})
+}
+
+// currentUser treats the current user of the site as a Confluence administrator.
+func (c *ConfluenceTransport) currentUser(writer http.ResponseWriter, request *http.Request) {
+	user := c.jira.User(c.jira.CurrentUser())
+	if user == nil {
+		writeJson(writer, http.StatusOK, map[string]any{"type": "anonymous"})
+		return
+	}
+
+	writeJson(writer, http.StatusOK, map[string]any{
+		"type":        "known",
+		"accountId":   user.AccountID,
+		"email":       user.EmailAddress,
+		"displayName": user.DisplayName,
+		"operations": []map[string]string{
+			{"operation": "administer", "targetType": "application"},
+		},
+	})
+}
+
+// readTask reports the progress of a space deletion, which completes on the second poll.
+func (c *ConfluenceTransport) readTask(writer http.ResponseWriter, request *http.Request) {
+	taskId := mux.Vars(request)["taskId"]
+	polls, ok := c.tasks[taskId]
+	if !ok {
+		writeConfluenceError(writer, http.StatusNotFound, "No long running task with id %s", taskId)
+		return
+	}
+
+	polls++
+	c.tasks[taskId] = polls
+
+	percentage := min(100, polls*50)
+	writeJson(writer, http.StatusOK, map[string]any{
+		"id":                 taskId,
+		"name":               map[string]string{"key": "com.atlassian.confluence.space.delete"},
+		"percentageComplete": percentage,
+		"finished":           percentage == 100,
+		"successful":         percentage == 100,
+		"messages":           []any{},
+	})
+}
+
+func (c *ConfluenceTransport) readSpaces(writer http.ResponseWriter, request *http.Request) {
+	spaceKeys := request.URL.Query()["spaceKey"]
+
+	spaces := make([]confluence.Space, 0)
+	for _, space := range c.spaces {
+		if len(spaceKeys) == 0 || slices.Contains(spaceKeys, space.space.Key) {
+			spaces = append(spaces, space.space)
+		}
+	}
+	sort.Slice(spaces, func(a, b int) bool {
+		return spaces[a].Key < spaces[b].Key
+	})
+
+	writeJson(writer, http.StatusOK, map[string]any{
+		"results": spaces,
+		"start":   0,
+		"limit":   len(spaces),
+		"size":    len(spaces),
+		"_links":  map[string]string{},
+	})
+}
+
+func (c *ConfluenceTransport) createSpace(writer http.ResponseWriter, request *http.Request) {
+	var create confluence.CreateSpace
+	err := json.NewDecoder(request.Body).Decode(&create)
+	if err != nil {
+		writeConfluenceError(writer, http.StatusBadRequest, "invalid request payload: %s", err)
+		return
+	}
+
+	if !spaceKeyPattern.MatchString(create.Key) || create.Name == "" {
+		writeConfluenceError(writer, http.StatusBadRequest, "A space requires an alphanumeric key and a name")
+		return
+	}
+
+	if _, exists := c.spaces[create.Key]; exists {
+		writeConfluenceError(writer, http.StatusBadRequest, "A space already exists with key %s", create.Key)
+		return
+	}
+
+	space := &fakeSpace{
+		space: confluence.Space{
+			Id:          c.nextId(),
+			Key:         create.Key,
+			Name:        create.Name,
+			Description: create.Description,
+		},
+	}
+	c.spaces[create.Key] = space
+
+	// like Confluence, the creator of a space is granted every permission of it
+	if creator := c.jira.CurrentUser(); creator != "" {
+		for _, operation := range spaceOperations {
+			key, target := splitPermission(operation)
+			_, _ = c.addPermission(space, confluence.PrincipalUser, creator, key, target)
+		}
+	}
+
+	writeJson(writer, http.StatusOK, space.space)
+}
+
+func (c *ConfluenceTransport) updateSpace(writer http.ResponseWriter, request *http.Request) {
+	space := c.findSpace(writer, request)
+	if space == nil {
+		return
+	}
+
+	var update confluence.UpdateSpace
+	err := json.NewDecoder(request.Body).Decode(&update)
+	if err != nil {
+		writeConfluenceError(writer, http.StatusBadRequest, "invalid request payload: %s", err)
+		return
+	}
+
+	if update.Name != "" {
+		space.space.Name = update.Name
+	}
+	if update.Description.Plain.Value != "" {
+		space.space.Description = update.Description
+	}
+
+	writeJson(writer, http.StatusOK, space.space)
+}
+
+// deleteSpace removes the space right away and answers with a long running task, like Confluence does.
+func (c *ConfluenceTransport) deleteSpace(writer http.ResponseWriter, request *http.Request) {
+	space := c.findSpace(writer, request)
+	if space == nil {
+		return
+	}
+
+	delete(c.spaces, space.space.Key)
+
+	taskId := strconv.FormatInt(c.nextId(), 10)
+	c.tasks[taskId] = 0
+
+	writeJson(writer, http.StatusAccepted, map[string]any{
+		"id": taskId,
+		"links": map[string]string{
+			"status": fmt.Sprintf("/wiki/rest/api/longtask/%s", taskId),
+		},
+	})
+}
+
+func (c *ConfluenceTransport) createPermission(writer http.ResponseWriter, request *http.Request) {
+	space := c.findSpace(writer, request)
+	if space == nil {
+		return
+	}
+
+	var add confluence.AddPermission
+	err := json.NewDecoder(request.Body).Decode(&add)
+	if err != nil {
+		writeConfluenceError(writer, http.StatusBadRequest, "invalid request payload: %s", err)
+		return
+	}
+
+	permission, err := c.addPermission(space, add.Subject.Type, add.Subject.Id, add.Operation.Key, add.Operation.Target)
+	if err != nil {
+		writeConfluenceError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	id, _ := strconv.ParseInt(permission.Id, 10, 64)
+	writeJson(writer, http.StatusOK, confluence.Permission{
+		Id:      id,
+		Subject: add.Subject,
+		Operation: confluence.Operation{
+			Key:    add.Operation.Key,
+			Target: add.Operation.Target,
+		},
+	})
+}
+
+func (c *ConfluenceTransport) deletePermission(writer http.ResponseWriter, request *http.Request) {
+	space := c.findSpace(writer, request)
+	if space == nil {
+		return
+	}
+
+	permissionId := mux.Vars(request)["permissionId"]
+	index := slices.IndexFunc(space.permissions, func(permission confluence.PermissionV2) bool {
+		return permission.Id == permissionId
+	})
+	if index < 0 {
+		writeConfluenceError(writer, http.StatusNotFound, "No permission with id %s in space %s", permissionId, space.space.Key)
+		return
+	}
+
+	space.permissions = slices.Delete(space.permissions, index, index+1)
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// readPermissions pages the permissions of a space with a cursor, like the v2 API.
+func (c *ConfluenceTransport) readPermissions(writer http.ResponseWriter, request *http.Request) {
+	spaceId := mux.Vars(request)["spaceId"]
+
+	var space *fakeSpace
+	for _, candidate := range c.spaces {
+		if strconv.FormatInt(candidate.space.Id, 10) == spaceId {
+			space = candidate
+		}
+	}
+	if space == nil {
+		writeConfluenceError(writer, http.StatusNotFound, "No space with id %s", spaceId)
+		return
+	}
+
+	start, _ := strconv.Atoi(request.URL.Query().Get("cursor"))
+	start = min(start, len(space.permissions))
+	end := min(start+permissionPageSize, len(space.permissions))
+
+	links := map[string]string{}
+	if end < len(space.permissions) {
+		links["next"] = fmt.Sprintf("/wiki/api/v2/spaces/%s/permissions?cursor=%d", spaceId, end)
+	}
+
+	writeJson(writer, http.StatusOK, map[string]any{
+		"results": append(make([]confluence.PermissionV2, 0), space.permissions[start:end]...),
+		"_links":  links,
+	})
+}
+
+// addPermission validates the permission like Confluence, every other permission requires read_space first.
+func (c *ConfluenceTransport) addPermission(space *fakeSpace, principalType string, principalId string, key string, target string) (*confluence.PermissionV2, error) {
+	switch principalType {
+	case confluence.PrincipalUser:
+		if c.jira.User(principalId) == nil {
+			return nil, fmt.Errorf("No user with account id %s", principalId)
+		}
+	case confluence.PrincipalGroup:
+		if c.jira.Group(principalId) == nil {
+			return nil, fmt.Errorf("No group with id %s", principalId)
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported subject type %s", principalType)
+	}
+
+	operation := confluence.OperationV2{Key: key, Target: target}
+	if !slices.Contains(spaceOperations, operation.GetSlug()) {
+		return nil, fmt.Errorf("Unsupported operation %s on %s", key, target)
+	}
+
+	if c.hasPermission(space, principalId, operation.GetSlug()) {
+		return nil, fmt.Errorf("Permission to %s %s already exists for %s", key, target, principalId)
+	}
+
+	if operation.GetSlug() != "read_space" && !c.hasPermission(space, principalId, "read_space") {
+		return nil, fmt.Errorf("Permission to read space is required before granting %s %s", key, target)
+	}
+
+	permission := confluence.PermissionV2{
+		Id:        strconv.FormatInt(c.nextId(), 10),
+		Principal: confluence.Principal{Type: principalType, Id: principalId},
+		Operation: operation,
+	}
+	space.permissions = append(space.permissions, permission)
+
+	return &permission, nil
+}
+
+func (c *ConfluenceTransport) hasPermission(space *fakeSpace, principalId string, slug string) bool {
+	return slices.ContainsFunc(space.permissions, func(permission confluence.PermissionV2) bool {
+		return permission.Principal.Id == principalId && permission.Operation.GetSlug() == slug
+	})
+}
+
+func (c *ConfluenceTransport) findSpace(writer http.ResponseWriter, request *http.Request) *fakeSpace {
+	spaceKey := mux.Vars(request)["spaceKey"]
+	space, ok := c.spaces[spaceKey]
+	if !ok {
+		writeConfluenceError(writer, http.StatusNotFound, "No space with key : %s", spaceKey)
+		return nil
+	}
+
+	return space
+}
+
+// splitPermission splits a slug like restrict_content_space into its operation key and target.
+func splitPermission(slug string) (key string, target string) {
+	index := strings.LastIndex(slug, "_")
+	if index < 0 {
+		return slug, ""
+	}
+
+	return slug[:index], slug[index+1:]
+}
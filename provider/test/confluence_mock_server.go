@@ -0,0 +1,164 @@
+package test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"github.com/yunarta/terraform-atlassian-api-client/confluence"
+	atlassianTransport "github.com/yunarta/terraform-provider-atlassian-cloud/provider/transport"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ConfluenceTransport is a stateful in-memory Confluence. It keeps spaces and their permissions, and
+// serves every request outside /wiki from a JiraTransport, as users and groups are shared by the site.
+type ConfluenceTransport struct {
+	router *mux.Router
+	jira   *JiraTransport
+
+	mutex    sync.Mutex
+	spaces   map[string]*fakeSpace
+	tasks    map[string]int
+	sequence int64
+	requests []string
+}
+
+type fakeSpace struct {
+	space       confluence.Space
+	permissions []confluence.PermissionV2
+}
+
+var _ transport.PayloadTransport = &ConfluenceTransport{}
+
+func (c *ConfluenceTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	reply, err := c.Send(request)
+	if err != nil {
+		return reply, err
+	}
+
+	return atlassianTransport.ExpectStatus(reply, expectedStatus...)
+}
+
+// Use adds middlewares in front of the Confluence routes, e.g. to script throttled or failing responses.
+func (c *ConfluenceTransport) Use(middleware ...mux.MiddlewareFunc) {
+	c.router.Use(middleware...)
+}
+
+func (c *ConfluenceTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	if !strings.HasPrefix(request.Url, "/wiki/") {
+		return c.jira.Send(request)
+	}
+
+	var reader io.Reader
+	if request.Payload != nil {
+		reader = bytes.NewReader(request.Payload.ContentMust())
+	}
+
+	muxRequest, err := http.NewRequest(
+		request.Method,
+		request.Url,
+		reader,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	c.requests = append(c.requests, fmt.Sprintf("%s %s", request.Method, request.Url))
+	c.mutex.Unlock()
+
+	muxResponse := httptest.NewRecorder()
+	c.router.ServeHTTP(muxResponse, muxRequest)
+	return atlassianTransport.NewPayloadResponse(muxResponse.Code, muxResponse.Header(), muxResponse.Body.String())
+}
+
+// NewConfluenceTransport creates a fake Confluence without spaces on the same site as jira.
+func NewConfluenceTransport(jira *JiraTransport) *ConfluenceTransport {
+	c := &ConfluenceTransport{
+		router:   mux.NewRouter(),
+		jira:     jira,
+		spaces:   map[string]*fakeSpace{},
+		tasks:    map[string]int{},
+		sequence: 65536,
+	}
+
+	c.routes()
+	return c
+}
+
+func (c *ConfluenceTransport) nextId() int64 {
+	c.sequence++
+	return c.sequence
+}
+
+// Space returns the space by key, or nil when it does not exist.
+func (c *ConfluenceTransport) Space(spaceKey string) *confluence.Space {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	space, ok := c.spaces[spaceKey]
+	if !ok {
+		return nil
+	}
+
+	result := space.space
+	return &result
+}
+
+// AddPermission grants a permission directly, e.g. to simulate a change made outside Terraform.
+// The permission is a slug like read_space, and principalType is either user or group.
+func (c *ConfluenceTransport) AddPermission(spaceKey string, principalType string, principalId string, permission string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	space, ok := c.spaces[spaceKey]
+	if !ok {
+		return fmt.Errorf("no space %s", spaceKey)
+	}
+
+	key, target := splitPermission(permission)
+	_, err := c.addPermission(space, principalType, principalId, key, target)
+	return err
+}
+
+// Permissions returns the permission slugs of every user and group of a space, sorted, by account ID or group ID.
+func (c *ConfluenceTransport) Permissions(spaceKey string) (users map[string][]string, groups map[string][]string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	users, groups = map[string][]string{}, map[string][]string{}
+
+	space, ok := c.spaces[spaceKey]
+	if !ok {
+		return
+	}
+
+	for _, permission := range space.permissions {
+		if permission.Principal.Type == confluence.PrincipalUser {
+			users[permission.Principal.Id] = append(users[permission.Principal.Id], permission.Operation.GetSlug())
+		} else {
+			groups[permission.Principal.Id] = append(groups[permission.Principal.Id], permission.Operation.GetSlug())
+		}
+	}
+
+	for _, slugs := range users {
+		sort.Strings(slugs)
+	}
+	for _, slugs := range groups {
+		sort.Strings(slugs)
+	}
+	return
+}
+
+// Requests returns every request to /wiki received so far as "METHOD url".
+func (c *ConfluenceTransport) Requests() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return append([]string{}, c.requests...)
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"github.com/yunarta/terraform-atlassian-api-client/confluence"
+	"github.com/yunarta/terraform-atlassian-api-client/confluence/cloud"
+	"net/http"
+	"testing"
+)
+
+func TestConfluenceTransport_SpaceLifecycle(t *testing.T) {
+	jiraTransport := NewJiraTransport()
+	creator := jiraTransport.AddUser("creator@example.com", "Space Creator")
+	jiraTransport.SetCurrentUser(creator.AccountID)
+
+	confluenceTransport := NewConfluenceTransport(jiraTransport)
+	var client = cloud.NewConfluenceClient(confluenceTransport)
+
+	created, err := client.SpaceService().Create(confluence.CreateSpace{
+		Key:  "TEST",
+		Name: "Test",
+		Description: confluence.Description{
+			Plain: confluence.ContentValue{Value: "Test space"},
+		},
+	})
+	assert.Nil(t, err)
+	assert.NotZero(t, created.Id)
+
+	users, _ := confluenceTransport.Permissions("TEST")
+	assert.Contains(t, users[creator.AccountID], "administer_space")
+
+	_, err = client.SpaceService().Create(confluence.CreateSpace{Key: "TEST", Name: "Duplicate"})
+	assert.NotNil(t, err)
+
+	updated, err := client.SpaceService().Update("TEST", confluence.UpdateSpace{Name: "Renamed"})
+	assert.Nil(t, err)
+	assert.Equal(t, "Renamed", updated.Name)
+	assert.Equal(t, "Test space", updated.Description.Plain.Value)
+
+	read, err := client.SpaceService().Read("TEST")
+	assert.Nil(t, err)
+	assert.Equal(t, created.Id, read.Id)
+
+	reply, err := confluenceTransport.SendWithExpectedStatus(&transport.PayloadRequest{Method: http.MethodDelete, Url: "/wiki/rest/api/space/TEST"}, 202)
+	assert.Nil(t, err)
+
+	var task struct {
+		Links struct {
+			Status string `json:"status"`
+		} `json:"links"`
+	}
+	assert.Nil(t, reply.Object(&task))
+
+	var progress struct {
+		PercentageComplete int  `json:"percentageComplete"`
+		Finished           bool `json:"finished"`
+	}
+	for _, finished := range []bool{false, true} {
+		reply, err = confluenceTransport.SendWithExpectedStatus(&transport.PayloadRequest{Method: http.MethodGet, Url: task.Links.Status}, 200)
+		assert.Nil(t, err)
+		assert.Nil(t, reply.Object(&progress))
+		assert.Equal(t, finished, progress.Finished)
+	}
+	assert.Equal(t, 100, progress.PercentageComplete)
+
+	read, err = client.SpaceService().Read("TEST")
+	assert.Nil(t, err)
+	assert.Nil(t, read)
+	assert.Nil(t, confluenceTransport.Space("TEST"))
+}
+
+func TestConfluenceTransport_SpacePermissions(t *testing.T) {
+	jiraTransport := NewJiraTransport()
+	writer := jiraTransport.AddUser("writer@example.com", "Writer")
+	readers := jiraTransport.AddGroup("confluence-readers")
+
+	confluenceTransport := NewConfluenceTransport(jiraTransport)
+	var client = cloud.NewConfluenceClient(confluenceTransport)
+
+	_, err := client.SpaceService().Create(confluence.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	_, err = client.SpacePermissionsService().Create("TEST", confluence.AddPermission{
+		Subject:   confluence.Subject{Type: confluence.PrincipalUser, Id: writer.AccountID},
+		Operation: confluence.AddOperation{Key: "create", Target: "page"},
+	})
+	assert.NotNil(t, err, "read_space is required first")
+
+	manager := cloud.NewSpaceRoleManager(client, "TEST")
+	_, err = manager.ReadPermissions()
+	assert.Nil(t, err)
+	assert.Nil(t, manager.UpdateUserPermissions("writer@example.com", []string{"create_page", "read_space"}))
+	assert.Nil(t, manager.UpdateGroupPermissions("confluence-readers", []string{"read_space"}))
+
+	users, groups := confluenceTransport.Permissions("TEST")
+	assert.Equal(t, []string{"create_page", "read_space"}, users[writer.AccountID])
+	assert.Equal(t, []string{"read_space"}, groups[readers.GroupId])
+
+	permissions, err := cloud.NewSpaceRoleManager(client, "TEST").ReadPermissions()
+	assert.Nil(t, err)
+	assert.Equal(t, "writer@example.com", permissions.FindUser(writer.AccountID).Name)
+	assert.Equal(t, "confluence-readers", permissions.FindGroup(readers.GroupId).Name)
+
+	manager = cloud.NewSpaceRoleManager(client, "TEST")
+	_, err = manager.ReadPermissions()
+	assert.Nil(t, err)
+	assert.Nil(t, manager.UpdateUserPermissions("writer@example.com", []string{"read_space"}))
+
+	users, _ = confluenceTransport.Permissions("TEST")
+	assert.Equal(t, []string{"read_space"}, users[writer.AccountID])
+}
+
+func TestConfluenceTransport_PermissionPages(t *testing.T) {
+	jiraTransport := NewJiraTransport()
+	confluenceTransport := NewConfluenceTransport(jiraTransport)
+	var client = cloud.NewConfluenceClient(confluenceTransport)
+
+	space, err := client.SpaceService().Create(confluence.CreateSpace{Key: "TEST", Name: "Test"})
+	assert.Nil(t, err)
+
+	for i := 0; i < permissionPageSize+5; i++ {
+		group := jiraTransport.AddGroup(fmt.Sprintf("group-%d", i))
+		assert.Nil(t, confluenceTransport.AddPermission("TEST", confluence.PrincipalGroup, group.GroupId, "read_space"))
+	}
+
+	permissions, err := client.SpacePermissionsService().Read(space.Id)
+	assert.Nil(t, err)
+	assert.Len(t, *permissions, permissionPageSize+5)
+	assert.Contains(t, confluenceTransport.Requests(), fmt.Sprintf("GET /wiki/api/v2/spaces/%d/permissions?cursor=%d", space.Id, permissionPageSize))
+}
@@ -2,21 +2,57 @@ package test
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/yunarta/terraform-api-transport/transport"
+	"github.com/yunarta/terraform-atlassian-api-client/jira"
+	atlassianTransport "github.com/yunarta/terraform-provider-atlassian-cloud/provider/transport"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"sort"
+	"sync"
 )
 
+// JiraSite is the base URL used in the self links of the fake Jira.
+const JiraSite = "https://example.atlassian.net"
+
+// JiraTransport is a stateful in-memory Jira. It keeps users, groups, projects and the actors of
+// project roles, so that a provider can create, read, update and delete projects without a site.
 type JiraTransport struct {
 	router *mux.Router
+
+	mutex    sync.Mutex
+	users    []jira.User
+	groups   []jira.Group
+	roles    []jira.Role
+	projects map[string]*fakeProject
+	sequence int
+	requests []string
+	// currentUser is the account ID of the caller, see SetCurrentUser
+	currentUser string
+}
+
+type fakeProject struct {
+	project jira.Project
+	// actors by role id
+	actors map[int][]jira.Actor
 }
 
 var _ transport.PayloadTransport = &JiraTransport{}
 
 func (j *JiraTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
-	return j.Send(request)
+	reply, err := j.Send(request)
+	if err != nil {
+		return reply, err
+	}
+
+	return atlassianTransport.ExpectStatus(reply, expectedStatus...)
+}
+
+// Use adds middlewares in front of the routes, e.g. to script throttled or failing responses.
+func (j *JiraTransport) Use(middleware ...mux.MiddlewareFunc) {
+	j.router.Use(middleware...)
 }
 
 func (j *JiraTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
@@ -36,26 +72,250 @@ func (j *JiraTransport) Send(request *transport.PayloadRequest) (*transport.Payl
 		return nil, err
 	}
 
+	j.mutex.Lock()
+	j.requests = append(j.requests, fmt.Sprintf("%s %s", request.Method, request.Url))
+	j.mutex.Unlock()
+
 	muxResponse := httptest.NewRecorder()
 	j.router.ServeHTTP(muxResponse, muxRequest)
-	return &transport.PayloadResponse{
-		StatusCode: muxResponse.Code,
-		Body:       muxResponse.Body.String(),
-	}, nil
+	return atlassianTransport.NewPayloadResponse(muxResponse.Code, muxResponse.Header(), muxResponse.Body.String())
 }
 
+// NewJiraTransport creates a fake Jira with the default project roles and a single user.
 func NewJiraTransport() *JiraTransport {
-	router := mux.NewRouter()
-	router.HandleFunc("/rest/api/latest/user/search", userSearchHandler)
+	j := &JiraTransport{
+		router:   mux.NewRouter(),
+		projects: map[string]*fakeProject{},
+		sequence: 10000,
+		roles: []jira.Role{
+			{ID: 10002, Name: "Administrators", Description: "A project role that represents administrators in a project"},
+			{ID: 10003, Name: "atlassian-addons-project-access", Description: "A project role that represents Connect add-ons declaring project permissions."},
+			{ID: 10008, Name: "Developer"},
+			{ID: 10009, Name: "Member"},
+			{ID: 10010, Name: "Viewer"},
+		},
+	}
+	for i, role := range j.roles {
+		j.roles[i].Self = fmt.Sprintf("%s/rest/api/3/role/%d", JiraSite, role.ID)
+	}
+
+	j.users = append(j.users, jira.User{
+		Self:         fmt.Sprintf("%s/rest/api/3/user?accountId=557058:32b276cf-1a9f-45ae-b3f5-f850bc24f1b9", JiraSite),
+		AccountID:    "557058:32b276cf-1a9f-45ae-b3f5-f850bc24f1b9",
+		AccountType:  "atlassian",
+		EmailAddress: "yunarta.kartawahyudi@gmail.com",
+		DisplayName:  "Yunarta Kartawahyudi",
+		Active:       true,
+		TimeZone:     "Etc/GMT",
+		Locale:       "en_US",
+	})
+
+	j.routes()
+	return j
+}
+
+func (j *JiraTransport) nextId() int {
+	j.sequence++
+	return j.sequence
+}
+
+// AddUser adds an active user and returns it with its generated account ID.
+func (j *JiraTransport) AddUser(emailAddress string, displayName string) jira.User {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	accountId := fmt.Sprintf("5b10ac8d82e05b22cc7d%04x", j.nextId()%0x10000)
+	user := jira.User{
+		Self:         fmt.Sprintf("%s/rest/api/3/user?accountId=%s", JiraSite, accountId),
+		AccountID:    accountId,
+		AccountType:  "atlassian",
+		EmailAddress: emailAddress,
+		DisplayName:  displayName,
+		Active:       true,
+	}
+	j.users = append(j.users, user)
 
-	return &JiraTransport{
-		router: router,
+	return user
+}
+
+// AddAppUser adds the account of a Connect or Forge app, which has no email address.
+func (j *JiraTransport) AddAppUser(displayName string) jira.User {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	accountId := fmt.Sprintf("557058:%08x-0000-4000-8000-000000000000", j.nextId())
+	user := jira.User{
+		Self:        fmt.Sprintf("%s/rest/api/3/user?accountId=%s", JiraSite, accountId),
+		AccountID:   accountId,
+		AccountType: "app",
+		DisplayName: displayName,
+		Active:      true,
+	}
+	j.users = append(j.users, user)
+
+	return user
+}
+
+// SetCurrentUser makes the fake answer as the given user is calling, e.g. for the myself endpoint
+// or to grant the creator of a Confluence space its default permissions.
+func (j *JiraTransport) SetCurrentUser(accountId string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.currentUser = accountId
+}
+
+// CurrentUser returns the account ID set with SetCurrentUser.
+func (j *JiraTransport) CurrentUser() string {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return j.currentUser
+}
+
+// AddGroup adds a group and returns it with its generated group ID.
+func (j *JiraTransport) AddGroup(name string) jira.Group {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	groupId := fmt.Sprintf("%08x-0000-4000-8000-000000000000", j.nextId())
+	group := jira.Group{
+		Self:    fmt.Sprintf("%s/rest/api/3/group?groupId=%s", JiraSite, groupId),
+		GroupId: groupId,
+		Name:    name,
 	}
+	j.groups = append(j.groups, group)
+
+	return group
 }
 
-func userSearchHandler(writer http.ResponseWriter, request *http.Request) {
-	writer.WriteHeader(200)
-	//query := request.URL.Query().Read("query")
+// AddRoleActors assigns users and groups to a project role directly, e.g. to simulate a change made outside Terraform.
+func (j *JiraTransport) AddRoleActors(projectKey string, roleName string, accountIds []string, groupIds []string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	project, ok := j.projects[projectKey]
+	if !ok {
+		return fmt.Errorf("no project %s", projectKey)
+	}
+
+	roleId := j.roleIdByName(roleName)
+	if roleId == 0 {
+		return fmt.Errorf("no role %s", roleName)
+	}
+
+	return j.addActors(project, roleId, accountIds, groupIds)
+}
+
+// Project returns the project by key, or nil when it does not exist.
+func (j *JiraTransport) Project(projectKey string) *jira.Project {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	project, ok := j.projects[projectKey]
+	if !ok {
+		return nil
+	}
+
+	result := j.projectResponse(project)
+	return &result
+}
+
+// RoleActors returns the account IDs and group IDs assigned to a project role, sorted.
+func (j *JiraTransport) RoleActors(projectKey string, roleName string) (accountIds []string, groupIds []string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	accountIds, groupIds = []string{}, []string{}
+
+	project, ok := j.projects[projectKey]
+	if !ok {
+		return
+	}
+
+	for _, actor := range project.actors[j.roleIdByName(roleName)] {
+		if actor.Type == userRoleActor {
+			accountIds = append(accountIds, actor.ActorUser.AccountID)
+		} else {
+			groupIds = append(groupIds, actor.ActorGroup.GroupId)
+		}
+	}
+
+	sort.Strings(accountIds)
+	sort.Strings(groupIds)
+	return
+}
+
+// User returns the user by account ID, or nil when it does not exist.
+func (j *JiraTransport) User(accountId string) *jira.User {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	user := j.findUser(accountId)
+	if user == nil {
+		return nil
+	}
+
+	result := *user
+	return &result
+}
+
+// Group returns the group by group ID, or nil when it does not exist.
+func (j *JiraTransport) Group(groupId string) *jira.Group {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	group := j.findGroup(groupId)
+	if group == nil {
+		return nil
+	}
+
+	result := *group
+	return &result
+}
+
+// ChangeEmail changes the email address of a user, its account ID stays the same.
+func (j *JiraTransport) ChangeEmail(accountId string, emailAddress string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	user := j.findUser(accountId)
+	if user == nil {
+		return fmt.Errorf("no user %s", accountId)
+	}
+
+	user.EmailAddress = emailAddress
+	return nil
+}
+
+// RenameGroup changes the name of a group, its group ID stays the same.
+func (j *JiraTransport) RenameGroup(groupId string, name string) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	group := j.findGroup(groupId)
+	if group == nil {
+		return fmt.Errorf("no group %s", groupId)
+	}
+
+	group.Name = name
+	return nil
+}
+
+// Requests returns every request received so far as "METHOD url".
+func (j *JiraTransport) Requests() []string {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	return append([]string{}, j.requests...)
+}
+
+func (j *JiraTransport) roleIdByName(name string) int {
+	for _, role := range j.roles {
+		if role.Name == name {
+			return role.ID
+		}
+	}
 
-	_, _ = writer.Write([]byte(`[{"self":"https://mobilesolutionworks.atlassian.net/rest/api/3/user?accountId=557058:32b276cf-1a9f-45ae-b3f5-f850bc24f1b9","accountId":"557058:32b276cf-1a9f-45ae-b3f5-f850bc24f1b9","accountType":"atlassian","emailAddress":"yunarta.kartawahyudi@gmail.com","avatarUrls":{"48x48":"https://secure.gravatar.com/avatar/a607ac1755019f3fd32eb16294c81292?d=https%3A%2F%2Favatar-management--avatars.us-west-2.prod.public.atl-paas.net%2Finitials%2FYK-6.png","24x24":"https://secure.gravatar.com/avatar/a607ac1755019f3fd32eb16294c81292?d=https%3A%2F%2Favatar-management--avatars.us-west-2.prod.public.atl-paas.net%2Finitials%2FYK-6.png","16x16":"https://secure.gravatar.com/avatar/a607ac1755019f3fd32eb16294c81292?d=https%3A%2F%2Favatar-management--avatars.us-west-2.prod.public.atl-paas.net%2Finitials%2FYK-6.png","32x32":"https://secure.gravatar.com/avatar/a607ac1755019f3fd32eb16294c81292?d=https%3A%2F%2Favatar-management--avatars.us-west-2.prod.public.atl-paas.net%2Finitials%2FYK-6.png"},"displayName":"Yunarta Kartawahyudi","active":true,"timeZone":"Etc/GMT","locale":"en_US"}]`))
+	return 0
 }
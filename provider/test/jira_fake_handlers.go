@@ -0,0 +1,541 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/yunarta/terraform-atlassian-api-client/jira"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	userRoleActor  = "atlassian-user-role-actor"
+	groupRoleActor = "atlassian-group-role-actor"
+)
+
+func (j *JiraTransport) routes() {
+	j.router.HandleFunc("/rest/api/3/serverInfo", j.locked(j.serverInfo)).Methods(http.MethodGet)
+	j.router.HandleFunc("/rest/api/3/myself", j.locked(j.myself)).Methods(http.MethodGet)
+	j.router.HandleFunc("/rest/api/3/mypermissions", j.locked(j.myPermissions)).Methods(http.MethodGet)
+
+	api := j.router.PathPrefix("/rest/api/latest").Subrouter()
+
+	api.HandleFunc("/user/search", j.locked(j.searchUsers)).Methods(http.MethodGet)
+	api.HandleFunc("/user/bulk", j.locked(j.bulkUsers)).Methods(http.MethodGet)
+	api.HandleFunc("/groups/picker", j.locked(j.pickGroups)).Methods(http.MethodGet)
+	api.HandleFunc("/group/bulk", j.locked(j.bulkGroups)).Methods(http.MethodGet)
+	api.HandleFunc("/role", j.locked(j.readAllRoles)).Methods(http.MethodGet)
+
+	api.HandleFunc("/project/search", j.locked(j.searchProjects)).Methods(http.MethodGet)
+	api.HandleFunc("/project", j.locked(j.createProject)).Methods(http.MethodPost)
+	api.HandleFunc("/project/{projectIdOrKey}", j.locked(j.readProject)).Methods(http.MethodGet)
+	api.HandleFunc("/project/{projectIdOrKey}", j.locked(j.updateProject)).Methods(http.MethodPut)
+	api.HandleFunc("/project/{projectIdOrKey}", j.locked(j.deleteProject)).Methods(http.MethodDelete)
+
+	api.HandleFunc("/project/{projectIdOrKey}/role", j.locked(j.readProjectRoles)).Methods(http.MethodGet)
+	api.HandleFunc("/project/{projectIdOrKey}/role/{roleId}", j.locked(j.readProjectRole)).Methods(http.MethodGet)
+	api.HandleFunc("/project/{projectIdOrKey}/role/{roleId}", j.locked(j.addProjectRoleActors)).Methods(http.MethodPost)
+	api.HandleFunc("/project/{projectIdOrKey}/role/{roleId}", j.locked(j.removeProjectRoleActors)).Methods(http.MethodDelete)
+}
+
+// locked serializes the handlers, the provider looks up users concurrently.
+func (j *JiraTransport) locked(handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		j.mutex.Lock()
+		defer j.mutex.Unlock()
+
+		handler(writer, request)
+	}
+}
+
+func writeJson(writer http.ResponseWriter, status int, body any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(body)
+}
+
+// writeError writes the error document of the Jira REST API.
+func writeError(writer http.ResponseWriter, status int, format string, args ...any) {
+	writeJson(writer, status, map[string]any{
+		"errorMessages": []string{fmt.Sprintf(format, args...)},
+		"errors":        map[string]string{},
+	})
+}
+
+func (j *JiraTransport) serverInfo(writer http.ResponseWriter, request *http.Request) {
+	writeJson(writer, http.StatusOK, map[string]string{
+		"baseUrl":        JiraSite,
+		"deploymentType": "Cloud",
+	})
+}
+
+func (j *JiraTransport) myself(writer http.ResponseWriter, request *http.Request) {
+	user := j.findUser(j.currentUser)
+	if user == nil {
+		writeError(writer, http.StatusUnauthorized, "Client must be authenticated to access this resource.")
+		return
+	}
+
+	writeJson(writer, http.StatusOK, user)
+}
+
+// myPermissions treats the current user as a Jira administrator.
+func (j *JiraTransport) myPermissions(writer http.ResponseWriter, request *http.Request) {
+	permissions := map[string]any{}
+	for _, key := range strings.Split(request.URL.Query().Get("permissions"), ",") {
+		permissions[key] = map[string]any{
+			"key":            key,
+			"havePermission": j.findUser(j.currentUser) != nil,
+		}
+	}
+
+	writeJson(writer, http.StatusOK, map[string]any{"permissions": permissions})
+}
+
+func (j *JiraTransport) searchUsers(writer http.ResponseWriter, request *http.Request) {
+	query := strings.ToLower(request.URL.Query().Get("query"))
+
+	users := make([]jira.User, 0)
+	for _, user := range j.users {
+		if strings.Contains(strings.ToLower(user.EmailAddress), query) ||
+			strings.Contains(strings.ToLower(user.DisplayName), query) {
+			users = append(users, user)
+		}
+	}
+
+	writeJson(writer, http.StatusOK, users)
+}
+
+func (j *JiraTransport) bulkUsers(writer http.ResponseWriter, request *http.Request) {
+	users := make([]jira.User, 0)
+	for _, accountId := range request.URL.Query()["accountId"] {
+		if user := j.findUser(accountId); user != nil {
+			users = append(users, *user)
+		}
+	}
+
+	writeJson(writer, http.StatusOK, map[string]any{
+		"isLast": true,
+		"total":  len(users),
+		"values": users,
+	})
+}
+
+func (j *JiraTransport) pickGroups(writer http.ResponseWriter, request *http.Request) {
+	query := strings.ToLower(request.URL.Query().Get("query"))
+
+	groups := make([]jira.Group, 0)
+	for _, group := range j.groups {
+		if strings.Contains(strings.ToLower(group.Name), query) {
+			groups = append(groups, group)
+		}
+	}
+
+	writeJson(writer, http.StatusOK, map[string]any{
+		"header": fmt.Sprintf("Showing %d of %d matching groups", len(groups), len(groups)),
+		"total":  len(groups),
+		"groups": groups,
+	})
+}
+
+func (j *JiraTransport) bulkGroups(writer http.ResponseWriter, request *http.Request) {
+	groupIds := request.URL.Query()["groupId"]
+	groupNames := request.URL.Query()["groupName"]
+
+	groups := make([]jira.Group, 0)
+	for _, group := range j.groups {
+		if slices.Contains(groupIds, group.GroupId) || slices.Contains(groupNames, group.Name) {
+			groups = append(groups, group)
+		}
+	}
+
+	writeJson(writer, http.StatusOK, map[string]any{
+		"isLast": true,
+		"total":  len(groups),
+		"values": groups,
+	})
+}
+
+func (j *JiraTransport) readAllRoles(writer http.ResponseWriter, request *http.Request) {
+	writeJson(writer, http.StatusOK, j.roles)
+}
+
+func (j *JiraTransport) searchProjects(writer http.ResponseWriter, request *http.Request) {
+	projects := make([]jira.Project, 0, len(j.projects))
+	for _, project := range j.projects {
+		projects = append(projects, j.projectResponse(project))
+	}
+	sort.Slice(projects, func(a, b int) bool {
+		return projects[a].Key < projects[b].Key
+	})
+
+	writeJson(writer, http.StatusOK, map[string]any{
+		"startAt":    0,
+		"maxResults": 50,
+		"total":      len(projects),
+		"isLast":     true,
+		"values":     projects,
+	})
+}
+
+func (j *JiraTransport) createProject(writer http.ResponseWriter, request *http.Request) {
+	var create jira.CreateProject
+	err := json.NewDecoder(request.Body).Decode(&create)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid request payload: %s", err)
+		return
+	}
+
+	if create.Key == "" || create.Name == "" || create.ProjectTypeKey == "" {
+		writeError(writer, http.StatusBadRequest, "key, name and projectTypeKey are required")
+		return
+	}
+
+	if _, exists := j.projects[create.Key]; exists {
+		writeError(writer, http.StatusBadRequest, "Project '%s' uses this project key.", create.Key)
+		return
+	}
+
+	lead := j.findUser(create.LeadAccountId)
+	if lead == nil {
+		writeError(writer, http.StatusBadRequest, "You must specify a valid project lead.")
+		return
+	}
+
+	id := j.nextId()
+	project := &fakeProject{
+		project: jira.Project{
+			Self:           fmt.Sprintf("%s/rest/api/3/project/%d", JiraSite, id),
+			ID:             strconv.Itoa(id),
+			Key:            create.Key,
+			Name:           create.Name,
+			Description:    create.Description,
+			ProjectTypeKey: create.ProjectTypeKey,
+			AssigneeType:   create.AssigneeType,
+			Lead:           *lead,
+		},
+		actors: map[int][]jira.Actor{},
+	}
+	if create.CategoryId > 0 {
+		project.project.ProjectCategory = category(create.CategoryId)
+	}
+	j.projects[create.Key] = project
+
+	writeJson(writer, http.StatusCreated, map[string]any{
+		"self": project.project.Self,
+		"id":   id,
+		"key":  create.Key,
+	})
+}
+
+func (j *JiraTransport) readProject(writer http.ResponseWriter, request *http.Request) {
+	project := j.findProject(writer, request)
+	if project == nil {
+		return
+	}
+
+	writeJson(writer, http.StatusOK, j.projectResponse(project))
+}
+
+func (j *JiraTransport) updateProject(writer http.ResponseWriter, request *http.Request) {
+	project := j.findProject(writer, request)
+	if project == nil {
+		return
+	}
+
+	var update struct {
+		jira.UpdateProject
+		CategoryId *int `json:"categoryId"`
+	}
+	err := json.NewDecoder(request.Body).Decode(&update)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid request payload: %s", err)
+		return
+	}
+
+	if update.LeadAccountId != "" {
+		lead := j.findUser(update.LeadAccountId)
+		if lead == nil {
+			writeError(writer, http.StatusBadRequest, "You must specify a valid project lead.")
+			return
+		}
+		project.project.Lead = *lead
+	}
+	if update.Name != "" {
+		project.project.Name = update.Name
+	}
+	if update.Description != "" {
+		project.project.Description = update.Description
+	}
+	if update.AssigneeType != "" {
+		project.project.AssigneeType = update.AssigneeType
+	}
+	if update.CategoryId != nil {
+		// a negative category removes the category
+		if *update.CategoryId > 0 {
+			project.project.ProjectCategory = category(*update.CategoryId)
+		} else {
+			project.project.ProjectCategory = jira.ProjectCategory{}
+		}
+	}
+
+	writeJson(writer, http.StatusOK, j.projectResponse(project))
+}
+
+func (j *JiraTransport) deleteProject(writer http.ResponseWriter, request *http.Request) {
+	project := j.findProject(writer, request)
+	if project == nil {
+		return
+	}
+
+	delete(j.projects, project.project.Key)
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+func (j *JiraTransport) readProjectRoles(writer http.ResponseWriter, request *http.Request) {
+	project := j.findProject(writer, request)
+	if project == nil {
+		return
+	}
+
+	writeJson(writer, http.StatusOK, j.roleLinks(project))
+}
+
+func (j *JiraTransport) readProjectRole(writer http.ResponseWriter, request *http.Request) {
+	project, role := j.findProjectRole(writer, request)
+	if role == nil {
+		return
+	}
+
+	writeJson(writer, http.StatusOK, j.roleResponse(project, role))
+}
+
+type roleActorsRequest struct {
+	User    []string `json:"user"`
+	GroupId []string `json:"groupId"`
+	Group   []string `json:"group"`
+}
+
+func (j *JiraTransport) addProjectRoleActors(writer http.ResponseWriter, request *http.Request) {
+	project, role := j.findProjectRole(writer, request)
+	if role == nil {
+		return
+	}
+
+	var actors roleActorsRequest
+	err := json.NewDecoder(request.Body).Decode(&actors)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid request payload: %s", err)
+		return
+	}
+
+	groupIds := actors.GroupId
+	for _, name := range actors.Group {
+		group := j.findGroupByName(name)
+		if group == nil {
+			writeError(writer, http.StatusBadRequest, "We couldn't find the group '%s'.", name)
+			return
+		}
+		groupIds = append(groupIds, group.GroupId)
+	}
+
+	err = j.addActors(project, role.ID, actors.User, groupIds)
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJson(writer, http.StatusOK, j.roleResponse(project, role))
+}
+
+func (j *JiraTransport) removeProjectRoleActors(writer http.ResponseWriter, request *http.Request) {
+	project, role := j.findProjectRole(writer, request)
+	if role == nil {
+		return
+	}
+
+	query := request.URL.Query()
+	groupIds := query["groupId"]
+	for _, name := range query["group"] {
+		if group := j.findGroupByName(name); group != nil {
+			groupIds = append(groupIds, group.GroupId)
+		}
+	}
+
+	// Jira removes a single user or group per request
+	if len(query["user"])+len(groupIds) != 1 {
+		writeError(writer, http.StatusBadRequest, "Specify exactly one user or group to remove from the project role.")
+		return
+	}
+
+	// validate every actor first, so that a failed request leaves the role untouched
+	for _, accountId := range query["user"] {
+		if indexOfActor(project.actors[role.ID], userRoleActor, accountId) < 0 {
+			writeError(writer, http.StatusNotFound, "User '%s' is not a member of the project role.", accountId)
+			return
+		}
+	}
+	for _, groupId := range groupIds {
+		if indexOfActor(project.actors[role.ID], groupRoleActor, groupId) < 0 {
+			writeError(writer, http.StatusNotFound, "Group '%s' is not a member of the project role.", groupId)
+			return
+		}
+	}
+
+	project.actors[role.ID] = slices.DeleteFunc(project.actors[role.ID], func(actor jira.Actor) bool {
+		return slices.Contains(query["user"], actor.ActorUser.AccountID) ||
+			slices.Contains(groupIds, actor.ActorGroup.GroupId)
+	})
+
+	writer.WriteHeader(http.StatusNoContent)
+}
+
+// addActors adds all actors or none of them, like Jira rejects a request with an unknown or already assigned actor.
+func (j *JiraTransport) addActors(project *fakeProject, roleId int, accountIds []string, groupIds []string) error {
+	var added []jira.Actor
+	for _, accountId := range accountIds {
+		user := j.findUser(accountId)
+		if user == nil {
+			return fmt.Errorf("We couldn't find the user with account ID '%s'.", accountId)
+		}
+		if indexOfActor(project.actors[roleId], userRoleActor, accountId) >= 0 {
+			return fmt.Errorf("User '%s' is already a member of the project role.", accountId)
+		}
+
+		added = append(added, jira.Actor{
+			ID:          j.nextId(),
+			DisplayName: user.DisplayName,
+			Type:        userRoleActor,
+			ActorUser:   jira.ActorUser{AccountID: accountId},
+		})
+	}
+
+	for _, groupId := range groupIds {
+		group := j.findGroup(groupId)
+		if group == nil {
+			return fmt.Errorf("We couldn't find the group with group ID '%s'.", groupId)
+		}
+		if indexOfActor(project.actors[roleId], groupRoleActor, groupId) >= 0 {
+			return fmt.Errorf("Group '%s' is already a member of the project role.", group.Name)
+		}
+
+		added = append(added, jira.Actor{
+			ID:          j.nextId(),
+			DisplayName: group.Name,
+			Type:        groupRoleActor,
+			ActorGroup:  jira.ActorGroup{GroupId: groupId},
+		})
+	}
+
+	project.actors[roleId] = append(project.actors[roleId], added...)
+	return nil
+}
+
+func indexOfActor(actors []jira.Actor, actorType string, id string) int {
+	return slices.IndexFunc(actors, func(actor jira.Actor) bool {
+		return actor.Type == actorType && (actor.ActorUser.AccountID == id || actor.ActorGroup.GroupId == id)
+	})
+}
+
+func (j *JiraTransport) findProject(writer http.ResponseWriter, request *http.Request) *fakeProject {
+	projectIdOrKey := mux.Vars(request)["projectIdOrKey"]
+	for _, project := range j.projects {
+		if project.project.Key == projectIdOrKey || project.project.ID == projectIdOrKey {
+			return project
+		}
+	}
+
+	writeError(writer, http.StatusNotFound, "No project could be found with key '%s'.", projectIdOrKey)
+	return nil
+}
+
+func (j *JiraTransport) findProjectRole(writer http.ResponseWriter, request *http.Request) (*fakeProject, *jira.Role) {
+	project := j.findProject(writer, request)
+	if project == nil {
+		return nil, nil
+	}
+
+	role := j.findRole(mux.Vars(request)["roleId"])
+	if role == nil {
+		writeError(writer, http.StatusNotFound, "Can not retrieve a role actor for a null project role.")
+		return nil, nil
+	}
+
+	return project, role
+}
+
+func (j *JiraTransport) findRole(roleId string) *jira.Role {
+	for i, role := range j.roles {
+		if strconv.Itoa(role.ID) == roleId {
+			return &j.roles[i]
+		}
+	}
+
+	return nil
+}
+
+func (j *JiraTransport) findUser(accountId string) *jira.User {
+	for i, user := range j.users {
+		if user.AccountID == accountId {
+			return &j.users[i]
+		}
+	}
+
+	return nil
+}
+
+func (j *JiraTransport) findGroup(groupId string) *jira.Group {
+	for i, group := range j.groups {
+		if group.GroupId == groupId {
+			return &j.groups[i]
+		}
+	}
+
+	return nil
+}
+
+func (j *JiraTransport) findGroupByName(name string) *jira.Group {
+	for i, group := range j.groups {
+		if group.Name == name {
+			return &j.groups[i]
+		}
+	}
+
+	return nil
+}
+
+func (j *JiraTransport) roleLinks(project *fakeProject) map[string]string {
+	links := map[string]string{}
+	for _, role := range j.roles {
+		links[role.Name] = fmt.Sprintf("%s/rest/api/3/project/%s/role/%d", JiraSite, project.project.ID, role.ID)
+	}
+
+	return links
+}
+
+func (j *JiraTransport) projectResponse(project *fakeProject) jira.Project {
+	response := project.project
+	response.Roles = j.roleLinks(project)
+
+	return response
+}
+
+func (j *JiraTransport) roleResponse(project *fakeProject, role *jira.Role) jira.Role {
+	response := *role
+	response.Self = fmt.Sprintf("%s/rest/api/3/project/%s/role/%d", JiraSite, project.project.ID, role.ID)
+	response.Actors = append(make([]jira.Actor, 0), project.actors[role.ID]...)
+
+	return response
+}
+
+func category(id int) jira.ProjectCategory {
+	return jira.ProjectCategory{
+		Self: fmt.Sprintf("%s/rest/api/3/projectCategory/%d", JiraSite, id),
+		ID:   strconv.Itoa(id),
+		Name: fmt.Sprintf("Category %d", id),
+	}
+}
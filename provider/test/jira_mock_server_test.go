@@ -2,6 +2,7 @@ package test
 
 import (
 	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-atlassian-api-client/jira"
 	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
 	"testing"
 )
@@ -15,3 +16,129 @@ func TestUserSearchHandler(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, success)
 }
+
+func TestJiraTransport_ProjectLifecycle(t *testing.T) {
+	jiraTransport := NewJiraTransport()
+	lead := jiraTransport.AddUser("lead@example.com", "Project Lead")
+
+	var client = cloud.NewJiraClient(jiraTransport)
+
+	created, err := client.ProjectService().Create(jira.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+		AssigneeType:   "PROJECT_LEAD",
+		CategoryId:     10000,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "TEST", created.Key)
+	assert.Equal(t, lead.AccountID, created.Lead.AccountID)
+	assert.Equal(t, "10000", created.ProjectCategory.ID)
+	assert.Contains(t, created.Roles, "Administrators")
+
+	_, err = client.ProjectService().Create(jira.CreateProject{
+		Key:            "TEST",
+		Name:           "Duplicate",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.NotNil(t, err)
+
+	updated, err := client.ProjectService().Update("TEST", jira.UpdateProject{
+		Name:       "Renamed",
+		CategoryId: -1,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Renamed", updated.Name)
+	assert.Empty(t, updated.ProjectCategory.ID)
+
+	read, err := client.ProjectService().Read(created.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, "Renamed", read.Name)
+
+	projects, err := client.ProjectService().ReadAll()
+	assert.Nil(t, err)
+	assert.Len(t, projects, 1)
+
+	_, err = client.ProjectService().Delete("TEST", false)
+	assert.Nil(t, err)
+	assert.Nil(t, jiraTransport.Project("TEST"))
+
+	_, err = client.ProjectService().Read("TEST")
+	assert.NotNil(t, err)
+}
+
+func TestJiraTransport_ProjectRoleActors(t *testing.T) {
+	jiraTransport := NewJiraTransport()
+	lead := jiraTransport.AddUser("lead@example.com", "Project Lead")
+	developer := jiraTransport.AddUser("developer@example.com", "Developer")
+	group := jiraTransport.AddGroup("jira-developers")
+
+	var client = cloud.NewJiraClient(jiraTransport)
+	_, err := client.ProjectService().Create(jira.CreateProject{
+		Key:            "TEST",
+		Name:           "Test",
+		ProjectTypeKey: "software",
+		LeadAccountId:  lead.AccountID,
+	})
+	assert.Nil(t, err)
+
+	manager := cloud.NewProjectRoleManager(client, "TEST")
+	_, err = manager.ReadRoles([]string{"Administrators", "Developer"})
+	assert.Nil(t, err)
+	assert.Nil(t, manager.UpdateUserRoles("developer@example.com", []string{"Administrators", "Developer"}))
+	assert.Nil(t, manager.UpdateGroupRoles("jira-developers", []string{"Developer"}))
+	manager.Finalized()
+
+	accountIds, groupIds := jiraTransport.RoleActors("TEST", "Developer")
+	assert.Equal(t, []string{developer.AccountID}, accountIds)
+	assert.Equal(t, []string{group.GroupId}, groupIds)
+
+	roles, err := cloud.NewProjectRoleManager(client, "TEST").ReadRoles([]string{"Administrators", "Developer"})
+	assert.Nil(t, err)
+	assert.Equal(t, "developer@example.com", roles.FindUser(developer.AccountID).Name)
+	assert.ElementsMatch(t, []string{"Administrators", "Developer"}, roles.FindUser(developer.AccountID).Roles)
+	assert.Equal(t, "jira-developers", roles.FindGroup(group.GroupId).Name)
+
+	err = client.ProjectRoleService().AddProjectRole("TEST", "10008", []string{developer.AccountID}, nil)
+	assert.NotNil(t, err, "an assigned actor is rejected")
+
+	err = client.ProjectRoleService().RemoveProjectRole("TEST", "10008", []string{developer.AccountID}, []string{group.GroupId})
+	assert.NotNil(t, err, "a single actor is removed per request")
+
+	err = client.ProjectRoleService().RemoveProjectRole("TEST", "10008", []string{developer.AccountID}, nil)
+	assert.Nil(t, err)
+	err = client.ProjectRoleService().RemoveProjectRole("TEST", "10008", nil, []string{group.GroupId})
+	assert.Nil(t, err)
+
+	accountIds, groupIds = jiraTransport.RoleActors("TEST", "Developer")
+	assert.Empty(t, accountIds)
+	assert.Empty(t, groupIds)
+}
+
+func TestJiraTransport_Lookup(t *testing.T) {
+	jiraTransport := NewJiraTransport()
+	user := jiraTransport.AddUser("jane.doe@example.com", "Jane Doe")
+	group := jiraTransport.AddGroup("jira-administrators")
+
+	var client = cloud.NewJiraClient(jiraTransport)
+
+	users, err := client.ActorService().BulkGetUsers([]string{user.AccountID, "unknown"})
+	assert.Nil(t, err)
+	assert.Equal(t, []jira.User{user}, users)
+
+	found, err := client.ActorService().ReadGroup("jira-administrators")
+	assert.Nil(t, err)
+	assert.Equal(t, group.GroupId, found.GroupId)
+
+	groups, err := client.ActorService().BulkGetGroupsByName([]string{"jira-administrators"})
+	assert.Nil(t, err)
+	assert.Equal(t, []jira.Group{group}, groups)
+
+	groups, err = client.ActorService().BulkGetGroupsById([]string{group.GroupId})
+	assert.Nil(t, err)
+	assert.Equal(t, []jira.Group{group}, groups)
+
+	assert.Contains(t, jiraTransport.Requests(), "GET /rest/api/latest/groups/picker?query=jira-administrators")
+}
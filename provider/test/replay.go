@@ -0,0 +1,167 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/yunarta/terraform-api-transport/transport"
+	atlassianTransport "github.com/yunarta/terraform-provider-atlassian-cloud/provider/transport"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// ReplayMode selects how a ReplayTransport picks the cassette for a request.
+type ReplayMode int
+
+const (
+	// ReplayByRequest serves the first unplayed cassette with the same method and URL, and serves the
+	// last matching cassette again once all of them are played, as Terraform may refresh more often.
+	ReplayByRequest ReplayMode = iota
+	// ReplayInOrder expects the requests in exactly the recorded order, and every cassette to be played.
+	ReplayInOrder
+)
+
+// ReplayTransport serves cassettes written by the cassette recording mode of the provider.
+// A request without a matching cassette fails the test.
+type ReplayTransport struct {
+	t    testing.TB
+	mode ReplayMode
+
+	mutex     sync.Mutex
+	cassettes []atlassianTransport.Cassette
+	played    []bool
+	next      int
+}
+
+var _ transport.PayloadTransport = &ReplayTransport{}
+
+// NewReplayTransport loads the cassettes of a directory, sorted by their sequence number.
+func NewReplayTransport(t testing.TB, directory string, mode ReplayMode) *ReplayTransport {
+	t.Helper()
+
+	cassettes, err := ReadCassettes(directory)
+	if err != nil {
+		t.Fatalf("failed to read cassettes from %s: %s", directory, err)
+	}
+
+	replay := &ReplayTransport{
+		t:         t,
+		mode:      mode,
+		cassettes: cassettes,
+		played:    make([]bool, len(cassettes)),
+	}
+
+	if mode == ReplayInOrder {
+		t.Cleanup(func() {
+			replay.mutex.Lock()
+			defer replay.mutex.Unlock()
+
+			if replay.next < len(replay.cassettes) {
+				t.Errorf("%d of %d cassettes were not played", len(replay.cassettes)-replay.next, len(replay.cassettes))
+			}
+		})
+	}
+
+	return replay
+}
+
+// ReadCassettes reads all cassettes of a directory in the order of the sequence number in their file name.
+func ReadCassettes(directory string) ([]atlassianTransport.Cassette, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Slice(names, func(a, b int) bool {
+		return sequence(names[a]) < sequence(names[b])
+	})
+
+	cassettes := make([]atlassianTransport.Cassette, 0, len(names))
+	for _, name := range names {
+		content, err := os.ReadFile(filepath.Join(directory, name))
+		if err != nil {
+			return nil, err
+		}
+
+		var cassette atlassianTransport.Cassette
+		err = json.Unmarshal(content, &cassette)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		cassettes = append(cassettes, cassette)
+	}
+
+	return cassettes, nil
+}
+
+func sequence(name string) int {
+	number, _, _ := strings.Cut(name, "-")
+	value, _ := strconv.Atoi(number)
+	return value
+}
+
+func (r *ReplayTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cassette := r.find(request)
+	if cassette == nil {
+		r.t.Errorf("unexpected request %s %s", request.Method, request.Url)
+		return atlassianTransport.NewPayloadResponse(http.StatusNotImplemented, http.Header{},
+			fmt.Sprintf("no cassette for %s %s", request.Method, request.Url))
+	}
+
+	return atlassianTransport.NewPayloadResponse(cassette.StatusCode, http.Header{},
+		atlassianTransport.CassetteBodyString(cassette.ResponseBody))
+}
+
+func (r *ReplayTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	reply, err := r.Send(request)
+	if err != nil {
+		return reply, err
+	}
+
+	return atlassianTransport.ExpectStatus(reply, expectedStatus...)
+}
+
+func (r *ReplayTransport) find(request *transport.PayloadRequest) *atlassianTransport.Cassette {
+	matches := func(cassette atlassianTransport.Cassette) bool {
+		return cassette.Method == request.Method && cassette.Url == request.Url
+	}
+
+	if r.mode == ReplayInOrder {
+		if r.next >= len(r.cassettes) || !matches(r.cassettes[r.next]) {
+			return nil
+		}
+
+		r.next++
+		return &r.cassettes[r.next-1]
+	}
+
+	var last *atlassianTransport.Cassette
+	for i, cassette := range r.cassettes {
+		if !matches(cassette) {
+			continue
+		}
+
+		if !r.played[i] {
+			r.played[i] = true
+			return &r.cassettes[i]
+		}
+		last = &r.cassettes[i]
+	}
+
+	return last
+}
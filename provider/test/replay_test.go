@@ -0,0 +1,62 @@
+package test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	"testing"
+)
+
+// recordingTB captures the failures reported by the replay transport.
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, format)
+}
+
+func TestReplayTransport_ByRequest(t *testing.T) {
+	client := cloud.NewJiraClient(NewReplayTransport(t, "collect", ReplayByRequest))
+
+	for i := 0; i < 3; i++ {
+		user, err := client.ActorService().ReadUser("user-1@example.com")
+		assert.Nil(t, err)
+		assert.Equal(t, "account-1", user.AccountID)
+	}
+
+	project, err := client.ProjectService().Read("TPXA")
+	assert.Nil(t, err)
+	assert.Equal(t, "TPXA", project.Key)
+}
+
+func TestReplayTransport_InOrder(t *testing.T) {
+	client := cloud.NewJiraClient(NewReplayTransport(t, "collect", ReplayInOrder))
+
+	for i := 0; i < 2; i++ {
+		_, err := client.ActorService().ReadUser("user-1@example.com")
+		assert.Nil(t, err)
+
+		_, err = client.ProjectService().Read("TPXA")
+		assert.Nil(t, err)
+
+		_, err = client.ProjectRoleService().ReadProjectRoles("TPXA")
+		assert.Nil(t, err)
+	}
+
+	_, err := client.ProjectService().Read("TPXA")
+	assert.Nil(t, err)
+	_, err = client.ProjectRoleService().ReadProjectRoles("TPXA")
+	assert.Nil(t, err)
+	_, err = client.ProjectRoleService().ReadProjectRoleActors("TPXA", "10002")
+	assert.Nil(t, err)
+}
+
+func TestReplayTransport_UnexpectedRequest(t *testing.T) {
+	recorder := &recordingTB{TB: t}
+	client := cloud.NewJiraClient(NewReplayTransport(recorder, "collect", ReplayInOrder))
+
+	_, err := client.ProjectService().Read("TPXA")
+	assert.NotNil(t, err)
+	assert.Len(t, recorder.errors, 1)
+}
@@ -0,0 +1,169 @@
+package test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-atlassian-api-client/jira"
+	"github.com/yunarta/terraform-atlassian-api-client/jira/cloud"
+	atlassianTransport "github.com/yunarta/terraform-provider-atlassian-cloud/provider/transport"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// throttle answers the first count requests with 429 and the given Retry-After header.
+func throttle(count int, retryAfter string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if count > 0 {
+				count--
+				writer.Header().Set("Retry-After", retryAfter)
+				writer.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+// fail answers the first count requests with the given status.
+func fail(count int, status int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			if count > 0 {
+				count--
+				writer.WriteHeader(status)
+				return
+			}
+
+			next.ServeHTTP(writer, request)
+		})
+	}
+}
+
+func TestRetryPayloadTransport_RetriesThrottledRequest(t *testing.T) {
+	jiraTransport := NewJiraTransport()
+	jiraTransport.Use(throttle(2, "1"))
+
+	var client = cloud.NewJiraClient(&atlassianTransport.RetryPayloadTransport{
+		Transport:  jiraTransport,
+		MaxRetries: 3,
+		MaxWait:    10 * time.Millisecond,
+	})
+
+	user, err := client.ActorService().ReadUser("yunarta.kartawahyudi@gmail.com")
+	assert.Nil(t, err)
+	assert.NotNil(t, user)
+}
+
+func TestRetryPayloadTransport_GivesUpAfterMaxRetries(t *testing.T) {
+	jiraTransport := NewJiraTransport()
+	jiraTransport.Use(throttle(3, "1"))
+
+	var client = cloud.NewJiraClient(&atlassianTransport.RetryPayloadTransport{
+		Transport:  jiraTransport,
+		MaxRetries: 2,
+		MaxWait:    10 * time.Millisecond,
+	})
+
+	_, err := client.ActorService().ReadUser("yunarta.kartawahyudi@gmail.com")
+	var responseError atlassianTransport.ResponseError
+	assert.ErrorAs(t, err, &responseError)
+	assert.Equal(t, http.StatusTooManyRequests, responseError.StatusCode)
+	assert.Equal(t, time.Second, responseError.RetryAfter)
+}
+
+func TestRetryPayloadTransport_Post(t *testing.T) {
+	createProject := func(client *cloud.JiraClient, lead jira.User, key string) error {
+		_, err := client.ProjectService().Create(jira.CreateProject{
+			Key:            key,
+			Name:           key,
+			ProjectTypeKey: "software",
+			LeadAccountId:  lead.AccountID,
+		})
+		return err
+	}
+	posts := func(jiraTransport *JiraTransport) (count int) {
+		for _, request := range jiraTransport.Requests() {
+			if strings.HasPrefix(request, "POST ") {
+				count++
+			}
+		}
+		return
+	}
+
+	// a throttled POST with Retry-After was refused before being processed
+	jiraTransport := NewJiraTransport()
+	lead := jiraTransport.AddUser("lead@example.com", "Project Lead")
+	jiraTransport.Use(throttle(1, "1"))
+	client := cloud.NewJiraClient(&atlassianTransport.RetryPayloadTransport{
+		Transport:  jiraTransport,
+		MaxRetries: 3,
+		MaxWait:    10 * time.Millisecond,
+	})
+	assert.Nil(t, createProject(client, lead, "THROTTLED"))
+	assert.Equal(t, 2, posts(jiraTransport))
+
+	// a POST that failed on the server may have been processed, it is not sent again
+	jiraTransport = NewJiraTransport()
+	lead = jiraTransport.AddUser("lead@example.com", "Project Lead")
+	jiraTransport.Use(fail(1, http.StatusBadGateway))
+	client = cloud.NewJiraClient(&atlassianTransport.RetryPayloadTransport{
+		Transport:  jiraTransport,
+		MaxRetries: 3,
+		MaxWait:    10 * time.Millisecond,
+	})
+	assert.NotNil(t, createProject(client, lead, "FAILED"))
+	assert.Equal(t, 1, posts(jiraTransport))
+
+	// neither is a throttled POST without Retry-After
+	jiraTransport = NewJiraTransport()
+	lead = jiraTransport.AddUser("lead@example.com", "Project Lead")
+	jiraTransport.Use(fail(1, http.StatusTooManyRequests))
+	client = cloud.NewJiraClient(&atlassianTransport.RetryPayloadTransport{
+		Transport:  jiraTransport,
+		MaxRetries: 3,
+		MaxWait:    10 * time.Millisecond,
+	})
+	assert.NotNil(t, createProject(client, lead, "UNKNOWN"))
+	assert.Equal(t, 1, posts(jiraTransport))
+}
+
+func TestRetryPayloadTransport_RetriesServerError(t *testing.T) {
+	jiraTransport := NewJiraTransport()
+	jiraTransport.Use(fail(2, http.StatusServiceUnavailable))
+
+	var client = cloud.NewJiraClient(&atlassianTransport.RetryPayloadTransport{
+		Transport:  &atlassianTransport.StatusPayloadTransport{Transport: jiraTransport},
+		MaxRetries: 3,
+		MaxWait:    10 * time.Millisecond,
+	})
+
+	user, err := client.ActorService().ReadUser("yunarta.kartawahyudi@gmail.com")
+	assert.Nil(t, err)
+	assert.NotNil(t, user)
+	assert.Len(t, jiraTransport.Requests(), 3)
+}
+
+func TestRetryPayloadTransport_Cancelled(t *testing.T) {
+	jiraTransport := NewJiraTransport()
+	jiraTransport.Use(throttle(1, "60"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var client = cloud.NewJiraClient(&atlassianTransport.RetryPayloadTransport{
+		Transport:  jiraTransport,
+		MaxRetries: 3,
+		MaxWait:    time.Minute,
+		Context:    ctx,
+	})
+
+	start := time.Now()
+	_, err := client.ActorService().ReadUser("yunarta.kartawahyudi@gmail.com")
+	assert.NotNil(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Len(t, jiraTransport.Requests(), 1)
+}
@@ -0,0 +1,240 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CassetteDirectoryEnv enables the cassette recording when the provider does not set cassette_directory.
+const CassetteDirectoryEnv = "ATLASSIAN_CASSETTE_DIR"
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._+-]+(@|%40)[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+
+	// accountIdPattern matches both the 557058:{uuid} and the 24 digit hex format of Atlassian account IDs
+	accountIdPattern = regexp.MustCompile(`\b([0-9]{6}(:|%3A)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[0-9a-f]{24})\b`)
+
+	// displayNamePattern matches the display name of a user, usually the full name of the person
+	displayNamePattern = regexp.MustCompile(`("displayName"\s*:\s*)"([^"]*)"`)
+
+	// avatarPattern matches gravatar URLs, of which the hash is derived from the email and the default image shows
+	// the initials of the user
+	avatarPattern = regexp.MustCompile(`https://secure\.gravatar\.com/avatar/[0-9a-f]{32}[^"\s]*`)
+
+	// sitePattern matches the address of the site, which names the organization
+	sitePattern = regexp.MustCompile(`https://[A-Za-z0-9-]+\.atlassian\.net`)
+)
+
+// placeholderSite replaces the address of the recorded site.
+const placeholderSite = "https://example.atlassian.net"
+
+// Cassette is a single recorded exchange.
+type Cassette struct {
+	Method       string          `json:"method"`
+	Url          string          `json:"url"`
+	RequestBody  json.RawMessage `json:"request_body,omitempty"`
+	StatusCode   int             `json:"status_code"`
+	ResponseBody json.RawMessage `json:"response_body,omitempty"`
+}
+
+// NewCassetteBody keeps a JSON body as is so that cassettes stay readable, any other body is stored as a JSON string.
+func NewCassetteBody(body string) json.RawMessage {
+	if body == "" {
+		return nil
+	}
+
+	if json.Valid([]byte(body)) && !strings.HasPrefix(strings.TrimSpace(body), `"`) {
+		return json.RawMessage(body)
+	}
+
+	content, _ := json.Marshal(body)
+	return content
+}
+
+// CassetteBodyString returns the body as it was sent or received.
+func CassetteBodyString(body json.RawMessage) string {
+	var text string
+	if strings.HasPrefix(string(body), `"`) && json.Unmarshal(body, &text) == nil {
+		return text
+	}
+
+	return string(body)
+}
+
+// CassetteRecorder writes exchanges into numbered files of a directory, numbered after the cassettes already in it
+// so that another run adds to them instead of overwriting them. Emails, account IDs, display names and avatars are
+// replaced with stable placeholders, so that a value keeps its placeholder across all cassettes of one recording,
+// the site with example.atlassian.net, and tokens are masked.
+type CassetteRecorder struct {
+	Directory string
+	Secrets   []string
+
+	mutex      sync.Mutex
+	count      int
+	started    bool
+	pseudonyms map[string]map[string]string
+}
+
+func NewCassetteRecorder(directory string, secrets ...string) *CassetteRecorder {
+	return &CassetteRecorder{
+		Directory:  directory,
+		Secrets:    secrets,
+		pseudonyms: map[string]map[string]string{},
+	}
+}
+
+// Record writes the exchange into the next numbered cassette.
+func (r *CassetteRecorder) Record(request *transport.PayloadRequest, reply *transport.PayloadResponse) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cassette := Cassette{
+		Method:      request.Method,
+		Url:         r.redact(request.Url),
+		RequestBody: NewCassetteBody(r.redact(requestBody(request))),
+	}
+	if reply != nil {
+		cassette.StatusCode = reply.StatusCode
+		cassette.ResponseBody = NewCassetteBody(r.redact(reply.Body))
+	}
+
+	content, err := json.MarshalIndent(cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(r.Directory, 0755)
+	if err != nil {
+		return err
+	}
+
+	if !r.started {
+		r.count, err = nextSequence(r.Directory)
+		if err != nil {
+			return err
+		}
+		r.started = true
+	}
+
+	name := filepath.Join(r.Directory, fmt.Sprintf("%d-%s.json", r.count, CassetteName(cassette.Url)))
+	r.count++
+
+	return os.WriteFile(name, content, 0644)
+}
+
+// nextSequence returns the number following the highest cassette number in the directory.
+func nextSequence(directory string) (int, error) {
+	entries, err := os.ReadDir(directory)
+	if err != nil {
+		return 0, err
+	}
+
+	next := 0
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "-")
+		if number, err := strconv.Atoi(prefix); err == nil && number >= next {
+			next = number + 1
+		}
+	}
+
+	return next, nil
+}
+
+// CassetteName converts a request URL into a file name.
+func CassetteName(url string) string {
+	return strings.NewReplacer("/", "-", "?", "-", "&", "-", "=", "-", ":", "-", "%", "-").Replace(url)
+}
+
+func (r *CassetteRecorder) redact(content string) string {
+	for _, secret := range r.Secrets {
+		if secret != "" {
+			content = strings.ReplaceAll(content, secret, redacted)
+		}
+	}
+
+	content = RedactBody(content)
+	content = sitePattern.ReplaceAllString(content, placeholderSite)
+	content = avatarPattern.ReplaceAllStringFunc(content, func(avatar string) string {
+		return r.pseudonym(avatar, "https://avatar.example.com/avatar-%d.png")
+	})
+	content = displayNamePattern.ReplaceAllStringFunc(content, func(field string) string {
+		match := displayNamePattern.FindStringSubmatch(field)
+		// a user hiding its name shows its email instead, which gets the placeholder of the email
+		if emailPattern.MatchString(match[2]) {
+			return field
+		}
+
+		return match[1] + `"` + r.pseudonym(match[2], "User %d") + `"`
+	})
+	content = emailPattern.ReplaceAllStringFunc(content, func(email string) string {
+		separator := emailPattern.FindStringSubmatch(email)[1]
+		return strings.Replace(r.pseudonym(strings.Replace(email, separator, "@", 1), "user-%d@example.com"), "@", separator, 1)
+	})
+	content = accountIdPattern.ReplaceAllStringFunc(content, func(accountId string) string {
+		return r.pseudonym(strings.Replace(accountId, "%3A", ":", 1), "account-%d")
+	})
+
+	return content
+}
+
+// pseudonym numbers the distinct values of each format in the order they are first seen.
+func (r *CassetteRecorder) pseudonym(value string, format string) string {
+	pseudonyms, ok := r.pseudonyms[format]
+	if !ok {
+		pseudonyms = map[string]string{}
+		r.pseudonyms[format] = pseudonyms
+	}
+
+	pseudonym, ok := pseudonyms[value]
+	if !ok {
+		pseudonym = fmt.Sprintf(format, len(pseudonyms)+1)
+		pseudonyms[value] = pseudonym
+	}
+
+	return pseudonym
+}
+
+// CassettePayloadTransport records every exchange with a CassetteRecorder. A cassette that cannot be written is
+// logged to the tflog subsystem, the exchange itself still succeeds.
+type CassettePayloadTransport struct {
+	Transport transport.PayloadTransport
+	Recorder  *CassetteRecorder
+	Context   context.Context
+	Subsystem string
+}
+
+var _ transport.PayloadTransport = &CassettePayloadTransport{}
+
+func (c *CassettePayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	reply, err := c.Transport.Send(request)
+	c.record(request, reply)
+
+	return reply, err
+}
+
+func (c *CassettePayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	reply, err := c.Transport.SendWithExpectedStatus(request, expectedStatus...)
+	c.record(request, reply)
+
+	return reply, err
+}
+
+func (c *CassettePayloadTransport) record(request *transport.PayloadRequest, reply *transport.PayloadResponse) {
+	err := c.Recorder.Record(request, reply)
+	if err != nil && c.Context != nil {
+		tflog.SubsystemWarn(c.Context, c.Subsystem, "Failed to record cassette", map[string]interface{}{
+			"method": request.Method,
+			"path":   request.Url,
+			"error":  err.Error(),
+		})
+	}
+}
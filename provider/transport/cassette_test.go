@@ -0,0 +1,125 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type cannedPayloadTransport struct {
+	body string
+}
+
+func (c *cannedPayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	return &transport.PayloadResponse{StatusCode: 200, Body: c.body}, nil
+}
+
+func (c *cannedPayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	return c.Send(request)
+}
+
+func TestCassettePayloadTransport(t *testing.T) {
+	directory := filepath.Join(t.TempDir(), "collect")
+	recorder := NewCassetteRecorder(directory, "api-token")
+
+	_, _ = (&CassettePayloadTransport{
+		Transport: &cannedPayloadTransport{
+			body: `[{"self":"https://acme.atlassian.net/rest/api/3/user?accountId=557058:32b276cf-1a9f-45ae-b3f5-f850bc24f1b9",` +
+				`"accountId":"557058:32b276cf-1a9f-45ae-b3f5-f850bc24f1b9","emailAddress":"jane.doe@example.org",` +
+				`"avatarUrls":{"48x48":"https://secure.gravatar.com/avatar/a607ac1755019f3fd32eb16294c81292?d=initials%2FJD-6.png"},` +
+				`"displayName":"Jane Doe"},{"displayName":"john.doe@example.org"}]`,
+		},
+		Recorder: recorder,
+	}).Send(&transport.PayloadRequest{
+		Method: "GET",
+		Url:    "/rest/api/latest/user/search?query=jane.doe%40example.org",
+	})
+
+	_, _ = (&CassettePayloadTransport{
+		Transport: &cannedPayloadTransport{body: `{"token":"api-token"}`},
+		Recorder:  recorder,
+	}).SendWithExpectedStatus(&transport.PayloadRequest{
+		Method: "POST",
+		Url:    "/rest/api/latest/project/TEST/role/10002",
+		Payload: transport.JsonPayloadData{
+			Payload: map[string][]string{"user": {"557058:32b276cf-1a9f-45ae-b3f5-f850bc24f1b9", "63a3c890030d706ab0e2c4e3"}},
+		},
+	}, 200)
+
+	files, _ := os.ReadDir(directory)
+	assert.Len(t, files, 2)
+	assert.Equal(t, "0--rest-api-latest-user-search-query-user-1-40example.com.json", files[0].Name())
+	assert.Equal(t, "1--rest-api-latest-project-TEST-role-10002.json", files[1].Name())
+
+	var search, role Cassette
+	content, _ := os.ReadFile(filepath.Join(directory, files[0].Name()))
+	assert.Nil(t, json.Unmarshal(content, &search))
+	assert.Equal(t, "/rest/api/latest/user/search?query=user-1%40example.com", search.Url)
+	assert.JSONEq(t, `[{"self":"https://example.atlassian.net/rest/api/3/user?accountId=account-1",`+
+		`"accountId":"account-1","emailAddress":"user-1@example.com",`+
+		`"avatarUrls":{"48x48":"https://avatar.example.com/avatar-1.png"},`+
+		`"displayName":"User 1"},{"displayName":"user-2@example.com"}]`, string(search.ResponseBody))
+
+	content, _ = os.ReadFile(filepath.Join(directory, files[1].Name()))
+	assert.Nil(t, json.Unmarshal(content, &role))
+	assert.Equal(t, "POST", role.Method)
+	assert.JSONEq(t, `{"user":["account-1","account-2"]}`, string(role.RequestBody))
+	assert.JSONEq(t, `{"token":"***"}`, string(role.ResponseBody))
+	assert.False(t, strings.Contains(string(content), "api-token"))
+}
+
+func TestCassetteRecorder_ContinuesNumbering(t *testing.T) {
+	directory := t.TempDir()
+	request := &transport.PayloadRequest{Method: "GET", Url: "/rest/api/latest/myself"}
+
+	assert.Nil(t, NewCassetteRecorder(directory).Record(request, nil))
+	assert.Nil(t, NewCassetteRecorder(directory).Record(request, nil))
+	assert.Nil(t, os.WriteFile(filepath.Join(directory, "README.md"), nil, 0644))
+	assert.Nil(t, NewCassetteRecorder(directory).Record(request, nil))
+
+	_, err := os.Stat(filepath.Join(directory, "0--rest-api-latest-myself.json"))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(directory, "1--rest-api-latest-myself.json"))
+	assert.Nil(t, err)
+	_, err = os.Stat(filepath.Join(directory, "2--rest-api-latest-myself.json"))
+	assert.Nil(t, err)
+}
+
+func TestCassettePayloadTransport_LogsFailure(t *testing.T) {
+	t.Setenv(LogLevelEnv, "WARN")
+
+	// a directory cannot be created below a file
+	parent := filepath.Join(t.TempDir(), "file")
+	assert.Nil(t, os.WriteFile(parent, nil, 0644))
+
+	var output bytes.Buffer
+	reply, err := (&CassettePayloadTransport{
+		Transport: &cannedPayloadTransport{body: `{}`},
+		Recorder:  NewCassetteRecorder(filepath.Join(parent, "collect")),
+		Context:   NewLoggingContext(tflogtest.RootLogger(context.Background(), &output), "jira"),
+		Subsystem: "jira",
+	}).Send(&transport.PayloadRequest{Method: "GET", Url: "/rest/api/latest/myself"})
+	assert.Nil(t, err)
+	assert.Equal(t, 200, reply.StatusCode)
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	assert.Nil(t, err)
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "Failed to record cassette", entries[0]["@message"])
+		assert.Equal(t, "/rest/api/latest/myself", entries[0]["path"])
+	}
+}
+
+func TestCassetteBody(t *testing.T) {
+	assert.Equal(t, `{"id":1}`, string(NewCassetteBody(`{"id":1}`)))
+	assert.Equal(t, "plain text", CassetteBodyString(NewCassetteBody("plain text")))
+	assert.Equal(t, `{"id":1}`, CassetteBodyString(NewCassetteBody(`{"id":1}`)))
+	assert.Nil(t, NewCassetteBody(""))
+}
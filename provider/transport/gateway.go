@@ -0,0 +1,102 @@
+package transport
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"net/http"
+	"strings"
+)
+
+const GatewayUrl = "https://api.atlassian.com"
+
+// GatewayPayloadTransport sends requests through the api.atlassian.com gateway using a bearer token.
+//
+// The Jira and Confluence clients build site relative URLs, so the gateway product is chosen from
+// the request path: /wiki/... goes to /ex/confluence/{cloudId} and everything else, including the
+// Jira user and group APIs used by the Confluence client, goes to /ex/jira/{cloudId}.
+type GatewayPayloadTransport struct {
+	GatewayUrl  string
+	CloudId     string
+	TokenSource TokenSource
+}
+
+var _ transport.PayloadTransport = &GatewayPayloadTransport{}
+
+func NewGatewayPayloadTransport(cloudId string, tokenSource TokenSource) *GatewayPayloadTransport {
+	return &GatewayPayloadTransport{
+		GatewayUrl:  GatewayUrl,
+		CloudId:     cloudId,
+		TokenSource: tokenSource,
+	}
+}
+
+func (g *GatewayPayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	return g.send(request, func(httpTransport *HttpPayloadTransport) (*transport.PayloadResponse, error) {
+		return httpTransport.Send(request)
+	})
+}
+
+func (g *GatewayPayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	return g.send(request, func(httpTransport *HttpPayloadTransport) (*transport.PayloadResponse, error) {
+		return httpTransport.SendWithExpectedStatus(request, expectedStatus...)
+	})
+}
+
+func (g *GatewayPayloadTransport) send(request *transport.PayloadRequest, send func(*HttpPayloadTransport) (*transport.PayloadResponse, error)) (*transport.PayloadResponse, error) {
+	for attempt := 0; ; attempt++ {
+		token, err := g.TokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		reply, err := send(NewHttpPayloadTransport(g.baseUrl(request.Url), transport.BearerAuthentication{
+			Token: token,
+		}))
+
+		// the token may be revoked before its expiry, retry once with a freshly issued token
+		if reply != nil && reply.StatusCode == http.StatusUnauthorized && attempt == 0 && g.TokenSource.Invalidate() {
+			continue
+		}
+
+		return reply, err
+	}
+}
+
+func (g *GatewayPayloadTransport) baseUrl(requestUrl string) string {
+	product := "jira"
+	if strings.HasPrefix(requestUrl, "/wiki/") {
+		product = "confluence"
+	}
+
+	return fmt.Sprintf("%s/ex/%s/%s", g.GatewayUrl, product, g.CloudId)
+}
+
+type tenantInfo struct {
+	CloudId string `json:"cloudId"`
+}
+
+// ReadCloudId looks up the cloud ID of a site such as https://example.atlassian.net.
+func ReadCloudId(endpoint string) (string, error) {
+	httpResponse, err := http.Get(strings.TrimSuffix(endpoint, "/") + "/_edge/tenant_info")
+	if err != nil {
+		return "", err
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to read tenant info of %s, status %d", endpoint, httpResponse.StatusCode)
+	}
+
+	var info tenantInfo
+	err = json.NewDecoder(httpResponse.Body).Decode(&info)
+	if err != nil {
+		return "", err
+	}
+
+	if info.CloudId == "" {
+		return "", fmt.Errorf("tenant info of %s does not contain a cloud ID", endpoint)
+	}
+
+	return info.CloudId, nil
+}
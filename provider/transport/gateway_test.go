@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGatewayPayloadTransport_RoutesByProduct(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		paths = append(paths, request.URL.Path)
+		assert.Equal(t, "Bearer scoped", request.Header.Get("Authorization"))
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	gateway := NewGatewayPayloadTransport("cloud", StaticToken("scoped"))
+	gateway.GatewayUrl = server.URL
+
+	_, err := gateway.SendWithExpectedStatus(&transport.PayloadRequest{Method: http.MethodGet, Url: "/rest/api/latest/project/KEY"}, 200)
+	assert.Nil(t, err)
+	_, err = gateway.SendWithExpectedStatus(&transport.PayloadRequest{Method: http.MethodGet, Url: "/wiki/rest/api/space"}, 200)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{
+		"/ex/jira/cloud/rest/api/latest/project/KEY",
+		"/ex/confluence/cloud/wiki/rest/api/space",
+	}, paths)
+}
+
+func TestGatewayPayloadTransport_RefreshesRevokedToken(t *testing.T) {
+	var issued atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		count := issued.Add(1)
+		_, _ = writer.Write([]byte(fmt.Sprintf(`{"access_token":"token-%d","expires_in":3600}`, count)))
+	}))
+	defer tokenServer.Close()
+
+	apiServer := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.Header.Get("Authorization") != "Bearer token-2" {
+			writer.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writer.WriteHeader(http.StatusOK)
+	}))
+	defer apiServer.Close()
+
+	token := NewClientCredentialsToken("id", "secret")
+	token.TokenUrl = tokenServer.URL
+
+	gateway := NewGatewayPayloadTransport("cloud", token)
+	gateway.GatewayUrl = apiServer.URL
+
+	reply, err := gateway.SendWithExpectedStatus(&transport.PayloadRequest{Method: http.MethodGet, Url: "/rest/api/latest/myself"}, 200)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, reply.StatusCode)
+	assert.Equal(t, int32(2), issued.Load())
+}
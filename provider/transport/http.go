@@ -0,0 +1,187 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseError is returned for responses with an unexpected status code.
+// Throttled and failed responses always produce it, so decorating transports can react to them.
+type ResponseError struct {
+	StatusCode int
+	Body       string
+	// RetryAfter is the wait requested by the server through the Retry-After header, zero when absent.
+	RetryAfter time.Duration
+}
+
+func (e ResponseError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("unexpected status %d", e.StatusCode)
+	}
+
+	return e.Body
+}
+
+var _ error = ResponseError{}
+
+// NewPayloadResponse converts an HTTP response into a payload response. Throttled (429) and
+// server error (5xx) responses are returned together with a ResponseError.
+func NewPayloadResponse(statusCode int, header http.Header, body string) (*transport.PayloadResponse, error) {
+	reply := &transport.PayloadResponse{
+		StatusCode: statusCode,
+		Body:       body,
+	}
+
+	if statusCode == http.StatusTooManyRequests || statusCode >= 500 {
+		return reply, ResponseError{
+			StatusCode: statusCode,
+			Body:       body,
+			RetryAfter: parseRetryAfter(header.Get("Retry-After")),
+		}
+	}
+
+	return reply, nil
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+
+	return 0
+}
+
+// HttpPayloadTransport sends requests to a site with the credentials of the provider.
+// Unlike the HTTP transport of the API library it sees the response headers, so throttled (429) and
+// server error (5xx) responses come with a ResponseError that carries the Retry-After of the server.
+type HttpPayloadTransport struct {
+	BaseUrl        string
+	Authentication transport.Authentication
+	// Client sends the requests, http.DefaultClient when nil.
+	Client *http.Client
+}
+
+var _ transport.PayloadTransport = &HttpPayloadTransport{}
+
+func NewHttpPayloadTransport(baseUrl string, authentication transport.Authentication) *HttpPayloadTransport {
+	return &HttpPayloadTransport{
+		BaseUrl:        baseUrl,
+		Authentication: authentication,
+	}
+}
+
+func (h *HttpPayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	var body io.Reader
+	if request.Payload != nil {
+		content, err := request.Payload.Content()
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(content)
+	}
+
+	httpRequest, err := http.NewRequest(request.Method, h.BaseUrl+request.Url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch authentication := h.Authentication.(type) {
+	case transport.BasicAuthentication:
+		httpRequest.SetBasicAuth(authentication.Username, authentication.Password)
+	case transport.BearerAuthentication:
+		httpRequest.Header.Set("Authorization", "Bearer "+authentication.Token)
+	}
+
+	for key, value := range request.Headers {
+		httpRequest.Header.Set(key, value)
+	}
+
+	if request.Payload != nil {
+		httpRequest.Header.Set("Content-Type", request.Payload.ContentType())
+		httpRequest.Header.Set("Accept", request.Payload.Accept())
+	} else {
+		httpRequest.Header.Set("Accept", "application/json")
+	}
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	httpResponse, err := client.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+
+	content, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPayloadResponse(httpResponse.StatusCode, httpResponse.Header, string(content))
+}
+
+func (h *HttpPayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	reply, err := h.Send(request)
+	if err != nil || reply == nil {
+		return reply, err
+	}
+
+	return ExpectStatus(reply, expectedStatus...)
+}
+
+// StatusPayloadTransport returns a ResponseError together with throttled (429) and server error (5xx) responses,
+// and with responses of an unexpected status, so that decorating transports can react to them. A transport that
+// knows the response headers, such as HttpPayloadTransport, returns the ResponseError itself with RetryAfter.
+type StatusPayloadTransport struct {
+	Transport transport.PayloadTransport
+}
+
+var _ transport.PayloadTransport = &StatusPayloadTransport{}
+
+func (s *StatusPayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	reply, err := s.Transport.Send(request)
+	if err != nil || reply == nil {
+		return reply, err
+	}
+
+	return NewPayloadResponse(reply.StatusCode, http.Header{}, reply.Body)
+}
+
+func (s *StatusPayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	// the status is checked here rather than by the transport, which returns untyped errors
+	reply, err := s.Send(request)
+	if err != nil || reply == nil {
+		return reply, err
+	}
+
+	return ExpectStatus(reply, expectedStatus...)
+}
+
+// ExpectStatus returns a ResponseError when the reply status is not one of the expected ones.
+func ExpectStatus(reply *transport.PayloadResponse, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	for _, status := range expectedStatus {
+		if status == reply.StatusCode {
+			return reply, nil
+		}
+	}
+
+	return reply, ResponseError{
+		StatusCode: reply.StatusCode,
+		Body:       reply.Body,
+	}
+}
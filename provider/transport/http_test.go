@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"net/http"
+	"testing"
+)
+
+type statusPayloadTransport struct {
+	status int
+}
+
+func (s *statusPayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	return &transport.PayloadResponse{StatusCode: s.status, Body: http.StatusText(s.status)}, nil
+}
+
+func (s *statusPayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	return s.Send(request)
+}
+
+func TestStatusPayloadTransport(t *testing.T) {
+	request := &transport.PayloadRequest{Method: "GET", Url: "/rest/api/latest/myself"}
+
+	reply, err := (&StatusPayloadTransport{Transport: &statusPayloadTransport{status: http.StatusOK}}).Send(request)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, reply.StatusCode)
+
+	var responseError ResponseError
+	_, err = (&StatusPayloadTransport{Transport: &statusPayloadTransport{status: http.StatusBadGateway}}).Send(request)
+	assert.ErrorAs(t, err, &responseError)
+	assert.Equal(t, http.StatusBadGateway, responseError.StatusCode)
+
+	_, err = (&StatusPayloadTransport{Transport: &statusPayloadTransport{status: http.StatusNotFound}}).SendWithExpectedStatus(request, http.StatusOK)
+	assert.ErrorAs(t, err, &responseError)
+	assert.Equal(t, http.StatusNotFound, responseError.StatusCode)
+}
@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"context"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"golang.org/x/time/rate"
+	"math"
+)
+
+// RateLimiter caps the request rate and the number of requests in flight.
+// A single limiter is shared by every client of a provider so that one apply stays within its budget.
+type RateLimiter struct {
+	limiter *rate.Limiter
+	slots   chan struct{}
+}
+
+// NewRateLimiter creates a limiter, zero requestsPerSecond or maxConcurrent disables the respective limit.
+func NewRateLimiter(requestsPerSecond float64, maxConcurrent int) *RateLimiter {
+	limiter := &RateLimiter{}
+
+	if requestsPerSecond > 0 {
+		limiter.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), int(math.Max(1, math.Ceil(requestsPerSecond))))
+	}
+
+	if maxConcurrent > 0 {
+		limiter.slots = make(chan struct{}, maxConcurrent)
+	}
+
+	return limiter
+}
+
+// acquire blocks until the request may be sent, the returned function releases the concurrency slot.
+// It gives up with the error of ctx once ctx is done, without holding a slot.
+func (l *RateLimiter) acquire(ctx context.Context) (func(), error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	if l.limiter != nil {
+		if err := l.limiter.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// LimitedPayloadTransport sends requests through a shared RateLimiter.
+// A request still waiting for the limiter once Context is done is not sent, and fails with the error of Context.
+type LimitedPayloadTransport struct {
+	Transport transport.PayloadTransport
+	Limiter   *RateLimiter
+	Context   context.Context
+}
+
+var _ transport.PayloadTransport = &LimitedPayloadTransport{}
+
+func (l *LimitedPayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	release, err := l.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return l.Transport.Send(request)
+}
+
+func (l *LimitedPayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	release, err := l.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return l.Transport.SendWithExpectedStatus(request, expectedStatus...)
+}
+
+func (l *LimitedPayloadTransport) acquire() (func(), error) {
+	ctx := l.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return l.Limiter.acquire(ctx)
+}
@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type slowPayloadTransport struct {
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (s *slowPayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	current := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+
+	for {
+		observed := s.maxInFlight.Load()
+		if current <= observed || s.maxInFlight.CompareAndSwap(observed, current) {
+			break
+		}
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	return &transport.PayloadResponse{StatusCode: 200}, nil
+}
+
+func (s *slowPayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	return s.Send(request)
+}
+
+func TestLimitedPayloadTransport_CapsConcurrency(t *testing.T) {
+	inner := &slowPayloadTransport{}
+	limited := &LimitedPayloadTransport{
+		Transport: inner,
+		Limiter:   NewRateLimiter(0, 2),
+	}
+
+	wg := new(sync.WaitGroup)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = limited.Send(&transport.PayloadRequest{Method: "GET", Url: "/"})
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), inner.maxInFlight.Load())
+}
+
+func TestLimitedPayloadTransport_LimitsRate(t *testing.T) {
+	limited := &LimitedPayloadTransport{
+		Transport: &slowPayloadTransport{},
+		Limiter:   NewRateLimiter(100, 0),
+	}
+
+	start := time.Now()
+	for i := 0; i < 120; i++ {
+		_, _ = limited.Send(&transport.PayloadRequest{Method: "GET", Url: "/"})
+	}
+
+	// the burst of 100 passes immediately, the remaining 20 requests need at least 200ms
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+}
+
+func TestLimitedPayloadTransport_Cancelled(t *testing.T) {
+	inner := &slowPayloadTransport{}
+	ctx, cancel := context.WithCancel(context.Background())
+	limited := &LimitedPayloadTransport{
+		Transport: inner,
+		Limiter:   NewRateLimiter(1, 1),
+		Context:   ctx,
+	}
+
+	// the burst of one passes, the next request waits a second for the limiter until it is cancelled
+	_, err := limited.Send(&transport.PayloadRequest{Method: "GET", Url: "/"})
+	assert.Nil(t, err)
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	reply, err := limited.Send(&transport.PayloadRequest{Method: "GET", Url: "/"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, reply)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, int32(1), inner.maxInFlight.Load())
+
+	// the slot of the cancelled request is released
+	assert.Len(t, limited.Limiter.slots, 0)
+}
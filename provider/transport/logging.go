@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// LogLevelEnv controls the level of the jira and confluence log subsystems.
+const LogLevelEnv = "TF_LOG_PROVIDER_ATLASSIAN"
+
+const redacted = "***"
+
+var (
+	sensitiveHeaders = []string{"Authorization", "Cookie"}
+
+	// sensitiveFields matches JSON string values of keys that carry credentials
+	sensitiveFields = regexp.MustCompile(`("(?i:token|access_token|refresh_token|client_secret|password|api_token)"\s*:\s*)"[^"]*"`)
+)
+
+// NewLoggingContext creates the log subsystem for a product, the given secrets are masked wherever they appear.
+// The context must carry the provider root logger, such as the one given to Configure.
+func NewLoggingContext(ctx context.Context, subsystem string, secrets ...string) context.Context {
+	ctx = tflog.NewSubsystem(ctx, subsystem, tflog.WithLevelFromEnv(LogLevelEnv))
+
+	var maskedSecrets []string
+	for _, secret := range secrets {
+		if secret != "" {
+			maskedSecrets = append(maskedSecrets, secret)
+		}
+	}
+	if len(maskedSecrets) > 0 {
+		ctx = tflog.SubsystemMaskAllFieldValuesStrings(ctx, subsystem, maskedSecrets...)
+		ctx = tflog.SubsystemMaskMessageStrings(ctx, subsystem, maskedSecrets...)
+	}
+
+	return ctx
+}
+
+// LoggingPayloadTransport writes every request and response to a tflog subsystem, with the method,
+// path, status, latency and the body where credentials are masked.
+type LoggingPayloadTransport struct {
+	Transport transport.PayloadTransport
+	Context   context.Context
+	Subsystem string
+}
+
+var _ transport.PayloadTransport = &LoggingPayloadTransport{}
+
+func (l *LoggingPayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	return l.log(request, func() (*transport.PayloadResponse, error) {
+		return l.Transport.Send(request)
+	})
+}
+
+func (l *LoggingPayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	return l.log(request, func() (*transport.PayloadResponse, error) {
+		return l.Transport.SendWithExpectedStatus(request, expectedStatus...)
+	})
+}
+
+func (l *LoggingPayloadTransport) log(request *transport.PayloadRequest, send func() (*transport.PayloadResponse, error)) (*transport.PayloadResponse, error) {
+	tflog.SubsystemDebug(l.Context, l.Subsystem, "Sending request", map[string]interface{}{
+		"method":  request.Method,
+		"path":    request.Url,
+		"headers": redactHeaders(request.Headers),
+		"body":    requestBody(request),
+	})
+
+	start := time.Now()
+	reply, err := send()
+
+	fields := map[string]interface{}{
+		"method":     request.Method,
+		"path":       request.Url,
+		"latency_ms": time.Since(start).Milliseconds(),
+	}
+	if reply != nil {
+		fields["status"] = reply.StatusCode
+		fields["body"] = RedactBody(reply.Body)
+	}
+	if err != nil {
+		fields["error"] = RedactBody(err.Error())
+	}
+
+	tflog.SubsystemDebug(l.Context, l.Subsystem, "Received response", fields)
+	return reply, err
+}
+
+// RedactBody masks the values of credential fields in a JSON body.
+func RedactBody(body string) string {
+	return sensitiveFields.ReplaceAllString(body, `$1"`+redacted+`"`)
+}
+
+func redactHeaders(headers map[string]string) map[string]string {
+	redactedHeaders := make(map[string]string, len(headers))
+	for key, value := range headers {
+		redactedHeaders[key] = value
+		for _, sensitive := range sensitiveHeaders {
+			if http.CanonicalHeaderKey(key) == sensitive {
+				redactedHeaders[key] = redacted
+			}
+		}
+	}
+
+	return redactedHeaders
+}
+
+// requestBody returns the JSON payload of a request, other payloads such as multipart uploads are not logged
+// as reading their content has side effects.
+func requestBody(request *transport.PayloadRequest) string {
+	payload, ok := request.Payload.(transport.JsonPayloadData)
+	if !ok {
+		return ""
+	}
+
+	content, err := payload.Content()
+	if err != nil {
+		return ""
+	}
+
+	return RedactBody(string(content))
+}
@@ -0,0 +1,80 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"strings"
+	"testing"
+)
+
+type echoPayloadTransport struct{}
+
+func (e *echoPayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	return &transport.PayloadResponse{
+		StatusCode: 200,
+		Body:       `{"access_token":"issued-token","accountId":"557058:abc"}`,
+	}, nil
+}
+
+func (e *echoPayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	return e.Send(request)
+}
+
+func TestLoggingPayloadTransport(t *testing.T) {
+	t.Setenv(LogLevelEnv, "DEBUG")
+
+	var output bytes.Buffer
+	ctx := NewLoggingContext(tflogtest.RootLogger(context.Background(), &output), "jira", "api-token")
+
+	logging := &LoggingPayloadTransport{
+		Transport: &echoPayloadTransport{},
+		Context:   ctx,
+		Subsystem: "jira",
+	}
+
+	_, _ = logging.Send(&transport.PayloadRequest{
+		Method: "POST",
+		Url:    "/rest/api/latest/project",
+		Headers: map[string]string{
+			"authorization": "Basic api-token",
+		},
+		Payload: transport.JsonPayloadData{
+			Payload: map[string]string{"key": "TEST", "password": "secret", "note": "uses api-token"},
+		},
+	})
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	assert.Nil(t, err)
+	assert.Len(t, entries, 2)
+
+	assert.Equal(t, "Sending request", entries[0]["@message"])
+	assert.Equal(t, "POST", entries[0]["method"])
+	assert.Equal(t, "/rest/api/latest/project", entries[0]["path"])
+	assert.Equal(t, "Received response", entries[1]["@message"])
+	assert.Equal(t, float64(200), entries[1]["status"])
+	assert.Contains(t, entries[1], "latency_ms")
+
+	logged := output.String()
+	for _, secret := range []string{"api-token", "secret", "issued-token"} {
+		assert.False(t, strings.Contains(logged, secret), "%s is logged", secret)
+	}
+}
+
+func TestLoggingPayloadTransport_LevelFromEnv(t *testing.T) {
+	t.Setenv(LogLevelEnv, "WARN")
+
+	var output bytes.Buffer
+	ctx := NewLoggingContext(tflogtest.RootLogger(context.Background(), &output), "confluence")
+
+	logging := &LoggingPayloadTransport{
+		Transport: &echoPayloadTransport{},
+		Context:   ctx,
+		Subsystem: "confluence",
+	}
+
+	_, _ = logging.Send(&transport.PayloadRequest{Method: "GET", Url: "/wiki/rest/api/space"})
+	assert.Empty(t, output.String())
+}
@@ -0,0 +1,42 @@
+package transport
+
+import (
+	"fmt"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"net/http"
+)
+
+// ReadOnlyError is returned for a request refused by ReadOnlyPayloadTransport.
+type ReadOnlyError struct {
+	Method string
+	Url    string
+}
+
+func (e ReadOnlyError) Error() string {
+	return fmt.Sprintf("read only provider refused %s %s", e.Method, e.Url)
+}
+
+var _ error = ReadOnlyError{}
+
+// ReadOnlyPayloadTransport refuses every request except GET, so that nothing sent through it can change the site.
+type ReadOnlyPayloadTransport struct {
+	Transport transport.PayloadTransport
+}
+
+var _ transport.PayloadTransport = &ReadOnlyPayloadTransport{}
+
+func (r *ReadOnlyPayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	if request.Method != http.MethodGet {
+		return nil, ReadOnlyError{Method: request.Method, Url: request.Url}
+	}
+
+	return r.Transport.Send(request)
+}
+
+func (r *ReadOnlyPayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	if request.Method != http.MethodGet {
+		return nil, ReadOnlyError{Method: request.Method, Url: request.Url}
+	}
+
+	return r.Transport.SendWithExpectedStatus(request, expectedStatus...)
+}
@@ -0,0 +1,25 @@
+package transport
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"net/http"
+	"testing"
+)
+
+func TestReadOnlyPayloadTransport(t *testing.T) {
+	readOnly := &ReadOnlyPayloadTransport{Transport: &cannedPayloadTransport{body: `{}`}}
+
+	reply, err := readOnly.SendWithExpectedStatus(&transport.PayloadRequest{Method: http.MethodGet, Url: "/rest/api/latest/project/TEST"}, 200)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, reply.StatusCode)
+
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch} {
+		reply, err = readOnly.Send(&transport.PayloadRequest{Method: method, Url: "/rest/api/latest/project/TEST"})
+		assert.Nil(t, reply)
+
+		var readOnlyError ReadOnlyError
+		assert.ErrorAs(t, err, &readOnlyError)
+		assert.Equal(t, method, readOnlyError.Method)
+	}
+}
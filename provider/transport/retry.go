@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"github.com/yunarta/terraform-api-transport/transport"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+const (
+	DefaultMaxRetries   = 4
+	DefaultRetryMaxWait = 30 * time.Second
+
+	retryBaseWait = 500 * time.Millisecond
+)
+
+// RetryPayloadTransport retries throttled (429) and server error (5xx) responses of GET, PUT and DELETE requests,
+// which may safely be sent again. Other requests such as POST are only retried when throttled with Retry-After, as
+// they were then refused before any change was made.
+// Throttled requests wait for the duration given by Retry-After, other failures use jittered
+// exponential backoff. No single wait exceeds MaxWait, and a wait ends early once Context is done.
+type RetryPayloadTransport struct {
+	Transport  transport.PayloadTransport
+	MaxRetries int
+	MaxWait    time.Duration
+	Context    context.Context
+}
+
+var _ transport.PayloadTransport = &RetryPayloadTransport{}
+
+func (r *RetryPayloadTransport) Send(request *transport.PayloadRequest) (*transport.PayloadResponse, error) {
+	return r.retry(request, func() (*transport.PayloadResponse, error) {
+		return r.Transport.Send(request)
+	})
+}
+
+func (r *RetryPayloadTransport) SendWithExpectedStatus(request *transport.PayloadRequest, expectedStatus ...int) (*transport.PayloadResponse, error) {
+	return r.retry(request, func() (*transport.PayloadResponse, error) {
+		return r.Transport.SendWithExpectedStatus(request, expectedStatus...)
+	})
+}
+
+func (r *RetryPayloadTransport) retry(request *transport.PayloadRequest, send func() (*transport.PayloadResponse, error)) (*transport.PayloadResponse, error) {
+	ctx := r.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	for attempt := 0; ; attempt++ {
+		reply, err := send()
+		if attempt >= r.MaxRetries || !isRetryable(request.Method, reply, err) {
+			return reply, err
+		}
+
+		timer := time.NewTimer(r.wait(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return reply, err
+		case <-timer.C:
+		}
+	}
+}
+
+func isRetryable(method string, reply *transport.PayloadResponse, err error) bool {
+	if reply == nil {
+		return false
+	}
+
+	throttled := reply.StatusCode == http.StatusTooManyRequests
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return throttled || (reply.StatusCode >= 500 && reply.StatusCode != http.StatusNotImplemented)
+	default:
+		var responseError ResponseError
+		return throttled && errors.As(err, &responseError) && responseError.RetryAfter > 0
+	}
+}
+
+func (r *RetryPayloadTransport) wait(attempt int, err error) time.Duration {
+	var responseError ResponseError
+	if errors.As(err, &responseError) && responseError.RetryAfter > 0 {
+		return min(responseError.RetryAfter, r.MaxWait)
+	}
+
+	backoff := min(retryBaseWait<<attempt, r.MaxWait)
+	// equal jitter keeps at least half of the backoff while spreading parallel retries apart
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
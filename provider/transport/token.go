@@ -0,0 +1,120 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const OAuthTokenUrl = "https://auth.atlassian.com/oauth/token"
+
+// TokenSource provides the bearer token sent to the Atlassian API gateway.
+type TokenSource interface {
+	Token() (string, error)
+	// Invalidate discards the current token and reports whether a new one can be issued.
+	Invalidate() bool
+}
+
+// StaticToken is a scoped API token that never changes.
+type StaticToken string
+
+var _ TokenSource = StaticToken("")
+
+func (s StaticToken) Token() (string, error) {
+	return string(s), nil
+}
+
+func (s StaticToken) Invalidate() bool {
+	return false
+}
+
+// ClientCredentialsToken issues OAuth 2.0 access tokens with the client credentials grant
+// and refreshes them shortly before they expire.
+type ClientCredentialsToken struct {
+	ClientId     string
+	ClientSecret string
+	TokenUrl     string
+
+	mutex       sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+var _ TokenSource = &ClientCredentialsToken{}
+
+type clientCredentialsRequest struct {
+	GrantType    string `json:"grant_type"`
+	ClientId     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Audience     string `json:"audience"`
+}
+
+type clientCredentialsResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func NewClientCredentialsToken(clientId, clientSecret string) *ClientCredentialsToken {
+	return &ClientCredentialsToken{
+		ClientId:     clientId,
+		ClientSecret: clientSecret,
+		TokenUrl:     OAuthTokenUrl,
+	}
+}
+
+func (c *ClientCredentialsToken) Token() (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	// refresh a minute early so a token never expires while a request is in flight
+	if c.accessToken != "" && time.Now().Add(time.Minute).Before(c.expiresAt) {
+		return c.accessToken, nil
+	}
+
+	content, err := json.Marshal(clientCredentialsRequest{
+		GrantType:    "client_credentials",
+		ClientId:     c.ClientId,
+		ClientSecret: c.ClientSecret,
+		Audience:     "api.atlassian.com",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	httpResponse, err := http.Post(c.TokenUrl, "application/json", bytes.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+	defer httpResponse.Body.Close()
+
+	body, err := io.ReadAll(httpResponse.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to issue OAuth token, status %d: %s", httpResponse.StatusCode, string(body))
+	}
+
+	var response clientCredentialsResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return "", err
+	}
+
+	c.accessToken = response.AccessToken
+	c.expiresAt = time.Now().Add(time.Duration(response.ExpiresIn) * time.Second)
+	return c.accessToken, nil
+}
+
+func (c *ClientCredentialsToken) Invalidate() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.accessToken = ""
+	return true
+}